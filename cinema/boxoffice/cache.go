@@ -0,0 +1,269 @@
+package boxoffice
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheEntry is a cached lookup result. A nil Record marks a negative entry,
+// i.e. the provider answered ErrNotFound.
+type CacheEntry struct {
+	Record    *Record
+	ExpiresAt time.Time
+}
+
+// CacheStore is an optional second-level cache shared between instances.
+// Get returns (nil, nil) when the key is absent or expired.
+type CacheStore interface {
+	Get(ctx context.Context, key string) (*CacheEntry, error)
+	Put(ctx context.Context, key string, entry CacheEntry) error
+}
+
+type CacheOptions struct {
+	// Capacity bounds the in-memory LRU. Defaults to 1024 entries.
+	Capacity int
+	// TTL applies to successful lookups. Defaults to 24h.
+	TTL time.Duration
+	// NegativeTTL applies to ErrNotFound answers. Defaults to 1h.
+	NegativeTTL time.Duration
+	// Store, when set, is consulted on an in-memory miss and written through.
+	Store CacheStore
+}
+
+// CacheStats counts lookups by outcome: Hits and StoreHits are positive answers
+// served from memory and from the store respectively, NegativeHits cached
+// ErrNotFound answers, and Misses upstream calls.
+type CacheStats struct {
+	Hits         uint64
+	NegativeHits uint64
+	StoreHits    uint64
+	Misses       uint64
+	Evictions    uint64
+	Entries      int
+}
+
+// CachingClient decorates a Client with a TTL-bound LRU cache so that repeated
+// lookups for the same title stay within the provider's quota. Transient
+// errors are never cached.
+type CachingClient struct {
+	next Client
+	opts CacheOptions
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+
+	hits, negativeHits, storeHits, misses, evictions atomic.Uint64
+}
+
+type cacheItem struct {
+	key   string
+	entry CacheEntry
+}
+
+func NewCachingClient(next Client, opts CacheOptions) *CachingClient {
+	if opts.Capacity <= 0 {
+		opts.Capacity = 1024
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 24 * time.Hour
+	}
+	if opts.NegativeTTL <= 0 {
+		opts.NegativeTTL = time.Hour
+	}
+
+	return &CachingClient{
+		next:    next,
+		opts:    opts,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *CachingClient) Fetch(ctx context.Context, title string) (*Record, error) {
+	key := lookupKey(ctx, title)
+	if key == "" {
+		return nil, ErrInvalidTitle
+	}
+
+	if entry, ok := c.lookup(key); ok {
+		return c.answer(entry, &c.hits)
+	}
+
+	if c.opts.Store != nil {
+		entry, err := c.opts.Store.Get(ctx, key)
+		switch {
+		case err != nil:
+			log.Printf("box office cache store read failed: %v", err)
+		case entry != nil && c.now().Before(entry.ExpiresAt):
+			c.remember(key, *entry)
+			return c.answer(*entry, &c.storeHits)
+		}
+	}
+
+	return c.fetchAndStore(ctx, key, title)
+}
+
+// FetchFresh skips cached answers, e.g. for an explicit refresh, and caches
+// the provider's answer like Fetch.
+func (c *CachingClient) FetchFresh(ctx context.Context, title string) (*Record, error) {
+	key := lookupKey(ctx, title)
+	if key == "" {
+		return nil, ErrInvalidTitle
+	}
+
+	return c.fetchAndStore(ctx, key, title)
+}
+
+func (c *CachingClient) fetchAndStore(ctx context.Context, key, title string) (*Record, error) {
+	c.misses.Add(1)
+	record, err := c.next.Fetch(ctx, title)
+	switch {
+	case err == nil && record != nil:
+		c.save(ctx, key, CacheEntry{Record: record, ExpiresAt: c.now().Add(c.opts.TTL)})
+		return cloneRecord(record), nil
+	case errors.Is(err, ErrNotFound):
+		c.save(ctx, key, CacheEntry{ExpiresAt: c.now().Add(c.opts.NegativeTTL)})
+	}
+	return record, err
+}
+
+func (c *CachingClient) Stats() CacheStats {
+	c.mu.Lock()
+	entries := c.order.Len()
+	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:         c.hits.Load(),
+		NegativeHits: c.negativeHits.Load(),
+		StoreHits:    c.storeHits.Load(),
+		Misses:       c.misses.Load(),
+		Evictions:    c.evictions.Load(),
+		Entries:      entries,
+	}
+}
+
+// answer turns a cached entry into a Fetch result, counting it under hits
+// unless it is a negative entry.
+func (c *CachingClient) answer(entry CacheEntry, hits *atomic.Uint64) (*Record, error) {
+	if entry.Record == nil {
+		c.negativeHits.Add(1)
+		return nil, ErrNotFound
+	}
+	hits.Add(1)
+	return cloneRecord(entry.Record), nil
+}
+
+func (c *CachingClient) lookup(key string) (CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+
+	item := elem.Value.(*cacheItem)
+	if !c.now().Before(item.entry.ExpiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return CacheEntry{}, false
+	}
+
+	c.order.MoveToFront(elem)
+	return item.entry, true
+}
+
+func (c *CachingClient) save(ctx context.Context, key string, entry CacheEntry) {
+	c.remember(key, entry)
+
+	if c.opts.Store != nil {
+		if err := c.opts.Store.Put(ctx, key, entry); err != nil {
+			log.Printf("box office cache store write failed: %v", err)
+		}
+	}
+}
+
+func (c *CachingClient) remember(key string, entry CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheItem).entry = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheItem{key: key, entry: entry})
+	for c.order.Len() > c.opts.Capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheItem).key)
+		c.evictions.Add(1)
+	}
+}
+
+// cacheKey normalises case and whitespace so that trivially different
+// spellings of a title share an entry.
+func cacheKey(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// lookupKey extends cacheKey with the release year passed via
+// WithReleaseYear, since the year can change which record matches.
+func lookupKey(ctx context.Context, title string) string {
+	key := cacheKey(title)
+	if year, ok := releaseYear(ctx); ok && key != "" {
+		key += " (" + strconv.Itoa(year) + ")"
+	}
+	return key
+}
+
+// cloneRecord copies the pointer fields so callers cannot mutate cached data.
+func cloneRecord(record *Record) *Record {
+	copied := *record
+	if record.Distributor != nil {
+		distributor := *record.Distributor
+		copied.Distributor = &distributor
+	}
+	if record.Budget != nil {
+		budget := *record.Budget
+		copied.Budget = &budget
+	}
+	if record.BudgetUSD != nil {
+		budget := *record.BudgetUSD
+		copied.BudgetUSD = &budget
+	}
+	if record.MpaRating != nil {
+		rating := *record.MpaRating
+		copied.MpaRating = &rating
+	}
+	if record.Revenue.OpeningWeekendUS != nil {
+		opening := *record.Revenue.OpeningWeekendUS
+		copied.Revenue.OpeningWeekendUS = &opening
+	}
+	if record.Revenue.WorldwideUSD != nil {
+		worldwide := *record.Revenue.WorldwideUSD
+		copied.Revenue.WorldwideUSD = &worldwide
+	}
+	if record.MatchConfidence != nil {
+		confidence := *record.MatchConfidence
+		copied.MatchConfidence = &confidence
+	}
+	if record.Sources != nil {
+		copied.Sources = make(map[string]string, len(record.Sources))
+		for field, provider := range record.Sources {
+			copied.Sources[field] = provider
+		}
+	}
+	return &copied
+}
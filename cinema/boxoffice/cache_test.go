@@ -0,0 +1,102 @@
+package boxoffice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type countingClient struct {
+	calls   map[string]int
+	records map[string]*Record
+	err     error
+}
+
+func (c *countingClient) Fetch(ctx context.Context, title string) (*Record, error) {
+	c.calls[title]++
+	if c.err != nil {
+		return nil, c.err
+	}
+	record, ok := c.records[title]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return record, nil
+}
+
+func TestCachingClientCachesHitsAndNotFound(t *testing.T) {
+	budget := int64(100)
+	upstream := &countingClient{
+		calls:   map[string]int{},
+		records: map[string]*Record{"Heat": {Budget: &budget, Revenue: Revenue{Worldwide: 187}}},
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewCachingClient(upstream, CacheOptions{TTL: time.Hour, NegativeTTL: time.Minute})
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		record, err := cache.Fetch(context.Background(), "Heat")
+		if err != nil || record.Revenue.Worldwide != 187 {
+			t.Fatalf("unexpected result %+v (%v)", record, err)
+		}
+		*record.Budget = 0
+	}
+	if upstream.calls["Heat"] != 1 {
+		t.Fatalf("expected one upstream call, got %d", upstream.calls["Heat"])
+	}
+	if record, _ := cache.Fetch(context.Background(), "  heat "); *record.Budget != 100 {
+		t.Fatal("expected cached record to be isolated from caller mutations")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Fetch(context.Background(), "Unknown"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+	if upstream.calls["Unknown"] != 1 {
+		t.Fatalf("expected not-found to be cached, got %d calls", upstream.calls["Unknown"])
+	}
+
+	now = now.Add(2 * time.Minute)
+	cache.Fetch(context.Background(), "Unknown")
+	cache.Fetch(context.Background(), "Heat")
+	if upstream.calls["Unknown"] != 2 || upstream.calls["Heat"] != 1 {
+		t.Fatalf("expected only the negative entry to expire, got %v", upstream.calls)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 4 || stats.NegativeHits != 1 || stats.Misses != 3 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCachingClientDoesNotCacheTransientErrors(t *testing.T) {
+	upstream := &countingClient{calls: map[string]int{}, err: errors.New("boom")}
+	cache := NewCachingClient(upstream, CacheOptions{})
+
+	cache.Fetch(context.Background(), "Heat")
+	cache.Fetch(context.Background(), "Heat")
+	if upstream.calls["Heat"] != 2 {
+		t.Fatalf("expected transient errors to bypass the cache, got %d calls", upstream.calls["Heat"])
+	}
+}
+
+func TestCachingClientEvictsLeastRecentlyUsed(t *testing.T) {
+	upstream := &countingClient{calls: map[string]int{}, records: map[string]*Record{"A": {}, "B": {}, "C": {}}}
+	cache := NewCachingClient(upstream, CacheOptions{Capacity: 2})
+
+	cache.Fetch(context.Background(), "A")
+	cache.Fetch(context.Background(), "B")
+	cache.Fetch(context.Background(), "A")
+	cache.Fetch(context.Background(), "C")
+	cache.Fetch(context.Background(), "A")
+	cache.Fetch(context.Background(), "B")
+
+	if upstream.calls["A"] != 1 || upstream.calls["B"] != 2 {
+		t.Fatalf("expected B to be evicted, got %v", upstream.calls)
+	}
+	if stats := cache.Stats(); stats.Entries != 2 || stats.Evictions != 2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
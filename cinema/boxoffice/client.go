@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -21,6 +22,11 @@ type Client interface {
 	Fetch(ctx context.Context, title string) (*Record, error)
 }
 
+// FreshFetcher is implemented by clients that can bypass cached answers.
+type FreshFetcher interface {
+	FetchFresh(ctx context.Context, title string) (*Record, error)
+}
+
 type HTTPClient struct {
 	baseURL    *url.URL
 	apiKey     string
@@ -28,22 +34,36 @@ type HTTPClient struct {
 }
 
 type Record struct {
+	// Title is the provider's spelling of the title, if it reports one.
+	Title       string
 	Distributor *string
 	ReleaseDate string
 	Budget      *int64
+	// BudgetUSD is Budget converted to US dollars, set by NormalizingClient.
+	BudgetUSD   *int64
 	MpaRating   *string
 	Revenue     Revenue
 	Currency    string
 	Source      string
 	LastUpdated time.Time
+	// MatchConfidence, set by MatchingClient, rates from 0 to 1 how closely
+	// the record's title matches the one looked up.
+	MatchConfidence *float64
+	// Sources maps FieldDistributor etc. to the provider that supplied the
+	// field. Only CompositeClient sets it.
+	Sources map[string]string
 }
 
 type Revenue struct {
 	Worldwide        int64
 	OpeningWeekendUS *int64
+	// WorldwideUSD is Worldwide converted to US dollars, set by
+	// NormalizingClient.
+	WorldwideUSD *int64
 }
 
 type apiResponse struct {
+	Title       string         `json:"title"`
 	Distributor *string        `json:"distributor"`
 	ReleaseDate string         `json:"releaseDate"`
 	Budget      *int64         `json:"budget"`
@@ -116,6 +136,7 @@ func (c *HTTPClient) Fetch(ctx context.Context, title string) (*Record, error) {
 		}
 
 		record := &Record{
+			Title:       payload.Title,
 			Distributor: payload.Distributor,
 			ReleaseDate: payload.ReleaseDate,
 			Budget:      payload.Budget,
@@ -138,6 +159,40 @@ func (c *HTTPClient) Fetch(ctx context.Context, title string) (*Record, error) {
 		if trimmed == "" {
 			trimmed = resp.Status
 		}
-		return nil, fmt.Errorf("box office service returned status %d: %s", resp.StatusCode, trimmed)
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			Body:       trimmed,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+}
+
+// StatusError reports an unexpected upstream status. RetryAfter is the delay
+// requested by the provider, or zero if none was given.
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("box office service returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// parseRetryAfter accepts both the delay-seconds and HTTP-date forms.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
 	}
+	return 0
 }
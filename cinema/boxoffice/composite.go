@@ -0,0 +1,141 @@
+package boxoffice
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// Record fields whose provenance is tracked in Record.Sources.
+const (
+	FieldReleaseDate = "releaseDate"
+	FieldDistributor = "distributor"
+	FieldBudget      = "budget"
+	FieldMpaRating   = "mpaRating"
+	FieldRevenue     = "revenue"
+)
+
+// Provider is a named box office source. The name is what Record.Sources
+// reports for the fields the provider supplied.
+type Provider struct {
+	Name   string
+	Client Client
+}
+
+// CompositeClient queries providers one at a time, in priority order, and
+// merges their answers field by field, taking each field from the first
+// provider that has it. A lower-priority provider is only asked while some
+// field is still missing. Revenue, currency, source and lastUpdated travel
+// together.
+//
+// A title found by any provider is a success even if others failed; the
+// failures are logged and the next refresh fills the gaps. The lookup only
+// fails when no provider found the title and at least one of them errored.
+type CompositeClient struct {
+	providers []Provider
+}
+
+func NewCompositeClient(providers ...Provider) *CompositeClient {
+	return &CompositeClient{providers: providers}
+}
+
+func (c *CompositeClient) Providers() []Provider {
+	return c.providers
+}
+
+func (c *CompositeClient) Fetch(ctx context.Context, title string) (*Record, error) {
+	return c.fetch(ctx, title, Client.Fetch)
+}
+
+// FetchFresh bypasses caches of providers that have one.
+func (c *CompositeClient) FetchFresh(ctx context.Context, title string) (*Record, error) {
+	return c.fetch(ctx, title, func(client Client, ctx context.Context, title string) (*Record, error) {
+		if fresh, ok := client.(FreshFetcher); ok {
+			return fresh.FetchFresh(ctx, title)
+		}
+		return client.Fetch(ctx, title)
+	})
+}
+
+func (c *CompositeClient) fetch(ctx context.Context, title string, fetch func(Client, context.Context, string) (*Record, error)) (*Record, error) {
+	var (
+		merged   *Record
+		failures []error
+	)
+	for _, provider := range c.providers {
+		if merged.complete() || ctx.Err() != nil {
+			break
+		}
+
+		record, err := fetch(provider.Client, ctx, title)
+		switch {
+		case err == nil && record != nil:
+			if merged == nil {
+				merged = &Record{Sources: make(map[string]string)}
+			}
+			merged.fillFrom(provider.Name, record)
+		case err != nil && !errors.Is(err, ErrNotFound):
+			failures = append(failures, err)
+		}
+	}
+
+	switch {
+	case merged != nil:
+		for _, err := range failures {
+			log.Printf("box office lookup of %q partially failed: %v", title, err)
+		}
+		return merged, nil
+	case len(failures) > 0:
+		return nil, errors.Join(failures...)
+	default:
+		return nil, ErrNotFound
+	}
+}
+
+// fillFrom copies the fields r does not have yet from record.
+func (r *Record) fillFrom(provider string, record *Record) {
+	if r.Distributor == nil && record.Distributor != nil {
+		r.Distributor = record.Distributor
+		r.Sources[FieldDistributor] = provider
+	}
+	if r.Budget == nil && record.Budget != nil {
+		r.Budget = record.Budget
+		r.BudgetUSD = record.BudgetUSD
+		r.Sources[FieldBudget] = provider
+	}
+	if r.MpaRating == nil && record.MpaRating != nil {
+		r.MpaRating = record.MpaRating
+		r.Sources[FieldMpaRating] = provider
+	}
+	if _, ok := r.Sources[FieldRevenue]; !ok && record.hasRevenue() {
+		r.Revenue = record.Revenue
+		r.Currency = record.Currency
+		r.Source = record.Source
+		r.LastUpdated = record.LastUpdated
+		r.MatchConfidence = record.MatchConfidence
+		r.Sources[FieldRevenue] = provider
+	}
+	if r.ReleaseDate == "" && record.ReleaseDate != "" {
+		r.ReleaseDate = record.ReleaseDate
+		r.Sources[FieldReleaseDate] = provider
+	}
+	if r.Title == "" {
+		r.Title = record.Title
+	}
+}
+
+// complete reports whether every field a provider can supply is filled, so
+// lower-priority providers have nothing to add.
+func (r *Record) complete() bool {
+	if r == nil {
+		return false
+	}
+	_, hasRevenue := r.Sources[FieldRevenue]
+	return hasRevenue && r.Distributor != nil && r.Budget != nil && r.MpaRating != nil && r.ReleaseDate != ""
+}
+
+// hasRevenue tells a record carrying revenue figures from one that only
+// supplies attributes, such as a partial override.
+func (r *Record) hasRevenue() bool {
+	return r.Currency != "" || r.Revenue.Worldwide != 0 || r.Revenue.OpeningWeekendUS != nil
+}
@@ -0,0 +1,96 @@
+package boxoffice
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompositeClientMergesFieldsInPriorityOrder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "overrides.json")
+	if err := os.WriteFile(path, []byte(`{"  heat ": {"distributor": "Warner Bros."}}`), 0o600); err != nil {
+		t.Fatalf("failed to write overrides: %v", err)
+	}
+	overrides, err := LoadOverrideFile(path)
+	if err != nil {
+		t.Fatalf("LoadOverrideFile returned error: %v", err)
+	}
+
+	primaryDistributor, rating := "Fox", "R"
+	secondaryBudget := int64(60000000)
+	primary := &countingClient{calls: map[string]int{}, records: map[string]*Record{
+		"Heat": {Distributor: &primaryDistributor, MpaRating: &rating, Revenue: Revenue{Worldwide: 187}, Currency: "USD", Source: "A"},
+	}}
+	secondary := &countingClient{calls: map[string]int{}, records: map[string]*Record{
+		"Heat": {Budget: &secondaryBudget, Revenue: Revenue{Worldwide: 190}, Currency: "USD", Source: "B"},
+	}}
+	client := NewCompositeClient(
+		Provider{Name: "override", Client: overrides},
+		Provider{Name: "a", Client: primary},
+		Provider{Name: "b", Client: secondary},
+	)
+
+	record, err := client.Fetch(context.Background(), "Heat")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if *record.Distributor != "Warner Bros." || *record.Budget != secondaryBudget || *record.MpaRating != "R" || record.Revenue.Worldwide != 187 {
+		t.Fatalf("unexpected merged record %+v", record)
+	}
+	want := map[string]string{FieldDistributor: "override", FieldBudget: "b", FieldMpaRating: "a", FieldRevenue: "a"}
+	for field, provider := range want {
+		if record.Sources[field] != provider {
+			t.Fatalf("expected %s from %s, got sources %v", field, provider, record.Sources)
+		}
+	}
+}
+
+func TestCompositeClientToleratesPartialFailure(t *testing.T) {
+	upstreamErr := &StatusError{StatusCode: 503, Body: "down"}
+	failing := &countingClient{calls: map[string]int{}, err: upstreamErr}
+	found := &countingClient{calls: map[string]int{}, records: map[string]*Record{"Heat": {Revenue: Revenue{Worldwide: 187}, Currency: "USD"}}}
+	missing := &countingClient{calls: map[string]int{}, records: map[string]*Record{}}
+
+	record, err := NewCompositeClient(Provider{Name: "a", Client: failing}, Provider{Name: "b", Client: found}).Fetch(context.Background(), "Heat")
+	if err != nil || record.Sources[FieldRevenue] != "b" {
+		t.Fatalf("expected the answer of the healthy provider, got %+v, %v", record, err)
+	}
+
+	_, err = NewCompositeClient(Provider{Name: "a", Client: failing}, Provider{Name: "c", Client: missing}).Fetch(context.Background(), "Heat")
+	if !errors.Is(err, upstreamErr) {
+		t.Fatalf("expected the upstream failure when nobody found the title, got %v", err)
+	}
+
+	_, err = NewCompositeClient(Provider{Name: "c", Client: missing}).Fetch(context.Background(), "Heat")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCompositeClientSkipsLowerProvidersOnceComplete(t *testing.T) {
+	distributor, rating := "Warner Bros.", "R"
+	budget := int64(60000000)
+	primary := &countingClient{calls: map[string]int{}, records: map[string]*Record{
+		"Heat": {Distributor: &distributor, Budget: &budget, MpaRating: &rating, ReleaseDate: "1995-12-15", Revenue: Revenue{Worldwide: 187}, Currency: "USD"},
+	}}
+	secondary := &countingClient{calls: map[string]int{}, records: map[string]*Record{}}
+	client := NewCompositeClient(Provider{Name: "a", Client: primary}, Provider{Name: "b", Client: secondary})
+
+	if _, err := client.Fetch(context.Background(), "Heat"); err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if secondary.calls["Heat"] != 0 {
+		t.Fatalf("expected the secondary provider to be skipped, got %d calls", secondary.calls["Heat"])
+	}
+
+	primary.records["Heat"].MpaRating = nil
+	if _, err := client.Fetch(context.Background(), "Heat"); err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if secondary.calls["Heat"] != 1 {
+		t.Fatalf("expected the secondary provider to fill the missing rating, got %d calls", secondary.calls["Heat"])
+	}
+}
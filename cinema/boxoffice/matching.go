@@ -0,0 +1,271 @@
+package boxoffice
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+type releaseYearKey struct{}
+
+// WithReleaseYear tells the lookups made with ctx which year the movie came
+// out, so that MatchingClient can reject records of a different film with the
+// same title.
+func WithReleaseYear(ctx context.Context, year int) context.Context {
+	return context.WithValue(ctx, releaseYearKey{}, year)
+}
+
+func releaseYear(ctx context.Context) (int, bool) {
+	year, ok := ctx.Value(releaseYearKey{}).(int)
+	return year, ok && year > 0
+}
+
+type MatchOptions struct {
+	// MaxVariants bounds the extra lookups with normalised spellings of the
+	// title made after the title as entered is not found. Defaults to 4; a
+	// negative value only tries the title as entered.
+	MaxVariants int
+	// CheckReleaseYear rejects records released more than a year away from
+	// the year passed with WithReleaseYear.
+	CheckReleaseYear bool
+	// MinConfidence rejects matches scoring below it. Defaults to 0.5.
+	MinConfidence float64
+}
+
+// MatchingClient makes title lookups forgiving. When the provider does not
+// know the title as entered it retries with variants that fix case, strip
+// diacritics and punctuation and move or drop a leading article, and it scores
+// how closely the provider's title matches the one asked for in
+// Record.MatchConfidence.
+type MatchingClient struct {
+	next Client
+	opts MatchOptions
+}
+
+func NewMatchingClient(next Client, opts MatchOptions) *MatchingClient {
+	if opts.MaxVariants == 0 {
+		opts.MaxVariants = 4
+	}
+	if opts.MinConfidence <= 0 {
+		opts.MinConfidence = 0.5
+	}
+	return &MatchingClient{next: next, opts: opts}
+}
+
+func (c *MatchingClient) Fetch(ctx context.Context, title string) (*Record, error) {
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		return nil, ErrInvalidTitle
+	}
+
+	year, checkYear := releaseYear(ctx)
+	checkYear = checkYear && c.opts.CheckReleaseYear
+
+	for _, variant := range titleVariants(title, c.opts.MaxVariants) {
+		record, err := c.next.Fetch(ctx, variant)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if checkYear && !releaseYearMatches(record.ReleaseDate, year) {
+			continue
+		}
+
+		matched := record.Title
+		if matched == "" {
+			matched = variant
+		}
+		confidence := matchConfidence(title, matched)
+		if confidence < c.opts.MinConfidence {
+			continue
+		}
+		record.MatchConfidence = &confidence
+		return record, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+// titleVariants returns the title as entered followed by up to maxVariants
+// distinct normalised spellings, most faithful first.
+func titleVariants(title string, maxVariants int) []string {
+	cased := titleCase(title)
+	candidates := []string{
+		title,
+		cased,
+		stripDiacritics(title),
+		stripDiacritics(cased),
+		moveLeadingArticle(cased),
+		stripPunctuation(stripDiacritics(cased)),
+	}
+
+	limit := 1 + max(maxVariants, 0)
+	variants := make([]string, 0, limit)
+	seen := make(map[string]bool, len(candidates))
+	for _, candidate := range candidates {
+		if candidate == "" || seen[candidate] {
+			continue
+		}
+		if len(variants) == limit {
+			break
+		}
+		seen[candidate] = true
+		variants = append(variants, candidate)
+	}
+	return variants
+}
+
+// matchConfidence scores how well the provider's title matches the requested
+// one: 1 for an exact match, slightly less for each normalisation needed, and
+// at most 0.8, scaled by edit distance, for titles that differ.
+func matchConfidence(requested, matched string) float64 {
+	switch {
+	case requested == matched:
+		return 1
+	case strings.EqualFold(requested, matched):
+		return 0.95
+	case foldTitle(requested) == foldTitle(matched):
+		return 0.9
+	case foldTitle(articleless(requested)) == foldTitle(articleless(matched)):
+		return 0.85
+	}
+
+	a, b := []rune(foldTitle(requested)), []rune(foldTitle(matched))
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 0.8 * (1 - float64(levenshtein(a, b))/float64(longest))
+}
+
+// releaseYearMatches accepts a one-year difference, since festival and
+// theatrical releases often straddle New Year. An unknown date passes.
+func releaseYearMatches(releaseDate string, year int) bool {
+	if len(releaseDate) < 4 {
+		return true
+	}
+	recordYear, err := strconv.Atoi(releaseDate[:4])
+	if err != nil {
+		return true
+	}
+	diff := recordYear - year
+	return diff >= -1 && diff <= 1
+}
+
+var leadingArticles = []string{"the", "a", "an"}
+
+var minorWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true,
+	"in": true, "on": true, "at": true, "to": true, "for": true, "with": true,
+}
+
+// titleCase capitalises every word except minor ones after the first, e.g.
+// "the lord OF the rings" becomes "The Lord of the Rings".
+func titleCase(title string) string {
+	words := strings.Fields(strings.ToLower(title))
+	for i, word := range words {
+		if i > 0 && minorWords[word] {
+			continue
+		}
+		r := []rune(word)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
+
+// moveLeadingArticle turns "Dark Knight, The" into "The Dark Knight" and
+// drops the article from "The Dark Knight".
+func moveLeadingArticle(title string) string {
+	if rest, article, ok := trailingArticle(title); ok {
+		return titleCase(article) + " " + rest
+	}
+	return withoutArticle(title)
+}
+
+// articleless drops a leading article or one moved to the end.
+func articleless(title string) string {
+	if rest, _, ok := trailingArticle(title); ok {
+		return rest
+	}
+	return withoutArticle(title)
+}
+
+func trailingArticle(title string) (string, string, bool) {
+	for _, article := range leadingArticles {
+		suffix := ", " + article
+		if len(title) > len(suffix) && strings.EqualFold(title[len(title)-len(suffix):], suffix) {
+			return title[:len(title)-len(suffix)], article, true
+		}
+	}
+	return "", "", false
+}
+
+func withoutArticle(title string) string {
+	for _, article := range leadingArticles {
+		if len(title) > len(article)+1 && strings.EqualFold(title[:len(article)+1], article+" ") {
+			return title[len(article)+1:]
+		}
+	}
+	return title
+}
+
+func stripDiacritics(title string) string {
+	stripped, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), title)
+	if err != nil {
+		return title
+	}
+	return stripped
+}
+
+// stripPunctuation drops punctuation, treating hyphens and slashes as spaces.
+func stripPunctuation(title string) string {
+	var b strings.Builder
+	for _, r := range title {
+		switch {
+		case r == '-' || r == '/' || r == '_':
+			b.WriteRune(' ')
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// foldTitle is the comparison form of a title: lower case, without
+// diacritics or punctuation.
+func foldTitle(title string) string {
+	return strings.ToLower(stripPunctuation(stripDiacritics(title)))
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
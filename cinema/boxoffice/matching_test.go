@@ -0,0 +1,80 @@
+package boxoffice
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// exactClient only knows titles spelled exactly as stored, like a strict
+// provider.
+type exactClient struct {
+	records map[string]*Record
+	queries []string
+}
+
+func (c *exactClient) Fetch(ctx context.Context, title string) (*Record, error) {
+	c.queries = append(c.queries, title)
+	record, ok := c.records[title]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneRecord(record), nil
+}
+
+func TestMatchingClientTriesNormalisedVariants(t *testing.T) {
+	upstream := &exactClient{records: map[string]*Record{
+		"The Dark Knight": {Title: "The Dark Knight", ReleaseDate: "2008-07-18"},
+		"Amelie":          {Title: "Amelie", ReleaseDate: "2001-04-25"},
+	}}
+	client := NewMatchingClient(upstream, MatchOptions{CheckReleaseYear: true})
+
+	cases := []struct {
+		title      string
+		year       int
+		confidence float64
+	}{
+		{"The Dark Knight", 2008, 1},
+		{"the dark  knight", 2008, 0.95},
+		{"Amélie", 0, 0.9},
+		{"Dark Knight, The", 2009, 0.85},
+	}
+	for _, tc := range cases {
+		record, err := client.Fetch(WithReleaseYear(context.Background(), tc.year), tc.title)
+		if err != nil {
+			t.Fatalf("Fetch(%q) returned error: %v", tc.title, err)
+		}
+		if record.MatchConfidence == nil || *record.MatchConfidence != tc.confidence {
+			t.Fatalf("Fetch(%q): expected confidence %v, got %v", tc.title, tc.confidence, record.MatchConfidence)
+		}
+	}
+
+	if _, err := client.Fetch(WithReleaseYear(context.Background(), 2019), "The Dark Knight"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a record from another year to be rejected, got %v", err)
+	}
+}
+
+func TestMatchingClientBoundsVariantsAndStopsOnFailure(t *testing.T) {
+	upstream := &exactClient{records: map[string]*Record{}}
+	if _, err := NewMatchingClient(upstream, MatchOptions{MaxVariants: -1}).Fetch(context.Background(), "the dark knight"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if len(upstream.queries) != 1 {
+		t.Fatalf("expected only the title as entered, got %v", upstream.queries)
+	}
+
+	failing := &countingClient{calls: map[string]int{}, err: &StatusError{StatusCode: 503, Body: "down"}}
+	_, err := NewMatchingClient(failing, MatchOptions{}).Fetch(context.Background(), "the dark knight")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || len(failing.calls) != 1 {
+		t.Fatalf("expected the first upstream failure to end the lookup, got %v after %v", err, failing.calls)
+	}
+}
+
+func TestMatchConfidenceScalesWithEditDistance(t *testing.T) {
+	close := matchConfidence("The Dark Knight", "The Dark Knight Rises")
+	far := matchConfidence("The Dark Knight", "Titanic")
+	if close >= 0.8 || close <= far || far < 0 {
+		t.Fatalf("unexpected confidences: close %v, far %v", close, far)
+	}
+}
@@ -0,0 +1,66 @@
+package boxoffice
+
+import (
+	"cinema/currency"
+	"context"
+	"log"
+	"time"
+)
+
+// NormalizingClient validates the currency of provider answers against ISO
+// 4217 and adds US dollar amounts (Record.BudgetUSD and
+// Revenue.WorldwideUSD) converted at the rates in effect when the provider
+// last updated the record. Records without a currency are taken to be in
+// USD, as the provider contract states. An unknown currency or a missing
+// rate leaves the USD amounts unset rather than failing the lookup.
+type NormalizingClient struct {
+	next  Client
+	rates *currency.Rates
+	now   func() time.Time
+}
+
+func NewNormalizingClient(next Client, rates *currency.Rates) *NormalizingClient {
+	return &NormalizingClient{next: next, rates: rates, now: time.Now}
+}
+
+func (c *NormalizingClient) Fetch(ctx context.Context, title string) (*Record, error) {
+	record, err := c.next.Fetch(ctx, title)
+	if err != nil || record == nil {
+		return record, err
+	}
+	record = cloneRecord(record)
+
+	code := currency.USD
+	if record.Currency != "" {
+		normalized, err := currency.Normalize(record.Currency)
+		if err != nil {
+			log.Printf("box office record for %q has unknown currency %q", title, record.Currency)
+			return record, nil
+		}
+		code = normalized
+		record.Currency = code
+	} else if record.hasRevenue() {
+		record.Currency = code
+	}
+
+	on := record.LastUpdated
+	if on.IsZero() {
+		on = c.now()
+	}
+
+	if record.Budget != nil {
+		if usd, err := c.rates.Convert(*record.Budget, code, currency.USD, on); err == nil {
+			record.BudgetUSD = &usd
+		} else {
+			log.Printf("cannot normalise budget of %q: %v", title, err)
+		}
+	}
+	if record.hasRevenue() {
+		if usd, err := c.rates.Convert(record.Revenue.Worldwide, code, currency.USD, on); err == nil {
+			record.Revenue.WorldwideUSD = &usd
+		} else {
+			log.Printf("cannot normalise revenue of %q: %v", title, err)
+		}
+	}
+	return record, nil
+}
@@ -0,0 +1,42 @@
+package boxoffice
+
+import (
+	"cinema/currency"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizingClientAddsUSDAmounts(t *testing.T) {
+	rates, err := currency.LoadRatesCSV(strings.NewReader("date,currency,units_per_usd\n2024-01-01,EUR,0.8\n"))
+	if err != nil {
+		t.Fatalf("LoadRatesCSV returned error: %v", err)
+	}
+	budget := int64(80)
+	updated := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	upstream := &countingClient{calls: map[string]int{}, records: map[string]*Record{
+		"Amélie": {Budget: &budget, Revenue: Revenue{Worldwide: 800}, Currency: "eur", LastUpdated: updated},
+		"Heat":   {Revenue: Revenue{Worldwide: 187}},
+		"Old":    {Revenue: Revenue{Worldwide: 5}, Currency: "FRF"},
+	}}
+	client := NewNormalizingClient(upstream, rates)
+
+	record, err := client.Fetch(context.Background(), "Amélie")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if record.Currency != "EUR" || *record.BudgetUSD != 100 || *record.Revenue.WorldwideUSD != 1000 {
+		t.Fatalf("unexpected normalised record %+v", record)
+	}
+
+	record, err = client.Fetch(context.Background(), "Heat")
+	if err != nil || record.Currency != currency.USD || *record.Revenue.WorldwideUSD != 187 {
+		t.Fatalf("expected a record without currency to be taken as USD, got %+v, %v", record, err)
+	}
+
+	record, err = client.Fetch(context.Background(), "Old")
+	if err != nil || record.Revenue.WorldwideUSD != nil {
+		t.Fatalf("expected an unknown currency to leave USD amounts unset, got %+v, %v", record, err)
+	}
+}
@@ -0,0 +1,72 @@
+package boxoffice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// OverrideClient answers lookups from a local JSON file of hand-curated
+// corrections, keyed by title:
+//
+//	{"Inception": {"distributor": "Warner Bros.", "budget": 160000000,
+//	  "revenue": {"worldwide": 836800000}, "currency": "USD"}}
+//
+// Every attribute is optional, so an entry can correct a single field. Titles
+// are matched like cache keys: case-insensitively, ignoring extra whitespace.
+type OverrideClient struct {
+	records map[string]*Record
+}
+
+type overrideEntry struct {
+	Distributor *string         `json:"distributor"`
+	Budget      *int64          `json:"budget"`
+	MpaRating   *string         `json:"mpaRating"`
+	Revenue     *revenuePayload `json:"revenue"`
+	Currency    string          `json:"currency"`
+}
+
+func LoadOverrideFile(path string) (*OverrideClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries map[string]overrideEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse box office overrides %s: %w", path, err)
+	}
+
+	records := make(map[string]*Record, len(entries))
+	for title, entry := range entries {
+		record := &Record{
+			Distributor: entry.Distributor,
+			Budget:      entry.Budget,
+			MpaRating:   entry.MpaRating,
+			Source:      "override",
+		}
+		if entry.Revenue != nil {
+			if entry.Currency == "" {
+				return nil, fmt.Errorf("box office override for %q has revenue but no currency", title)
+			}
+			record.Revenue = Revenue{Worldwide: entry.Revenue.Worldwide, OpeningWeekendUS: entry.Revenue.OpeningWeekendUSA}
+			record.Currency = entry.Currency
+		}
+		records[cacheKey(title)] = record
+	}
+
+	return &OverrideClient{records: records}, nil
+}
+
+func (c *OverrideClient) Fetch(ctx context.Context, title string) (*Record, error) {
+	if strings.TrimSpace(title) == "" {
+		return nil, ErrInvalidTitle
+	}
+	record, ok := c.records[cacheKey(title)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneRecord(record), nil
+}
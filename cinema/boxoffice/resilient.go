@@ -0,0 +1,283 @@
+package boxoffice
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the provider while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("box office circuit breaker is open")
+
+// Clock abstracts time so that backoff and breaker timing can be tested.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+type ResilienceOptions struct {
+	// MaxAttempts bounds the calls per Fetch, including the first. Defaults to 3.
+	MaxAttempts int
+	// BaseDelay and MaxDelay bound the full-jitter exponential backoff.
+	// They default to 200ms and 5s. A Retry-After longer than MaxDelay is
+	// not waited out; the error is returned instead.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// FailureThreshold consecutive failed attempts open the breaker. Defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before letting a single
+	// probe through. Defaults to 30s.
+	OpenTimeout time.Duration
+	// Budget bounds the whole Fetch, backoff included. No retry is started
+	// unless it can finish, judged by the last attempt, before the budget or
+	// the caller's deadline runs out. Defaults to 8s, below the server's
+	// write timeout.
+	Budget time.Duration
+	Clock  Clock
+}
+
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// BreakerStatus is a snapshot of the circuit breaker.
+type BreakerStatus struct {
+	State               string
+	ConsecutiveFailures int
+	OpenedAt            *time.Time
+	RetryAt             *time.Time
+}
+
+// ResilientClient retries transient failures with jittered exponential
+// backoff and stops calling the provider altogether once it keeps failing.
+type ResilientClient struct {
+	next   Client
+	opts   ResilienceOptions
+	jitter func(max time.Duration) time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewResilientClient(next Client, opts ResilienceOptions) *ResilientClient {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = 200 * time.Millisecond
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 5 * time.Second
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 5
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = 30 * time.Second
+	}
+	if opts.Budget <= 0 {
+		opts.Budget = 8 * time.Second
+	}
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+
+	return &ResilientClient{
+		next:  next,
+		opts:  opts,
+		state: BreakerClosed,
+		jitter: func(max time.Duration) time.Duration {
+			return rand.N(max + 1)
+		},
+	}
+}
+
+func (c *ResilientClient) Fetch(ctx context.Context, title string) (*Record, error) {
+	deadline := c.deadline(ctx)
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Budget)
+	defer cancel()
+
+	var err error
+	for attempt := 0; attempt < c.opts.MaxAttempts; attempt++ {
+		if !c.allow() {
+			if err != nil {
+				return nil, err
+			}
+			return nil, ErrCircuitOpen
+		}
+
+		var record *Record
+		started := c.opts.Clock.Now()
+		record, err = c.next.Fetch(ctx, title)
+		if err != nil && ctx.Err() != nil {
+			// The caller gave up or the budget ran out; that says nothing
+			// about the provider.
+			c.releaseProbe()
+			return nil, err
+		}
+		if !isTransient(err) {
+			if isRejected(err) {
+				c.recordFailure()
+			} else {
+				c.recordSuccess()
+			}
+			return record, err
+		}
+		c.recordFailure()
+
+		if attempt+1 == c.opts.MaxAttempts {
+			break
+		}
+		delay, ok := c.backoff(attempt, err)
+		if !ok {
+			break
+		}
+		now := c.opts.Clock.Now()
+		if now.Add(delay).Add(now.Sub(started)).After(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.opts.Clock.After(delay):
+		}
+	}
+
+	return nil, err
+}
+
+// deadline is when Fetch must be done, on c.opts.Clock: the budget, or the
+// caller's deadline if that comes first.
+func (c *ResilientClient) deadline(ctx context.Context) time.Time {
+	now := c.opts.Clock.Now()
+	deadline := now.Add(c.opts.Budget)
+	if callerDeadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(callerDeadline); now.Add(remaining).Before(deadline) {
+			deadline = now.Add(remaining)
+		}
+	}
+	return deadline
+}
+
+func (c *ResilientClient) Breaker() BreakerStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := BreakerStatus{State: c.currentState(), ConsecutiveFailures: c.failures}
+	if c.state != BreakerClosed {
+		openedAt := c.openedAt
+		retryAt := openedAt.Add(c.opts.OpenTimeout)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+// backoff returns the delay before the next attempt, or false if the
+// provider asked for a longer pause than we are willing to hold a request.
+func (c *ResilientClient) backoff(attempt int, err error) (time.Duration, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return statusErr.RetryAfter, statusErr.RetryAfter <= c.opts.MaxDelay
+	}
+
+	ceiling := c.opts.BaseDelay << attempt
+	if ceiling <= 0 || ceiling > c.opts.MaxDelay {
+		ceiling = c.opts.MaxDelay
+	}
+	return c.jitter(ceiling), true
+}
+
+// currentState reports half-open once the open timeout has elapsed. Callers
+// must hold c.mu.
+func (c *ResilientClient) currentState() string {
+	if c.state == BreakerOpen && !c.opts.Clock.Now().Before(c.openedAt.Add(c.opts.OpenTimeout)) {
+		return BreakerHalfOpen
+	}
+	return c.state
+}
+
+// allow admits every call while closed and a single probe while half-open.
+func (c *ResilientClient) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.currentState() {
+	case BreakerClosed:
+		return true
+	case BreakerHalfOpen:
+		if c.probing {
+			return false
+		}
+		c.state = BreakerHalfOpen
+		c.probing = true
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *ResilientClient) recordSuccess() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state = BreakerClosed
+	c.failures = 0
+	c.probing = false
+}
+
+func (c *ResilientClient) releaseProbe() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.probing = false
+}
+
+func (c *ResilientClient) recordFailure() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failures++
+	if c.state == BreakerHalfOpen || c.failures >= c.opts.FailureThreshold {
+		c.state = BreakerOpen
+		c.openedAt = c.opts.Clock.Now()
+	}
+	c.probing = false
+}
+
+// isRejected reports whether the provider refused our credentials. That is
+// not worth retrying, but it is not a sign of health either, so it counts
+// towards opening the breaker.
+func isRejected(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) &&
+		(statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden)
+}
+
+// isTransient reports whether err is worth retrying: network failures,
+// timeouts, 429 and 5xx. Lookups are GETs, so retrying is safe.
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidTitle) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	return true
+}
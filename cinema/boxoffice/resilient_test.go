@@ -0,0 +1,195 @@
+package boxoffice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock fires timers immediately and records the requested delays.
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func newTestServer(t *testing.T, handler func(w http.ResponseWriter, calls int32)) (Client, *int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, atomic.AddInt32(&calls, 1))
+	}))
+	t.Cleanup(server.Close)
+	return NewHTTPClient(server.URL, "key", server.Client()), &calls
+}
+
+func TestResilientClientRetriesTransientFailures(t *testing.T) {
+	upstream, calls := newTestServer(t, func(w http.ResponseWriter, calls int32) {
+		switch calls {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"revenue":{"worldwide":187}}`))
+		}
+	})
+
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := NewResilientClient(upstream, ResilienceOptions{BaseDelay: 100 * time.Millisecond, Clock: clock})
+	client.jitter = func(max time.Duration) time.Duration { return max }
+
+	record, err := client.Fetch(context.Background(), "Heat")
+	if err != nil || record.Revenue.Worldwide != 187 {
+		t.Fatalf("unexpected result %+v (%v)", record, err)
+	}
+	if *calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", *calls)
+	}
+	if len(clock.sleeps) != 2 || clock.sleeps[0] != 100*time.Millisecond || clock.sleeps[1] != 2*time.Second {
+		t.Fatalf("expected backoff then Retry-After, got %v", clock.sleeps)
+	}
+}
+
+func TestResilientClientDoesNotRetryClientErrors(t *testing.T) {
+	upstream, calls := newTestServer(t, func(w http.ResponseWriter, calls int32) {
+		if calls == 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	client := NewResilientClient(upstream, ResilienceOptions{Clock: &fakeClock{}})
+
+	if _, err := client.Fetch(context.Background(), "Heat"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	var statusErr *StatusError
+	if _, err := client.Fetch(context.Background(), "Heat"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 StatusError, got %v", err)
+	}
+	if *calls != 2 {
+		t.Fatalf("expected no retries, got %d calls", *calls)
+	}
+}
+
+func TestResilientClientOpensAndRecoversCircuit(t *testing.T) {
+	var healthy atomic.Bool
+	upstream, calls := newTestServer(t, func(w http.ResponseWriter, calls int32) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := NewResilientClient(upstream, ResilienceOptions{
+		MaxAttempts:      2,
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		Clock:            clock,
+	})
+	client.jitter = func(time.Duration) time.Duration { return 0 }
+
+	if _, err := client.Fetch(context.Background(), "Heat"); err == nil {
+		t.Fatal("expected failure")
+	}
+	if status := client.Breaker(); status.State != BreakerOpen || status.ConsecutiveFailures != 2 {
+		t.Fatalf("expected open breaker, got %+v", status)
+	}
+
+	if _, err := client.Fetch(context.Background(), "Heat"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if *calls != 2 {
+		t.Fatalf("expected open breaker to fail fast, got %d calls", *calls)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	if status := client.Breaker(); status.State != BreakerHalfOpen {
+		t.Fatalf("expected half-open breaker, got %+v", status)
+	}
+
+	healthy.Store(true)
+	if _, err := client.Fetch(context.Background(), "Heat"); err != nil {
+		t.Fatalf("expected probe to succeed, got %v", err)
+	}
+	if status := client.Breaker(); status.State != BreakerClosed || status.ConsecutiveFailures != 0 {
+		t.Fatalf("expected closed breaker, got %+v", status)
+	}
+}
+
+func TestResilientClientCountsRejectedCredentialsAsFailures(t *testing.T) {
+	upstream, calls := newTestServer(t, func(w http.ResponseWriter, calls int32) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	client := NewResilientClient(upstream, ResilienceOptions{FailureThreshold: 2, Clock: &fakeClock{}})
+
+	for range 2 {
+		if _, err := client.Fetch(context.Background(), "Heat"); err == nil {
+			t.Fatal("expected failure")
+		}
+	}
+	if status := client.Breaker(); status.State != BreakerOpen {
+		t.Fatalf("expected rejected credentials to open the breaker, got %+v", status)
+	}
+	if *calls != 2 {
+		t.Fatalf("expected no retries, got %d calls", *calls)
+	}
+}
+
+func TestResilientClientStopsRetryingWhenTheBudgetRunsOut(t *testing.T) {
+	upstream, calls := newTestServer(t, func(w http.ResponseWriter, calls int32) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	client := NewResilientClient(upstream, ResilienceOptions{
+		MaxAttempts: 5,
+		BaseDelay:   time.Second,
+		MaxDelay:    4 * time.Second,
+		Budget:      5 * time.Second,
+		Clock:       clock,
+	})
+	client.jitter = func(max time.Duration) time.Duration { return max }
+
+	var statusErr *StatusError
+	if _, err := client.Fetch(context.Background(), "Heat"); !errors.As(err, &statusErr) {
+		t.Fatalf("expected the last StatusError, got %v", err)
+	}
+	// 1s then 2s of backoff fit in 5s; another 4s would not.
+	if *calls != 3 || len(clock.sleeps) != 2 {
+		t.Fatalf("expected 3 attempts within the budget, got %d calls and sleeps %v", *calls, clock.sleeps)
+	}
+}
+
+func TestResilientClientRespectsTheCallersDeadline(t *testing.T) {
+	upstream, calls := newTestServer(t, func(w http.ResponseWriter, calls int32) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	client := NewResilientClient(upstream, ResilienceOptions{BaseDelay: time.Second, Clock: &fakeClock{}})
+	client.jitter = func(max time.Duration) time.Duration { return max }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if _, err := client.Fetch(ctx, "Heat"); err == nil {
+		t.Fatal("expected failure")
+	}
+	if *calls != 1 {
+		t.Fatalf("expected no retry past the caller's deadline, got %d calls", *calls)
+	}
+}
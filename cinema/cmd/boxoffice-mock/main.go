@@ -0,0 +1,221 @@
+// Command boxoffice-mock serves GET /boxoffice from a JSON file per
+// boxoffice.openapi.yml, so the app and the e2e tests can run offline. It can
+// add latency and inject failures to exercise the client's degradation paths.
+//
+//	go run ./cmd/boxoffice-mock -data ../mock-boxoffice.json -latency 200ms -failure-rate 0.2
+//
+// Every flag can also be set through the environment variable shown in its
+// usage string.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// options configures the mock. FailureRate is the probability in [0, 1] of
+// answering FailureStatus instead of looking the title up; RetryAfter is sent
+// with injected 429 and 503 answers when set.
+type options struct {
+	APIKey        string
+	Latency       time.Duration
+	Jitter        time.Duration
+	FailureRate   float64
+	FailureStatus int
+	RetryAfter    time.Duration
+}
+
+type server struct {
+	records map[string]json.RawMessage
+	opts    options
+	random  func() float64
+	sleep   func(time.Duration)
+}
+
+type errorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+func main() {
+	var (
+		addr     = flag.String("addr", envOrDefault("MOCK_ADDR", ":8081"), "listen address (MOCK_ADDR)")
+		dataPath = flag.String("data", envOrDefault("MOCK_DATA_FILE", "mock-boxoffice.json"), "JSON file of records keyed by title (MOCK_DATA_FILE)")
+		opts     options
+	)
+	flag.StringVar(&opts.APIKey, "api-key", envOrDefault("MOCK_API_KEY", "mock-key"), "accepted X-API-Key value (MOCK_API_KEY)")
+	flag.DurationVar(&opts.Latency, "latency", durationEnv("MOCK_LATENCY"), "delay added to every answer (MOCK_LATENCY)")
+	flag.DurationVar(&opts.Jitter, "jitter", durationEnv("MOCK_JITTER"), "random extra delay up to this value (MOCK_JITTER)")
+	flag.Float64Var(&opts.FailureRate, "failure-rate", floatEnv("MOCK_FAILURE_RATE"), "probability of an injected failure, 0 to 1 (MOCK_FAILURE_RATE)")
+	flag.IntVar(&opts.FailureStatus, "failure-status", intEnv("MOCK_FAILURE_STATUS", http.StatusInternalServerError), "status of injected failures (MOCK_FAILURE_STATUS)")
+	flag.DurationVar(&opts.RetryAfter, "retry-after", durationEnv("MOCK_RETRY_AFTER"), "Retry-After sent with injected 429/503 answers (MOCK_RETRY_AFTER)")
+	flag.Parse()
+
+	if opts.FailureRate < 0 || opts.FailureRate > 1 {
+		log.Fatalf("failure rate must be between 0 and 1, got %v", opts.FailureRate)
+	}
+	if opts.FailureStatus < 400 || opts.FailureStatus > 599 {
+		log.Fatalf("failure status must be a 4xx or 5xx code, got %d", opts.FailureStatus)
+	}
+
+	records, err := loadRecords(*dataPath)
+	if err != nil {
+		log.Fatalf("failed to load mock data: %v", err)
+	}
+
+	log.Printf("box office mock serving %d records on %s", len(records), *addr)
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           newServer(records, opts),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("mock server stopped unexpectedly: %v", err)
+	}
+}
+
+func newServer(records map[string]json.RawMessage, opts options) *server {
+	return &server{
+		records: records,
+		opts:    opts,
+		random:  rand.Float64,
+		sleep:   time.Sleep,
+	}
+}
+
+// loadRecords reads the data file. Titles are matched case-insensitively.
+func loadRecords(path string) (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var byTitle map[string]json.RawMessage
+	if err := json.Unmarshal(data, &byTitle); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	records := make(map[string]json.RawMessage, len(byTitle))
+	for title, record := range byTitle {
+		records[normalizeTitle(title)] = record
+	}
+	return records, nil
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/healthz":
+		w.WriteHeader(http.StatusOK)
+		return
+	case r.URL.Path != "/boxoffice":
+		writeError(w, http.StatusNotFound, "Not Found", "No such endpoint.")
+		return
+	case r.Method != http.MethodGet:
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed", "Only GET is supported.")
+		return
+	}
+
+	s.delay()
+
+	if r.Header.Get("X-API-Key") != s.opts.APIKey {
+		writeError(w, http.StatusUnauthorized, "Unauthorized", "The API key is missing or invalid.")
+		return
+	}
+
+	title := strings.TrimSpace(r.URL.Query().Get("title"))
+	if title == "" {
+		writeError(w, http.StatusBadRequest, "Bad Request", "The 'title' query parameter is required.")
+		return
+	}
+
+	if s.opts.FailureRate > 0 && s.random() < s.opts.FailureRate {
+		status := s.opts.FailureStatus
+		if s.opts.RetryAfter > 0 && (status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(s.opts.RetryAfter.Round(time.Second)/time.Second)))
+		}
+		writeError(w, status, http.StatusText(status), "Injected failure.")
+		return
+	}
+
+	record, ok := s.records[normalizeTitle(title)]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found", "Movie with the specified title was not found.")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(record)
+}
+
+func (s *server) delay() {
+	d := s.opts.Latency
+	if s.opts.Jitter > 0 {
+		d += time.Duration(s.random() * float64(s.opts.Jitter))
+	}
+	if d > 0 {
+		s.sleep(d)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, title, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: title, Message: message})
+}
+
+func normalizeTitle(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func durationEnv(key string) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("%s must be a duration such as 200ms, got %q", key, value)
+	}
+	return parsed
+}
+
+func floatEnv(key string) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Fatalf("%s must be a number, got %q", key, value)
+	}
+	return parsed
+}
+
+func intEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("%s must be an integer, got %q", key, value)
+	}
+	return parsed
+}
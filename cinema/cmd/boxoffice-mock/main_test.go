@@ -0,0 +1,76 @@
+package main
+
+import (
+	"cinema/boxoffice"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMockServesRepositoryDataToHTTPClient(t *testing.T) {
+	records, err := loadRecords("../../../mock-boxoffice.json")
+	if err != nil {
+		t.Fatalf("failed to load mock data: %v", err)
+	}
+	srv := httptest.NewServer(newServer(records, options{APIKey: "secret"}))
+	defer srv.Close()
+
+	client := boxoffice.NewHTTPClient(srv.URL, "secret", srv.Client())
+	record, err := client.Fetch(context.Background(), "  inception ")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	if record.Distributor == nil || *record.Distributor != "Warner Bros. Pictures" || record.Revenue.Worldwide != 829895144 {
+		t.Fatalf("unexpected record %+v", record)
+	}
+
+	if _, err := client.Fetch(context.Background(), "No Such Movie"); !errors.Is(err, boxoffice.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	var statusErr *boxoffice.StatusError
+	_, err = boxoffice.NewHTTPClient(srv.URL, "wrong", srv.Client()).Fetch(context.Background(), "Inception")
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong API key, got %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/boxoffice", nil)
+	req.Header.Set("X-API-Key", "secret")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a title, got %d", resp.StatusCode)
+	}
+}
+
+func TestMockInjectsLatencyAndFailures(t *testing.T) {
+	mock := newServer(nil, options{
+		APIKey:        "secret",
+		Latency:       100 * time.Millisecond,
+		Jitter:        50 * time.Millisecond,
+		FailureRate:   0.5,
+		FailureStatus: http.StatusServiceUnavailable,
+		RetryAfter:    2 * time.Second,
+	})
+	var slept time.Duration
+	mock.sleep = func(d time.Duration) { slept = d }
+	mock.random = func() float64 { return 0.4 }
+
+	srv := httptest.NewServer(mock)
+	defer srv.Close()
+
+	_, err := boxoffice.NewHTTPClient(srv.URL, "secret", srv.Client()).Fetch(context.Background(), "Inception")
+	var statusErr *boxoffice.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable || statusErr.RetryAfter != 2*time.Second {
+		t.Fatalf("expected an injected 503 with Retry-After, got %v", err)
+	}
+	if slept != 120*time.Millisecond {
+		t.Fatalf("expected 120ms of latency, got %v", slept)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"cinema/db"
+	"cinema/handler/middleware"
+	"cinema/model"
+	"cinema/repository"
+	"cinema/service"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runCommand dispatches maintenance subcommands, e.g. `app repair-rating-stats`.
+func runCommand(name string, args []string) {
+	switch name {
+	case "serve":
+		runServer()
+	case "repair-rating-stats":
+		repairRatingStats()
+	case "issue-rater-token":
+		issueRaterToken(args)
+	case "mint-api-token":
+		mintAPIToken(args)
+	case "refresh-boxoffice":
+		refreshBoxOffice()
+	case "migrate":
+		migrate(args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\nusage: %s [serve|repair-rating-stats|issue-rater-token|mint-api-token|refresh-boxoffice|migrate]\n", name, os.Args[0])
+		os.Exit(2)
+	}
+}
+
+// repairRatingStats is a no-op on SQLite, where rating aggregates are
+// computed on read.
+func repairRatingStats() {
+	sqlDB, backend := mustConnect()
+	defer sqlDB.Close()
+	if backend != db.Postgres {
+		log.Println("rating stats are only materialised on Postgres; nothing to repair")
+		return
+	}
+
+	rebuilt, err := repository.NewPostgresRatingRepository(sqlDB).RebuildStats(context.Background())
+	if err != nil {
+		log.Fatalf("failed to rebuild rating stats: %v", err)
+	}
+	log.Printf("rebuilt rating stats for %d movies", rebuilt)
+}
+
+// issueRaterToken prints an HMAC rater token for RATER_AUTH_MODE=hmac.
+func issueRaterToken(args []string) {
+	flags := flag.NewFlagSet("issue-rater-token", flag.ExitOnError)
+	ttl := flags.Duration("ttl", 30*24*time.Hour, "token lifetime")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Fatal("usage: issue-rater-token [-ttl 720h] <rater-id>")
+	}
+
+	secret := os.Getenv("RATER_TOKEN_SECRET")
+	if err := middleware.CheckRaterTokenSecret([]byte(secret)); err != nil {
+		log.Fatalf("RATER_TOKEN_SECRET: %v", err)
+	}
+
+	token, err := middleware.SignRaterToken([]byte(secret), flags.Arg(0), time.Now().Add(*ttl))
+	if err != nil {
+		log.Fatalf("failed to sign rater token: %v", err)
+	}
+	fmt.Println(token)
+}
+
+// mintAPIToken stores a new scoped API token and prints its plaintext once.
+// It is the way to obtain the first admin token when AUTH_TOKEN is not set.
+func mintAPIToken(args []string) {
+	flags := flag.NewFlagSet("mint-api-token", flag.ExitOnError)
+	scopes := flags.String("scopes", model.ScopeAdmin, "comma-separated scopes")
+	ttl := flags.Duration("ttl", 0, "token lifetime (0 = no expiry)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		log.Fatal("usage: mint-api-token [-scopes movies:write,admin] [-ttl 720h] <name>")
+	}
+
+	sqlDB, backend := mustConnect()
+	defer sqlDB.Close()
+
+	var tokenRepo repository.APITokenRepository = repository.NewPostgresAPITokenRepository(sqlDB)
+	if backend == db.SQLite {
+		tokenRepo = repository.NewSQLiteAPITokenRepository(sqlDB)
+	}
+	tokens := service.NewTokenService(tokenRepo, "")
+	plaintext, token, err := tokens.Mint(context.Background(), service.MintTokenParams{
+		Name:   flags.Arg(0),
+		Scopes: strings.Split(*scopes, ","),
+		TTL:    *ttl,
+	})
+	if err != nil {
+		log.Fatalf("failed to mint api token: %v", err)
+	}
+	log.Printf("minted token %s with scopes %s", token.ID, strings.Join(token.Scopes, " "))
+	fmt.Println(plaintext)
+}
+
+// refreshBoxOffice runs a single stale box office sweep, resuming an
+// interrupted one, e.g. from cron when the in-process scheduler is disabled.
+func refreshBoxOffice() {
+	sqlDB, backend := mustConnect()
+	defer sqlDB.Close()
+	if backend != db.Postgres {
+		log.Fatal("refresh-boxoffice requires a Postgres database")
+	}
+
+	refresher := service.NewBoxOfficeRefresher(
+		repository.NewPostgresBoxOfficeRefreshRepository(sqlDB),
+		boxOfficeClientFromEnv(exchangeRatesFromEnv()),
+		boxOfficeRefreshOptionsFromEnv(),
+	)
+	run, err := refresher.RunOnce(context.Background())
+	if err != nil {
+		log.Fatalf("box office refresh failed: %v", err)
+	}
+	log.Printf("box office refresh run %d finished: %d refreshed, %d not found, %d failed", run.ID, run.Refreshed, run.NotFound, run.Failed)
+}
+
+// migrate applies or reverts the embedded schema migrations:
+// `migrate [up [n]]`, `migrate down [n]` (one by default) or `migrate status`.
+func migrate(args []string) {
+	const usage = "usage: migrate [up [n] | down [n] | status]"
+
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	steps := 0
+	if action == "down" {
+		steps = 1
+	}
+	if len(args) > 1 {
+		parsed, err := strconv.Atoi(args[1])
+		if err != nil || parsed <= 0 || len(args) > 2 {
+			log.Fatal(usage)
+		}
+		steps = parsed
+	}
+
+	sqlDB, backend := mustConnect()
+	defer sqlDB.Close()
+
+	migrator, err := db.NewMigrator(sqlDB, backend)
+	if err != nil {
+		log.Fatalf("failed to load migrations: %v", err)
+	}
+	ctx := context.Background()
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx, steps)
+		for _, migration := range applied {
+			log.Printf("applied migration %03d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			log.Println("schema is up to date")
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			log.Printf("reverted migration %03d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("migration failed: %v", err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("failed to read migration status: %v", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			if status.Drifted {
+				state += " (modified since applied)"
+			}
+			fmt.Printf("%03d_%s\t%s\n", status.Version, status.Name, state)
+		}
+	default:
+		log.Fatal(usage)
+	}
+}
+
+func mustConnect() (*sql.DB, db.Backend) {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		log.Fatal("DB_URL must be provided to connect to the database")
+	}
+
+	sqlDB, err := db.NewConnection(dbURL)
+	if err != nil {
+		log.Fatalf("failed to connect database: %v", err)
+	}
+	return sqlDB, db.BackendOf(dbURL)
+}
@@ -0,0 +1,143 @@
+package currency
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrNoRate = errors.New("no exchange rate available")
+
+// Rates is a table of exchange rates against the US dollar, each effective
+// from its date until the next one for the same currency. A nil *Rates only
+// knows USD.
+type Rates struct {
+	byCurrency map[string][]ratePoint
+}
+
+type ratePoint struct {
+	effective   time.Time
+	unitsPerUSD float64
+}
+
+// LoadRatesFile reads a CSV rate table, see LoadRatesCSV.
+func LoadRatesFile(path string) (*Rates, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rates, err := LoadRatesCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rates, nil
+}
+
+// LoadRatesCSV reads rows of the form
+//
+//	date,currency,units_per_usd
+//	2024-01-01,EUR,0.9050
+//
+// where units_per_usd is how many units of the currency one US dollar buys
+// from that date on. The header row is required.
+func LoadRatesCSV(r io.Reader) (*Rates, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if strings.Join(header, ",") != "date,currency,units_per_usd" {
+		return nil, fmt.Errorf("unexpected header %q, want date,currency,units_per_usd", strings.Join(header, ","))
+	}
+
+	rates := &Rates{byCurrency: make(map[string][]ratePoint)}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		effective, err := time.Parse("2006-01-02", row[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q", line, row[0])
+		}
+		code, err := Normalize(row[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w: %q", line, err, row[1])
+		}
+		units, err := strconv.ParseFloat(row[2], 64)
+		if err != nil || units <= 0 || math.IsInf(units, 0) {
+			return nil, fmt.Errorf("line %d: invalid rate %q", line, row[2])
+		}
+
+		rates.byCurrency[code] = append(rates.byCurrency[code], ratePoint{effective: effective, unitsPerUSD: units})
+	}
+
+	for code, points := range rates.byCurrency {
+		sort.Slice(points, func(i, j int) bool { return points[i].effective.Before(points[j].effective) })
+		for i := 1; i < len(points); i++ {
+			if points[i].effective.Equal(points[i-1].effective) {
+				return nil, fmt.Errorf("duplicate %s rate for %s", code, points[i].effective.Format("2006-01-02"))
+			}
+		}
+	}
+	return rates, nil
+}
+
+// UnitsPerUSD returns the rate of code in effect on the given day.
+func (r *Rates) UnitsPerUSD(code string, on time.Time) (float64, error) {
+	if code == USD {
+		return 1, nil
+	}
+	if r == nil {
+		return 0, fmt.Errorf("%w for %s", ErrNoRate, code)
+	}
+
+	points := r.byCurrency[code]
+	i := sort.Search(len(points), func(i int) bool { return points[i].effective.After(on) })
+	if i == 0 {
+		return 0, fmt.Errorf("%w for %s on %s", ErrNoRate, code, on.Format("2006-01-02"))
+	}
+	return points[i-1].unitsPerUSD, nil
+}
+
+// Has reports whether the table has any rate for code.
+func (r *Rates) Has(code string) bool {
+	if code == USD {
+		return true
+	}
+	return r != nil && len(r.byCurrency[code]) > 0
+}
+
+// Convert converts an amount in whole units between currencies at the rates
+// in effect on the given day, rounding to the nearest unit.
+func (r *Rates) Convert(amount int64, from, to string, on time.Time) (int64, error) {
+	if from == to {
+		return amount, nil
+	}
+	fromRate, err := r.UnitsPerUSD(from, on)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := r.UnitsPerUSD(to, on)
+	if err != nil {
+		return 0, err
+	}
+	return int64(math.Round(float64(amount) / fromRate * toRate)), nil
+}
@@ -0,0 +1,94 @@
+package currency
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRates = `date,currency,units_per_usd
+2024-01-01,EUR,0.9
+2024-06-01,eur,0.8
+2024-01-01,JPY,150
+`
+
+func TestNormalizeValidatesISO4217(t *testing.T) {
+	if code, err := Normalize(" eur "); err != nil || code != "EUR" {
+		t.Fatalf("expected EUR, got %q, %v", code, err)
+	}
+	for _, code := range []string{"", "EURO", "XYZ"} {
+		if _, err := Normalize(code); !errors.Is(err, ErrUnknownCurrency) {
+			t.Fatalf("expected %q to be rejected, got %v", code, err)
+		}
+	}
+}
+
+func TestRatesAreDateEffective(t *testing.T) {
+	rates, err := LoadRatesCSV(strings.NewReader(testRates))
+	if err != nil {
+		t.Fatalf("LoadRatesCSV returned error: %v", err)
+	}
+
+	cases := []struct {
+		on   string
+		want float64
+	}{
+		{"2024-01-01", 0.9},
+		{"2024-05-31", 0.9},
+		{"2024-06-01", 0.8},
+		{"2025-01-01", 0.8},
+	}
+	for _, tc := range cases {
+		on, _ := time.Parse("2006-01-02", tc.on)
+		got, err := rates.UnitsPerUSD("EUR", on)
+		if err != nil || got != tc.want {
+			t.Fatalf("EUR on %s: expected %v, got %v, %v", tc.on, tc.want, got, err)
+		}
+	}
+
+	if _, err := rates.UnitsPerUSD("EUR", time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)); !errors.Is(err, ErrNoRate) {
+		t.Fatalf("expected no rate before the first entry, got %v", err)
+	}
+	if !rates.Has("JPY") || rates.Has("GBP") || !rates.Has(USD) {
+		t.Fatal("unexpected Has results")
+	}
+}
+
+func TestConvertGoesThroughUSD(t *testing.T) {
+	rates, err := LoadRatesCSV(strings.NewReader(testRates))
+	if err != nil {
+		t.Fatalf("LoadRatesCSV returned error: %v", err)
+	}
+	on := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	if got, err := rates.Convert(900, "EUR", USD, on); err != nil || got != 1000 {
+		t.Fatalf("expected 900 EUR to be 1000 USD, got %d, %v", got, err)
+	}
+	if got, err := rates.Convert(90, "EUR", "JPY", on); err != nil || got != 15000 {
+		t.Fatalf("expected 90 EUR to be 15000 JPY, got %d, %v", got, err)
+	}
+	if _, err := rates.Convert(1, "GBP", USD, on); !errors.Is(err, ErrNoRate) {
+		t.Fatalf("expected ErrNoRate for GBP, got %v", err)
+	}
+
+	var none *Rates
+	if got, err := none.Convert(5, USD, USD, on); err != nil || got != 5 {
+		t.Fatalf("expected a nil table to handle USD, got %d, %v", got, err)
+	}
+}
+
+func TestLoadRatesCSVRejectsBadTables(t *testing.T) {
+	tables := map[string]string{
+		"header":    "day,code,rate\n",
+		"currency":  "date,currency,units_per_usd\n2024-01-01,EURO,0.9\n",
+		"rate":      "date,currency,units_per_usd\n2024-01-01,EUR,-1\n",
+		"date":      "date,currency,units_per_usd\n01/01/2024,EUR,0.9\n",
+		"duplicate": "date,currency,units_per_usd\n2024-01-01,EUR,0.9\n2024-01-01,EUR,0.8\n",
+	}
+	for name, table := range tables {
+		if _, err := LoadRatesCSV(strings.NewReader(table)); err == nil {
+			t.Fatalf("expected the %s table to be rejected", name)
+		}
+	}
+}
@@ -4,15 +4,39 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
 )
 
+// Backend identifies the database engine behind a connection string.
+type Backend string
+
+const (
+	Postgres Backend = "postgres"
+	SQLite   Backend = "sqlite"
+)
+
+// BackendOf selects the backend by the scheme of dsn: sqlite:path opens a
+// SQLite database file (sqlite:///abs/path for an absolute path), anything
+// else is handed to the Postgres driver.
+func BackendOf(dsn string) Backend {
+	if strings.HasPrefix(strings.ToLower(dsn), "sqlite:") {
+		return SQLite
+	}
+	return Postgres
+}
+
 func NewConnection(dsn string) (*sql.DB, error) {
 	if dsn == "" {
 		return nil, fmt.Errorf("database connection string is required")
 	}
+	if BackendOf(dsn) == SQLite {
+		return openSQLite(dsn)
+	}
 
 	const (
 		maxRetries    = 10
@@ -46,3 +70,35 @@ func NewConnection(dsn string) (*sql.DB, error) {
 
 	return nil, fmt.Errorf("database connection failed after retries: %w", err)
 }
+
+// openSQLite opens the database file named by a sqlite: URL with foreign keys
+// enforced. SQLite allows a single writer, so the pool is limited to one
+// connection: writers queue in the pool instead of failing with SQLITE_BUSY.
+func openSQLite(dsn string) (*sql.DB, error) {
+	path, rawQuery, _ := strings.Cut(dsn[len("sqlite:"):], "?")
+	path = strings.TrimPrefix(path, "//")
+	if path == "" {
+		return nil, fmt.Errorf("sqlite connection string must name a database file")
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("parse sqlite connection string: %w", err)
+	}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Add("_pragma", "journal_mode(WAL)")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("open database failed: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open database failed: %w", err)
+	}
+	log.Printf("sqlite database %s opened", path)
+	return db, nil
+}
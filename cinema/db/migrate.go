@@ -0,0 +1,376 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql migrations/sqlite/*.sql
+var embeddedMigrations embed.FS
+
+var (
+	// ErrChecksumMismatch means an applied migration file has been edited
+	// since it ran. Migrations are immutable once applied; add a new one.
+	ErrChecksumMismatch = errors.New("applied migration has been modified")
+	// ErrUnknownMigration means the database has a migration applied that
+	// this build does not know, e.g. after a rollback to an older release.
+	ErrUnknownMigration = errors.New("applied migration is unknown to this build")
+	ErrNoDownMigration  = errors.New("migration cannot be reverted")
+)
+
+// migrationLockKey identifies the advisory lock that serialises migrations
+// across app instances sharing a database.
+const migrationLockKey int64 = 0x63696e656d61 // "cinema"
+
+// Migration is a pair of SQL files NNN_name.sql and, optionally,
+// NNN_name.down.sql. Checksum covers the up file.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus describes a known migration and whether it has been applied.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+	// Drifted is set when the applied checksum no longer matches the file.
+	Drifted bool
+}
+
+// Migrator applies the embedded migrations of a backend and records them in
+// the schema_migrations table. On Postgres every operation holds an advisory
+// lock, so instances started together migrate one at a time; SQLite databases
+// belong to a single node and need no lock.
+//
+// Up migrations must be idempotent (CREATE ... IF NOT EXISTS and the like):
+// databases initialised before schema_migrations existed have no history and
+// simply run every migration again.
+type Migrator struct {
+	db         *sql.DB
+	backend    Backend
+	migrations []Migration
+}
+
+// NewMigrator loads the migrations for backend: db/migrations for Postgres
+// and db/migrations/sqlite for SQLite.
+func NewMigrator(db *sql.DB, backend Backend) (*Migrator, error) {
+	dir := "migrations"
+	if backend == SQLite {
+		dir = "migrations/sqlite"
+	}
+
+	migrations, err := LoadMigrations(embeddedMigrations, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, backend: backend, migrations: migrations}, nil
+}
+
+// LoadMigrations reads the migrations in dir of fsys, ordered by version.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		filename := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(filename, ".sql") {
+			continue
+		}
+
+		base := strings.TrimSuffix(filename, ".sql")
+		down := strings.HasSuffix(base, ".down")
+		base = strings.TrimSuffix(base, ".down")
+
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil || version <= 0 || name == "" {
+			return nil, fmt.Errorf("migration %s: file name must look like 001_name.sql", filename)
+		}
+
+		raw, err := fs.ReadFile(fsys, path.Join(dir, filename))
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %s: version %d is also used by %q", filename, version, migration.Name)
+		}
+		if down {
+			migration.Down = string(raw)
+			continue
+		}
+		if migration.Up != "" {
+			return nil, fmt.Errorf("migration %s: duplicate version %d", filename, version)
+		}
+		migration.Up = string(raw)
+		sum := sha256.Sum256(raw)
+		migration.Checksum = hex.EncodeToString(sum[:])
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %03d_%s has a down file but no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies up to steps pending migrations, all of them when steps <= 0, and
+// returns those applied. Each migration runs in its own transaction.
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, history map[int64]appliedMigration) error {
+		for _, migration := range m.migrations {
+			if _, done := history[migration.Version]; done {
+				continue
+			}
+			if steps > 0 && len(applied) == steps {
+				break
+			}
+
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`, migration.Version, migration.Name, migration.Checksum)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %03d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// those reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, history map[int64]appliedMigration) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, done := history[migration.Version]; !done {
+				continue
+			}
+			if strings.TrimSpace(migration.Down) == "" {
+				return fmt.Errorf("migration %03d_%s: %w", migration.Version, migration.Name, ErrNoDownMigration)
+			}
+
+			err := inTx(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("revert migration %03d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every known migration with its applied state. Unlike Up and
+// Down it reports drift instead of failing on it.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := ensureMigrationsTable(ctx, conn, m.backend); err != nil {
+		return nil, err
+	}
+	history, err := loadHistory(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if applied, ok := history[migration.Version]; ok {
+			appliedAt := applied.appliedAt
+			status.AppliedAt = &appliedAt
+			status.Drifted = applied.checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// locked runs fn on a single connection holding the migration advisory lock,
+// after checking the recorded history against the known migrations.
+func (m *Migrator) locked(ctx context.Context, fn func(*sql.Conn, map[int64]appliedMigration) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Session-level lock: it is released on unlock or when the connection
+	// drops, so a crashed migrator cannot block the next one.
+	if m.backend == Postgres {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer func() {
+			if _, unlockErr := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey); unlockErr != nil && err == nil {
+				err = fmt.Errorf("release migration lock: %w", unlockErr)
+			}
+		}()
+	}
+
+	if err := ensureMigrationsTable(ctx, conn, m.backend); err != nil {
+		return err
+	}
+	history, err := loadHistory(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if err := m.verify(history); err != nil {
+		return err
+	}
+	return fn(conn, history)
+}
+
+func (m *Migrator) verify(history map[int64]appliedMigration) error {
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	versions := make([]int64, 0, len(history))
+	for version := range history {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	for _, version := range versions {
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("version %d: %w", version, ErrUnknownMigration)
+		}
+		if history[version].checksum != migration.Checksum {
+			return fmt.Errorf("migration %03d_%s: %w", version, migration.Name, ErrChecksumMismatch)
+		}
+	}
+	return nil
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn, backend Backend) error {
+	query := `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version BIGINT PRIMARY KEY,
+            name TEXT NOT NULL,
+            checksum TEXT NOT NULL,
+            applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        )
+    `
+	if backend == SQLite {
+		query = `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version INTEGER PRIMARY KEY,
+            name TEXT NOT NULL,
+            checksum TEXT NOT NULL,
+            applied_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+        )
+    `
+	}
+
+	_, err := conn.ExecContext(ctx, query)
+	return err
+}
+
+func loadHistory(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make(map[int64]appliedMigration)
+	for rows.Next() {
+		var (
+			version   int64
+			applied   appliedMigration
+			appliedAt timestamp
+		)
+		if err := rows.Scan(&version, &applied.checksum, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied.appliedAt = appliedAt.Time
+		history[version] = applied
+	}
+	return history, rows.Err()
+}
+
+// timestamp scans both native Postgres timestamps and the ISO 8601 text
+// SQLite stores.
+type timestamp struct {
+	time.Time
+}
+
+func (t *timestamp) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case time.Time:
+		t.Time = v
+		return nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, v)
+		t.Time = parsed
+		return err
+	default:
+		return fmt.Errorf("cannot scan %T into a timestamp", value)
+	}
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(*sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestEmbeddedMigrationsAreOrderedAndReversible(t *testing.T) {
+	for _, dir := range []string{"migrations", "migrations/sqlite"} {
+		migrations, err := LoadMigrations(embeddedMigrations, dir)
+		if err != nil {
+			t.Fatalf("LoadMigrations(%s) returned error: %v", dir, err)
+		}
+		if len(migrations) == 0 {
+			t.Fatalf("expected embedded migrations in %s", dir)
+		}
+		for i, migration := range migrations {
+			if migration.Version != int64(i+1) {
+				t.Fatalf("expected version %d at position %d of %s, got %d", i+1, i, dir, migration.Version)
+			}
+			if strings.TrimSpace(migration.Down) == "" {
+				t.Fatalf("migration %s/%03d_%s has no down file", dir, migration.Version, migration.Name)
+			}
+		}
+	}
+}
+
+func TestSQLiteMigrationsApplyAndRevert(t *testing.T) {
+	ctx := context.Background()
+	sqlDB, err := NewConnection("sqlite://" + filepath.Join(t.TempDir(), "cinema.db"))
+	if err != nil {
+		t.Fatalf("NewConnection returned error: %v", err)
+	}
+	defer sqlDB.Close()
+
+	migrator, err := NewMigrator(sqlDB, SQLite)
+	if err != nil {
+		t.Fatalf("NewMigrator returned error: %v", err)
+	}
+	applied, err := migrator.Up(ctx, 0)
+	if err != nil {
+		t.Fatalf("Up returned error: %v", err)
+	}
+	if len(applied) == 0 {
+		t.Fatal("expected migrations to be applied")
+	}
+	if again, err := migrator.Up(ctx, 0); err != nil || len(again) != 0 {
+		t.Fatalf("expected a second Up to do nothing, got %d migrations and %v", len(again), err)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Fatalf("expected migration %d to be applied", status.Version)
+		}
+	}
+
+	if _, err := migrator.Down(ctx, len(applied)); err != nil {
+		t.Fatalf("Down returned error: %v", err)
+	}
+	var tables int
+	if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'movies'`).Scan(&tables); err != nil {
+		t.Fatalf("query schema: %v", err)
+	}
+	if tables != 0 {
+		t.Fatal("expected Down to drop the movies table")
+	}
+}
+
+func TestMigratorRefusesEditedOrUnknownMigrations(t *testing.T) {
+	ctx := context.Background()
+	sqlDB, err := NewConnection("sqlite://" + filepath.Join(t.TempDir(), "cinema.db"))
+	if err != nil {
+		t.Fatalf("NewConnection returned error: %v", err)
+	}
+	defer sqlDB.Close()
+
+	original := []Migration{
+		{Version: 1, Name: "first", Up: "CREATE TABLE a (id INTEGER);", Down: "DROP TABLE a;", Checksum: "one"},
+		{Version: 2, Name: "second", Up: "CREATE TABLE b (id INTEGER);", Down: "DROP TABLE b;", Checksum: "two"},
+	}
+	migrator := &Migrator{db: sqlDB, backend: SQLite, migrations: original}
+	if _, err := migrator.Up(ctx, 0); err != nil {
+		t.Fatalf("Up returned error: %v", err)
+	}
+
+	edited := append([]Migration(nil), original...)
+	edited[0].Checksum = "edited"
+	migrator.migrations = edited
+	if _, err := migrator.Up(ctx, 0); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected Up to refuse an edited migration, got %v", err)
+	}
+	if _, err := migrator.Down(ctx, 1); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected Down to refuse an edited migration, got %v", err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	if !statuses[0].Drifted || statuses[1].Drifted {
+		t.Fatalf("expected only the edited migration to be reported as drifted, got %+v", statuses)
+	}
+
+	migrator.migrations = original[:1]
+	if _, err := migrator.Up(ctx, 0); !errors.Is(err, ErrUnknownMigration) {
+		t.Fatalf("expected Up to refuse an unknown applied migration, got %v", err)
+	}
+}
+
+// TestPostgresMigratorWaitsForTheAdvisoryLock runs against the database at
+// TEST_DB_URL and is skipped without it.
+func TestPostgresMigratorWaitsForTheAdvisoryLock(t *testing.T) {
+	dsn := os.Getenv("TEST_DB_URL")
+	if dsn == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+	ctx := context.Background()
+	sqlDB, err := NewConnection(dsn)
+	if err != nil {
+		t.Fatalf("NewConnection returned error: %v", err)
+	}
+	defer sqlDB.Close()
+
+	migrator, err := NewMigrator(sqlDB, Postgres)
+	if err != nil {
+		t.Fatalf("NewMigrator returned error: %v", err)
+	}
+
+	holder, err := sqlDB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("open connection: %v", err)
+	}
+	defer holder.Close()
+	if _, err := holder.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		t.Fatalf("take migration lock: %v", err)
+	}
+
+	waiting, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	if _, err := migrator.Up(waiting, 0); err == nil || !strings.Contains(err.Error(), "acquire migration lock") {
+		t.Fatalf("expected Up to wait for the held lock, got %v", err)
+	}
+
+	if _, err := holder.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+		t.Fatalf("release migration lock: %v", err)
+	}
+	if _, err := migrator.Up(ctx, 0); err != nil {
+		t.Fatalf("expected Up to proceed once the lock is released, got %v", err)
+	}
+}
+
+func TestLoadMigrationsPairsFilesAndChecksumsUp(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/002_second.sql":     {Data: []byte("CREATE TABLE b ();")},
+		"m/001_first.sql":      {Data: []byte("CREATE TABLE a ();")},
+		"m/001_first.down.sql": {Data: []byte("DROP TABLE a;")},
+		"m/README.md":          {Data: []byte("ignored")},
+	}
+	migrations, err := LoadMigrations(fsys, "m")
+	if err != nil {
+		t.Fatalf("LoadMigrations returned error: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Name != "first" || migrations[0].Down != "DROP TABLE a;" || migrations[1].Down != "" {
+		t.Fatalf("unexpected migrations %+v", migrations)
+	}
+
+	edited := fstest.MapFS{
+		"m/001_first.sql":      {Data: []byte("CREATE TABLE a (id INT);")},
+		"m/001_first.down.sql": {Data: []byte("DROP TABLE IF EXISTS a;")},
+		"m/002_second.sql":     {Data: []byte("CREATE TABLE b ();")},
+	}
+	again, err := LoadMigrations(edited, "m")
+	if err != nil {
+		t.Fatalf("LoadMigrations returned error: %v", err)
+	}
+	if again[0].Checksum == migrations[0].Checksum || again[1].Checksum != migrations[1].Checksum {
+		t.Fatal("expected only the edited up file to change its checksum")
+	}
+}
+
+func TestLoadMigrationsRejectsBadLayouts(t *testing.T) {
+	layouts := map[string]fstest.MapFS{
+		"unnumbered": {"m/init.sql": {Data: []byte("SELECT 1;")}},
+		"duplicate":  {"m/001_a.sql": {Data: []byte("SELECT 1;")}, "m/001_b.sql": {Data: []byte("SELECT 1;")}},
+		"down only":  {"m/001_a.down.sql": {Data: []byte("SELECT 1;")}},
+	}
+	for name, fsys := range layouts {
+		if _, err := LoadMigrations(fsys, "m"); err == nil {
+			t.Fatalf("expected the %s layout to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+-- Soft-deleted movies are purged: without deleted_at they would come back to
+-- life and could clash with the titles that reused them.
+DROP INDEX IF EXISTS idx_movies_title_lower_live;
+DELETE FROM movies WHERE deleted_at IS NOT NULL;
+ALTER TABLE movies DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE movies ADD CONSTRAINT movies_title_key UNIQUE (title);
@@ -0,0 +1,6 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Titles only need to be unique among live movies so that a soft-deleted
+-- title can be reused. Uniqueness follows the case-insensitive lookups.
+ALTER TABLE movies DROP CONSTRAINT IF EXISTS movies_title_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_movies_title_lower_live ON movies ((LOWER(title))) WHERE deleted_at IS NULL;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_ratings_movie_created_rater;
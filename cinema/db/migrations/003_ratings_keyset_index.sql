@@ -0,0 +1,2 @@
+-- Supports keyset pagination of a movie's ratings by (created_at, rater_id).
+CREATE INDEX IF NOT EXISTS idx_ratings_movie_created_rater ON ratings (movie_id, created_at, rater_id);
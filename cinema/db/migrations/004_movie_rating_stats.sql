@@ -0,0 +1,29 @@
+-- Incrementally maintained rating aggregates, updated in the same transaction
+-- as every rating write. histogram[i] counts ratings equal to i * 0.5.
+CREATE TABLE IF NOT EXISTS movie_rating_stats (
+    movie_id UUID PRIMARY KEY REFERENCES movies(id) ON DELETE CASCADE,
+    rating_sum NUMERIC(14,1) NOT NULL DEFAULT 0,
+    rating_count INTEGER NOT NULL DEFAULT 0,
+    histogram INTEGER[] NOT NULL DEFAULT ARRAY[0,0,0,0,0,0,0,0,0,0],
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO movie_rating_stats (movie_id, rating_sum, rating_count, histogram)
+SELECT movie_id,
+       SUM(rating),
+       COUNT(*),
+       ARRAY[
+           COUNT(*) FILTER (WHERE rating = 0.5),
+           COUNT(*) FILTER (WHERE rating = 1.0),
+           COUNT(*) FILTER (WHERE rating = 1.5),
+           COUNT(*) FILTER (WHERE rating = 2.0),
+           COUNT(*) FILTER (WHERE rating = 2.5),
+           COUNT(*) FILTER (WHERE rating = 3.0),
+           COUNT(*) FILTER (WHERE rating = 3.5),
+           COUNT(*) FILTER (WHERE rating = 4.0),
+           COUNT(*) FILTER (WHERE rating = 4.5),
+           COUNT(*) FILTER (WHERE rating = 5.0)
+       ]::INTEGER[]
+FROM ratings
+GROUP BY movie_id
+ON CONFLICT (movie_id) DO NOTHING;
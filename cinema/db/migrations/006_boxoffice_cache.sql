@@ -0,0 +1,9 @@
+-- Shared box office lookup cache. A NULL record is a cached "not found".
+CREATE TABLE IF NOT EXISTS boxoffice_cache (
+    title_key TEXT PRIMARY KEY,
+    record JSONB,
+    expires_at TIMESTAMPTZ NOT NULL,
+    fetched_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_boxoffice_cache_expires_at ON boxoffice_cache (expires_at);
@@ -0,0 +1,14 @@
+-- Box office enrichment runs asynchronously from an outbox of jobs that is
+-- written in the same transaction as the movie.
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS enrichment_status TEXT NOT NULL DEFAULT 'complete';
+
+CREATE TABLE IF NOT EXISTS enrichment_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    movie_id UUID NOT NULL UNIQUE REFERENCES movies(id) ON DELETE CASCADE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_enrichment_jobs_run_at ON enrichment_jobs (run_at, id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS boxoffice_refresh_runs;
+DROP INDEX IF EXISTS idx_movies_boxoffice_checked_at;
+ALTER TABLE movies DROP COLUMN IF EXISTS boxoffice_checked_at;
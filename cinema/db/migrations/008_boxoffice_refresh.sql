@@ -0,0 +1,23 @@
+-- When box office data was last looked up (found or not), so stale records can
+-- be refreshed. Existing rows start as NULL and are picked up by the first run.
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS boxoffice_checked_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_movies_boxoffice_checked_at ON movies (boxoffice_checked_at) WHERE deleted_at IS NULL;
+
+-- One row per refresh sweep. The cursor and counters are saved after every
+-- batch; an unfinished run whose lease has expired is resumed by the next
+-- scheduler that starts.
+CREATE TABLE IF NOT EXISTS boxoffice_refresh_runs (
+    id BIGSERIAL PRIMARY KEY,
+    stale_before TIMESTAMPTZ NOT NULL,
+    cursor_created_at TIMESTAMPTZ,
+    cursor_id UUID,
+    refreshed INTEGER NOT NULL DEFAULT 0,
+    not_found INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    lease_expires_at TIMESTAMPTZ NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_boxoffice_refresh_runs_active ON boxoffice_refresh_runs ((true)) WHERE finished_at IS NULL;
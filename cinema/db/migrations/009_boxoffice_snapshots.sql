@@ -0,0 +1,28 @@
+-- Append-only history of box office data, one row per successful lookup, so
+-- the growth of a film's gross can be charted. movies.box_office keeps only
+-- the latest values.
+CREATE TABLE IF NOT EXISTS box_office_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    worldwide BIGINT NOT NULL,
+    opening_weekend_usa BIGINT,
+    currency TEXT NOT NULL,
+    source TEXT NOT NULL,
+    provider_updated_at TIMESTAMPTZ,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_box_office_snapshots_movie_recorded ON box_office_snapshots (movie_id, recorded_at, id);
+
+-- Seed the history with the data already stored on each movie.
+INSERT INTO box_office_snapshots (movie_id, worldwide, opening_weekend_usa, currency, source, provider_updated_at, recorded_at)
+SELECT id,
+       (box_office->'revenue'->>'worldwide')::BIGINT,
+       (box_office->'revenue'->>'openingWeekendUSA')::BIGINT,
+       COALESCE(box_office->>'currency', ''),
+       COALESCE(box_office->>'source', ''),
+       NULLIF(box_office->>'lastUpdated', '0001-01-01T00:00:00Z')::TIMESTAMPTZ,
+       COALESCE(boxoffice_checked_at, updated_at)
+FROM movies
+WHERE box_office IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM box_office_snapshots s WHERE s.movie_id = movies.id);
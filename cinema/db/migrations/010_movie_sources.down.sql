@@ -0,0 +1 @@
+ALTER TABLE movies DROP COLUMN IF EXISTS sources;
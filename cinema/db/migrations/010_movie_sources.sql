@@ -0,0 +1,4 @@
+-- Which box office provider supplied each enriched attribute, e.g.
+-- {"distributor": "override", "revenue": "primary"}. Attributes entered by
+-- users have no entry.
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS sources JSONB NOT NULL DEFAULT '{}'::jsonb;
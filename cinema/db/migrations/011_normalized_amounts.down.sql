@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_movies_worldwide_usd;
+DROP INDEX IF EXISTS idx_movies_budget_usd;
+ALTER TABLE movies DROP COLUMN IF EXISTS worldwide_usd;
+ALTER TABLE movies DROP COLUMN IF EXISTS budget_usd;
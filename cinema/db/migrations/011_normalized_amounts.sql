@@ -0,0 +1,24 @@
+-- Amounts normalised to US dollars for filtering across currencies. User
+-- budgets are entered in USD; provider amounts are converted at the exchange
+-- rate in effect when the provider last updated them. Existing rows are
+-- backfilled where the amounts are already in USD: budgets of movies whose box
+-- office is in USD, or that are known to be user-entered. Other budgets stay
+-- NULL until the next box office refresh converts them.
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS budget_usd BIGINT;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS worldwide_usd BIGINT;
+
+UPDATE movies
+SET budget_usd = budget
+WHERE budget_usd IS NULL
+  AND budget IS NOT NULL
+  AND (COALESCE(box_office->>'currency', '') IN ('', 'USD')
+       OR (sources <> '{}'::jsonb AND NOT sources ? 'budget'));
+
+UPDATE movies
+SET worldwide_usd = (box_office->'revenue'->>'worldwide')::BIGINT
+WHERE worldwide_usd IS NULL
+  AND box_office IS NOT NULL
+  AND COALESCE(box_office->>'currency', '') IN ('', 'USD');
+
+CREATE INDEX IF NOT EXISTS idx_movies_budget_usd ON movies (budget_usd) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_movies_worldwide_usd ON movies (worldwide_usd) WHERE deleted_at IS NULL;
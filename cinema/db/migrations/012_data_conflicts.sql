@@ -0,0 +1,18 @@
+-- Attributes on which a box office provider disagrees with the stored movie,
+-- kept for an admin to resolve. Values are stored as text (dates as
+-- YYYY-MM-DD, budgets in whole US dollars). A movie has at most one open
+-- conflict per attribute; later lookups refresh it.
+CREATE TABLE IF NOT EXISTS data_conflicts (
+    id BIGSERIAL PRIMARY KEY,
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    our_value TEXT NOT NULL,
+    their_value TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'accepted_ours', 'accepted_theirs')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_conflicts_open ON data_conflicts (movie_id, field) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_data_conflicts_status_created ON data_conflicts (status, created_at, id);
@@ -0,0 +1,4 @@
+-- Version counts the writes to a movie, starting at 1. It is the movie's
+-- entity tag: clients send it back in If-Match so that concurrent edits fail
+-- instead of overwriting each other.
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
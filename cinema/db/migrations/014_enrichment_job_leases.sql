@@ -0,0 +1,5 @@
+-- Workers lease enrichment jobs instead of holding a row lock during the
+-- provider lookup. A job whose lease has expired, e.g. because its worker
+-- crashed, is claimed again; attempts counts claims and fences out a worker
+-- whose lease was taken over.
+ALTER TABLE enrichment_jobs ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMPTZ;
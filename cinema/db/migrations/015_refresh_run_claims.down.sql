@@ -0,0 +1 @@
+ALTER TABLE boxoffice_refresh_runs DROP COLUMN IF EXISTS claims;
@@ -0,0 +1,4 @@
+-- claims counts how often a refresh run has been claimed. Saving progress and
+-- finishing a run require the count seen at claim time, which fences out a
+-- scheduler whose lease expired and was taken over.
+ALTER TABLE boxoffice_refresh_runs ADD COLUMN IF NOT EXISTS claims INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+DELETE FROM data_conflicts WHERE status = 'stale';
+ALTER TABLE data_conflicts DROP CONSTRAINT IF EXISTS data_conflicts_status_check;
+ALTER TABLE data_conflicts ADD CONSTRAINT data_conflicts_status_check CHECK (status IN ('open', 'accepted_ours', 'accepted_theirs'));
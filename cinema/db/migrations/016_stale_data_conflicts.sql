@@ -0,0 +1,4 @@
+-- A conflict becomes stale when an admin accepts the provider's value after
+-- the movie's own value has changed since the conflict was raised.
+ALTER TABLE data_conflicts DROP CONSTRAINT IF EXISTS data_conflicts_status_check;
+ALTER TABLE data_conflicts ADD CONSTRAINT data_conflicts_status_check CHECK (status IN ('open', 'accepted_ours', 'accepted_theirs', 'stale'));
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS box_office_snapshots;
+DROP TABLE IF EXISTS api_tokens;
+DROP TABLE IF EXISTS ratings;
+DROP TABLE IF EXISTS movies;
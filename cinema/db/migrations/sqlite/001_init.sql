@@ -0,0 +1,63 @@
+-- Schema for single-node deployments on SQLite. It mirrors the Postgres
+-- schema with these differences:
+--   * title_key holds the title lower-cased by the application, since SQLite
+--     only folds ASCII; it backs case-insensitive lookups and uniqueness.
+--   * Timestamps are ISO 8601 UTC text with microseconds, written by the
+--     application, so that they compare correctly as strings.
+--   * box_office and sources are JSON text.
+--   * Rating aggregates are computed from ratings on read.
+CREATE TABLE IF NOT EXISTS movies (
+    id TEXT PRIMARY KEY,
+    title TEXT NOT NULL,
+    title_key TEXT NOT NULL,
+    genre TEXT NOT NULL,
+    release_date TEXT NOT NULL,
+    distributor TEXT,
+    budget INTEGER,
+    budget_usd INTEGER,
+    mpa_rating TEXT,
+    box_office TEXT,
+    worldwide_usd INTEGER,
+    enrichment_status TEXT NOT NULL DEFAULT 'complete',
+    sources TEXT NOT NULL DEFAULT '{}',
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    deleted_at TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_movies_title_key_live ON movies (title_key) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_movies_created_at_id ON movies (created_at, id);
+
+CREATE TABLE IF NOT EXISTS ratings (
+    movie_id TEXT NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    rater_id TEXT NOT NULL,
+    rating REAL NOT NULL CHECK (rating >= 0.5 AND rating <= 5.0 AND rating * 2 = CAST(rating * 2 AS INTEGER)),
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    PRIMARY KEY (movie_id, rater_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ratings_movie_created_rater ON ratings (movie_id, created_at, rater_id);
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    token_hash BLOB NOT NULL UNIQUE,
+    scopes TEXT NOT NULL,
+    expires_at TEXT,
+    revoked_at TEXT,
+    created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS box_office_snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    movie_id TEXT NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    worldwide INTEGER NOT NULL,
+    opening_weekend_usa INTEGER,
+    currency TEXT NOT NULL,
+    source TEXT NOT NULL,
+    provider_updated_at TEXT,
+    recorded_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_box_office_snapshots_movie_recorded ON box_office_snapshots (movie_id, recorded_at, id);
@@ -0,0 +1,2 @@
+-- Version counts the writes to a movie, as on Postgres.
+ALTER TABLE movies ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"cinema/boxoffice"
+	"cinema/repository"
+	"cinema/service"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BoxOfficeHandler exposes the box office history of movies and the
+// operational state of the box office integration.
+type BoxOfficeHandler struct {
+	history   *service.BoxOfficeHistoryService
+	cache     *boxoffice.CachingClient
+	providers []boxoffice.Provider
+}
+
+type boxOfficeHistoryPointResponse struct {
+	At string `json:"at"`
+	boxOfficeResponse
+	Snapshots int `json:"snapshots"`
+}
+
+type boxOfficeHistoryResponse struct {
+	Title    string                          `json:"title"`
+	Interval string                          `json:"interval"`
+	Points   []boxOfficeHistoryPointResponse `json:"points"`
+}
+
+type boxOfficeCacheStatsResponse struct {
+	Hits         uint64 `json:"hits"`
+	NegativeHits uint64 `json:"negativeHits"`
+	StoreHits    uint64 `json:"storeHits"`
+	Misses       uint64 `json:"misses"`
+	Evictions    uint64 `json:"evictions"`
+	Entries      int    `json:"entries"`
+}
+
+type boxOfficeBreakerResponse struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+	RetryAt             *time.Time `json:"retryAt,omitempty"`
+}
+
+type boxOfficeProviderResponse struct {
+	Name    string                    `json:"name"`
+	Breaker *boxOfficeBreakerResponse `json:"breaker,omitempty"`
+}
+
+// boxOfficeStatusResponse keeps the breaker of the first HTTP provider at the
+// top level for existing dashboards; providers lists all of them.
+type boxOfficeStatusResponse struct {
+	Breaker   *boxOfficeBreakerResponse    `json:"breaker,omitempty"`
+	Providers []boxOfficeProviderResponse  `json:"providers"`
+	Cache     *boxOfficeCacheStatsResponse `json:"cache,omitempty"`
+}
+
+// NewBoxOfficeHandler accepts a nil cache when caching is disabled. Providers
+// wrapped in a ResilientClient report their circuit breaker.
+func NewBoxOfficeHandler(history *service.BoxOfficeHistoryService, cache *boxoffice.CachingClient, providers []boxoffice.Provider) *BoxOfficeHandler {
+	return &BoxOfficeHandler{history: history, cache: cache, providers: providers}
+}
+
+// History lists the box office snapshots of a movie, optionally limited to
+// ?from= and ?to= (inclusive YYYY-MM-DD dates) and grouped by ?interval=daily
+// or weekly.
+func (h *BoxOfficeHandler) History(c *gin.Context) {
+	title := c.Param("title")
+	if strings.TrimSpace(title) == "" {
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "movie title is required", nil)
+		return
+	}
+
+	params := service.BoxOfficeHistoryParams{Interval: service.HistoryInterval(c.DefaultQuery("interval", string(service.HistoryRaw)))}
+	for _, bound := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &params.From}, {"to", &params.To}} {
+		raw := c.Query(bound.name)
+		if raw == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "BAD_REQUEST", bound.name+" must be a date in YYYY-MM-DD format", nil)
+			return
+		}
+		*bound.dst = &date
+	}
+
+	movie, points, err := h.history.History(c.Request.Context(), title, params)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrInvalidInput):
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "interval must be raw, daily or weekly and from must not be after to", nil)
+		return
+	case errors.Is(err, repository.ErrMovieNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Movie not found", nil)
+		return
+	default:
+		log.Printf("History error: %v", err)
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to load box office history", nil)
+		return
+	}
+
+	resp := boxOfficeHistoryResponse{
+		Title:    movie.Title,
+		Interval: string(params.Interval),
+		Points:   make([]boxOfficeHistoryPointResponse, 0, len(points)),
+	}
+	for _, point := range points {
+		resp.Points = append(resp.Points, boxOfficeHistoryPointResponse{
+			At:                point.At.UTC().Format(time.RFC3339),
+			boxOfficeResponse: toBoxOfficeResponse(point.BoxOffice),
+			Snapshots:         point.Snapshots,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *BoxOfficeHandler) Status(c *gin.Context) {
+	resp := boxOfficeStatusResponse{Providers: make([]boxOfficeProviderResponse, 0, len(h.providers))}
+	for _, provider := range h.providers {
+		status := boxOfficeProviderResponse{Name: provider.Name}
+		if resilient, ok := provider.Client.(*boxoffice.ResilientClient); ok {
+			breaker := resilient.Breaker()
+			status.Breaker = &boxOfficeBreakerResponse{
+				State:               breaker.State,
+				ConsecutiveFailures: breaker.ConsecutiveFailures,
+				OpenedAt:            breaker.OpenedAt,
+				RetryAt:             breaker.RetryAt,
+			}
+			if resp.Breaker == nil {
+				resp.Breaker = status.Breaker
+			}
+		}
+		resp.Providers = append(resp.Providers, status)
+	}
+	if h.cache != nil {
+		stats := h.cache.Stats()
+		resp.Cache = &boxOfficeCacheStatsResponse{
+			Hits:         stats.Hits,
+			NegativeHits: stats.NegativeHits,
+			StoreHits:    stats.StoreHits,
+			Misses:       stats.Misses,
+			Evictions:    stats.Evictions,
+			Entries:      stats.Entries,
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"cinema/model"
+	"cinema/service"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notModified evaluates If-None-Match and If-Modified-Since against the
+// current representation. If-None-Match takes precedence when both are sent,
+// as required by RFC 9110.
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if header := c.GetHeader("If-None-Match"); header != "" {
+		return etagMatches(header, etag)
+	}
+
+	if header := c.GetHeader("If-Modified-Since"); header != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(header)
+		if err != nil {
+			return false
+		}
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// etagMatches performs the weak comparison used for If-None-Match.
+func etagMatches(header, etag string) bool {
+	current := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == current {
+			return true
+		}
+	}
+	return false
+}
+
+func setCacheValidators(c *gin.Context, etag string, lastModified time.Time) {
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// movieETag is the entity tag of a movie as returned by writes. Detail reads
+// extend it, but every movie ETag starts with the movie's version.
+func movieETag(movie *model.Movie) string {
+	return `"` + strconv.FormatInt(movie.Version, 10) + `"`
+}
+
+// requireIfMatch reads the If-Match condition of a movie write and answers
+// 428 when there is none. Only the version an entity tag starts with is
+// compared, since the rest of a detail ETag describes ratings and currency
+// conversion, which a write does not touch. Weak tags never match: If-Match
+// uses the strong comparison.
+func requireIfMatch(c *gin.Context) (service.VersionMatch, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		writeError(c, http.StatusPreconditionRequired, "PRECONDITION_REQUIRED", "If-Match header with the movie's ETag is required", nil)
+		return service.VersionMatch{}, false
+	}
+	return parseIfMatch(header), true
+}
+
+func parseIfMatch(header string) service.VersionMatch {
+	var match service.VersionMatch
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return service.VersionMatch{Any: true}
+		}
+		if !strings.HasPrefix(candidate, `"`) || !strings.HasSuffix(candidate, `"`) || len(candidate) < 2 {
+			continue
+		}
+		version, _, _ := strings.Cut(candidate[1:len(candidate)-1], "-")
+		if parsed, err := strconv.ParseInt(version, 10, 64); err == nil {
+			match.Versions = append(match.Versions, parsed)
+		}
+	}
+	return match
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"cinema/currency"
+	"cinema/model"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currencyConversion converts the amounts of a movie response into the
+// currency requested with ?currency=, at the rates in effect on the day.
+type currencyConversion struct {
+	rates *currency.Rates
+	to    string
+	on    time.Time
+}
+
+// parseCurrencyQuery returns nil when no conversion was requested. It writes
+// a 400 response for unknown codes and for currencies without a rate.
+func parseCurrencyQuery(c *gin.Context, rates *currency.Rates) (*currencyConversion, bool) {
+	value := strings.TrimSpace(c.Query("currency"))
+	if value == "" {
+		return nil, true
+	}
+
+	code, err := currency.Normalize(value)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "currency must be an ISO 4217 code", nil)
+		return nil, false
+	}
+	if !rates.Has(code) {
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "No exchange rate is available for "+code, nil)
+		return nil, false
+	}
+
+	return &currencyConversion{rates: rates, to: code, on: time.Now()}, true
+}
+
+// apply converts the budget from its normalised USD amount and the box office
+// revenue from the currency it was reported in. The budget is omitted when it
+// could not be normalised; box office data keeps its own currency when there
+// is no rate for it.
+func (conv *currencyConversion) apply(response *movieResponse, movie *model.Movie) {
+	if conv == nil {
+		return
+	}
+	response.Currency = conv.to
+
+	response.Budget = nil
+	if movie.BudgetUSD != nil {
+		if budget, err := conv.rates.Convert(*movie.BudgetUSD, currency.USD, conv.to, conv.on); err == nil {
+			response.Budget = &budget
+		}
+	}
+
+	if response.BoxOffice == nil {
+		return
+	}
+	from := response.BoxOffice.Currency
+	if from == "" {
+		from = currency.USD
+	}
+	worldwide, err := conv.rates.Convert(response.BoxOffice.Revenue.Worldwide, from, conv.to, conv.on)
+	if err != nil {
+		return
+	}
+	revenue := boxOfficeRevenueResponse{Worldwide: worldwide}
+	if opening := response.BoxOffice.Revenue.OpeningWeekendUSA; opening != nil {
+		converted, err := conv.rates.Convert(*opening, from, conv.to, conv.on)
+		if err != nil {
+			return
+		}
+		revenue.OpeningWeekendUSA = &converted
+	}
+	boxOffice := *response.BoxOffice
+	boxOffice.Revenue = revenue
+	boxOffice.Currency = conv.to
+	response.BoxOffice = &boxOffice
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"cinema/model"
+	"cinema/repository"
+	"cinema/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DataConflictHandler struct {
+	service *service.DataConflictService
+}
+
+type resolveConflictRequest struct {
+	Accept string `json:"accept"`
+}
+
+type dataConflictResponse struct {
+	ID         int64      `json:"id"`
+	MovieTitle string     `json:"movieTitle"`
+	Field      string     `json:"field"`
+	Ours       string     `json:"ours"`
+	Theirs     string     `json:"theirs"`
+	Source     string     `json:"source,omitempty"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+}
+
+type dataConflictPageResponse struct {
+	Items      []dataConflictResponse `json:"items"`
+	NextCursor *string                `json:"nextCursor,omitempty"`
+}
+
+func NewDataConflictHandler(service *service.DataConflictService) *DataConflictHandler {
+	return &DataConflictHandler{service: service}
+}
+
+func (h *DataConflictHandler) ListConflicts(c *gin.Context) {
+	limit := 0
+	if value := strings.TrimSpace(c.Query("limit")); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "BAD_REQUEST", "limit must be an integer", nil)
+			return
+		}
+		limit = parsed
+	}
+
+	conflicts, nextCursor, err := h.service.List(c.Request.Context(), service.ListConflictsParams{
+		Status:     strings.TrimSpace(c.Query("status")),
+		MovieTitle: c.Query("movie"),
+		Limit:      limit,
+		Cursor:     strings.TrimSpace(c.Query("cursor")),
+	})
+	switch {
+	case err == nil:
+		response := dataConflictPageResponse{
+			Items:      make([]dataConflictResponse, 0, len(conflicts)),
+			NextCursor: nextCursor,
+		}
+		for _, conflict := range conflicts {
+			response.Items = append(response.Items, toDataConflictResponse(conflict))
+		}
+		c.JSON(http.StatusOK, response)
+	case errors.Is(err, service.ErrInvalidInput):
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "status or cursor is invalid", gin.H{
+			"statuses": []string{model.ConflictOpen, model.ConflictAcceptedOurs, model.ConflictAcceptedTheirs, model.ConflictStale},
+		})
+	case errors.Is(err, repository.ErrMovieNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Movie not found", nil)
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list conflicts", nil)
+	}
+}
+
+func (h *DataConflictHandler) ResolveConflict(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Conflict not found", nil)
+		return
+	}
+
+	var req resolveConflictRequest
+	if err := bindJSONBody(c.Request.Body, &req); err != nil {
+		if errors.Is(err, errJSONBodyTooLarge) {
+			writeError(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "Request body exceeds the maximum allowed size", nil)
+			return
+		}
+		writeError(c, http.StatusUnprocessableEntity, "UNPROCESSABLE_ENTITY", "Malformed JSON payload", nil)
+		return
+	}
+
+	conflict, err := h.service.Resolve(c.Request.Context(), id, strings.TrimSpace(req.Accept))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, toDataConflictResponse(conflict))
+	case errors.Is(err, service.ErrInvalidInput):
+		writeError(c, http.StatusUnprocessableEntity, "UNPROCESSABLE_ENTITY", "accept must be ours or theirs", nil)
+	case errors.Is(err, repository.ErrConflictNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Conflict not found", nil)
+	case errors.Is(err, repository.ErrConflictResolved):
+		writeError(c, http.StatusConflict, "CONFLICT", "Conflict is already resolved", nil)
+	case errors.Is(err, repository.ErrConflictStale):
+		writeError(c, http.StatusConflict, "CONFLICT", "The movie has changed since the conflict was raised; the conflict is now stale", nil)
+	case errors.Is(err, repository.ErrMovieNotFound):
+		writeError(c, http.StatusConflict, "CONFLICT", "The movie has been deleted", nil)
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to resolve conflict", nil)
+	}
+}
+
+func toDataConflictResponse(conflict *model.DataConflict) dataConflictResponse {
+	return dataConflictResponse{
+		ID:         conflict.ID,
+		MovieTitle: conflict.MovieTitle,
+		Field:      conflict.Field,
+		Ours:       conflict.Ours,
+		Theirs:     conflict.Theirs,
+		Source:     conflict.Source,
+		Status:     conflict.Status,
+		CreatedAt:  conflict.CreatedAt,
+		ResolvedAt: conflict.ResolvedAt,
+	}
+}
@@ -1,36 +1,71 @@
 package middleware
 
 import (
+	"cinema/model"
+	"context"
+	"errors"
+	"log"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+const apiTokenKey = "apiToken"
+
 type authError struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 }
 
-func RequireBearerToken(expected string) gin.HandlerFunc {
-	token := strings.TrimSpace(expected)
+// TokenAuthenticator resolves a bearer token to the API token it identifies.
+type TokenAuthenticator interface {
+	Authenticate(ctx context.Context, token string) (*model.APIToken, error)
+}
+
+// RequireScope rejects requests whose bearer token is invalid (401) or does
+// not grant scope (403). The resolved token is available via APIToken.
+func RequireScope(auth TokenAuthenticator, scope string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		header := strings.TrimSpace(c.GetHeader("Authorization"))
-		if !strings.HasPrefix(header, "Bearer ") {
+		provided, ok := bearerToken(c.Request)
+		if !ok {
 			unauthorised(c)
 			return
 		}
 
-		provided := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
-		if provided == "" || provided != token {
+		token, err := auth.Authenticate(c.Request.Context(), provided)
+		switch {
+		case errors.Is(err, model.ErrUnauthenticated):
 			unauthorised(c)
 			return
+		case err != nil:
+			log.Printf("api token lookup failed: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, authError{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to verify authentication information",
+			})
+			return
 		}
 
+		if !token.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, authError{
+				Code:    "FORBIDDEN",
+				Message: "Token lacks the " + scope + " scope",
+			})
+			return
+		}
+
+		c.Set(apiTokenKey, token)
 		c.Next()
 	}
 }
 
+// APIToken returns the token resolved by RequireScope, or nil if none.
+func APIToken(c *gin.Context) *model.APIToken {
+	token, _ := c.Get(apiTokenKey)
+	resolved, _ := token.(*model.APIToken)
+	return resolved
+}
+
 func unauthorised(c *gin.Context) {
 	c.AbortWithStatusJSON(http.StatusUnauthorized, authError{
 		Code:    "UNAUTHORIZED",
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"cinema/model"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type stubTokenAuthenticator map[string]*model.APIToken
+
+func (s stubTokenAuthenticator) Authenticate(ctx context.Context, token string) (*model.APIToken, error) {
+	if resolved, ok := s[token]; ok {
+		return resolved, nil
+	}
+	return nil, model.ErrUnauthenticated
+}
+
+func TestRequireScopeChecksTokenScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	auth := stubTokenAuthenticator{
+		"writer": {ID: "1", Scopes: []string{model.ScopeMoviesWrite}},
+		"reader": {ID: "2"},
+		"admin":  {ID: "3", Scopes: []string{model.ScopeAdmin}},
+	}
+
+	router := gin.New()
+	router.POST("/movies", RequireScope(auth, model.ScopeMoviesWrite), func(c *gin.Context) {
+		c.String(http.StatusCreated, APIToken(c).ID)
+	})
+
+	cases := map[string]int{
+		"":       http.StatusUnauthorized,
+		"nope":   http.StatusUnauthorized,
+		"reader": http.StatusForbidden,
+		"writer": http.StatusCreated,
+		"admin":  http.StatusCreated,
+	}
+	for token, want := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/movies", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != want {
+			t.Fatalf("token %q: expected %d, got %d", token, want, rec.Code)
+		}
+	}
+}
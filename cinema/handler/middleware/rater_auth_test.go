@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func requestWithBearer(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/movies/Heat/ratings", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestHMACRaterAuthenticatorVerifiesSignedTokens(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	auth := NewHMACRaterAuthenticator(secret)
+	auth.now = func() time.Time { return now }
+
+	token, err := SignRaterToken(secret, "alice", now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("SignRaterToken returned error: %v", err)
+	}
+
+	raterID, err := auth.Authenticate(requestWithBearer(token))
+	if err != nil || raterID != "alice" {
+		t.Fatalf("expected alice, got %q (%v)", raterID, err)
+	}
+
+	forged, _ := SignRaterToken([]byte("another-secret-another-secret-xx"), "alice", now.Add(time.Hour))
+	if _, err := auth.Authenticate(requestWithBearer(forged)); !errors.Is(err, ErrInvalidRaterToken) {
+		t.Fatalf("expected forged token to be rejected, got %v", err)
+	}
+
+	auth.now = func() time.Time { return now.Add(2 * time.Hour) }
+	if _, err := auth.Authenticate(requestWithBearer(token)); !errors.Is(err, ErrInvalidRaterToken) {
+		t.Fatalf("expected expired token to be rejected, got %v", err)
+	}
+}
+
+func TestCheckRaterTokenSecretRequiresMinimumLength(t *testing.T) {
+	if err := CheckRaterTokenSecret([]byte("0123456789abcdef0123456789abcdef")); err != nil {
+		t.Fatalf("expected a %d-character secret to be accepted, got %v", MinRaterTokenSecretLength, err)
+	}
+	for _, secret := range []string{"", "0123456789abcdef0123456789abcde"} {
+		if err := CheckRaterTokenSecret([]byte(secret)); err == nil {
+			t.Fatalf("expected a %d-character secret to be rejected", len(secret))
+		}
+	}
+}
+
+func TestJWTRaterAuthenticatorVerifiesES256AgainstJWKS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	jwks, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "EC",
+			"kid": "k1",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		}},
+	})
+
+	auth, err := NewJWTRaterAuthenticator(jwks, "https://issuer.example", "cinema")
+	if err != nil {
+		t.Fatalf("NewJWTRaterAuthenticator returned error: %v", err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	auth.now = func() time.Time { return now }
+
+	sign := func(header, claims map[string]interface{}) string {
+		h, _ := json.Marshal(header)
+		c, _ := json.Marshal(claims)
+		signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+		digest := sha256.Sum256([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		sig := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+	}
+
+	claims := map[string]interface{}{
+		"sub": "bob",
+		"iss": "https://issuer.example",
+		"aud": []string{"cinema"},
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	raterID, err := auth.Authenticate(requestWithBearer(sign(map[string]interface{}{"alg": "ES256", "kid": "k1"}, claims)))
+	if err != nil || raterID != "bob" {
+		t.Fatalf("expected bob, got %q (%v)", raterID, err)
+	}
+
+	if _, err := auth.Authenticate(requestWithBearer(sign(map[string]interface{}{"alg": "none", "kid": "k1"}, claims))); !errors.Is(err, ErrInvalidRaterToken) {
+		t.Fatalf("expected alg none to be rejected, got %v", err)
+	}
+
+	claims["aud"] = "someone-else"
+	if _, err := auth.Authenticate(requestWithBearer(sign(map[string]interface{}{"alg": "ES256", "kid": "k1"}, claims))); !errors.Is(err, ErrInvalidRaterToken) {
+		t.Fatalf("expected wrong audience to be rejected, got %v", err)
+	}
+}
+
+func TestHeaderRaterAuthenticatorRequiresHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/movies/Heat/ratings", nil)
+	if _, err := (HeaderRaterAuthenticator{}).Authenticate(req); err == nil {
+		t.Fatal("expected missing header to be rejected")
+	}
+
+	req.Header.Set(RaterIDHeader, " carol ")
+	if raterID, err := (HeaderRaterAuthenticator{}).Authenticate(req); err != nil || raterID != "carol" {
+		t.Fatalf("expected carol, got %q (%v)", raterID, err)
+	}
+}
@@ -0,0 +1,294 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// jwtClockSkew tolerates small clock differences with the token issuer.
+const jwtClockSkew = 30 * time.Second
+
+// JWTRaterAuthenticator verifies RS256/ES256 bearer JWTs against keys loaded
+// from a local JWKS file. The rater ID is taken from the "sub" claim.
+type JWTRaterAuthenticator struct {
+	keys     map[string]crypto.PublicKey
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// NewJWTRaterAuthenticator builds an authenticator from a JWKS document.
+// Empty issuer or audience disables the corresponding claim check.
+func NewJWTRaterAuthenticator(jwks []byte, issuer, audience string) (*JWTRaterAuthenticator, error) {
+	keys, err := parseJWKS(jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWTRaterAuthenticator{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+		now:      time.Now,
+	}, nil
+}
+
+func NewJWTRaterAuthenticatorFromFile(path, issuer, audience string) (*JWTRaterAuthenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read JWKS file: %w", err)
+	}
+	return NewJWTRaterAuthenticator(data, issuer, audience)
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *int64          `json:"exp"`
+	NotBefore *int64          `json:"nbf"`
+}
+
+func (a *JWTRaterAuthenticator) Authenticate(r *http.Request) (string, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return "", ErrInvalidRaterToken
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidRaterToken
+	}
+
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return "", ErrInvalidRaterToken
+	}
+
+	key, err := a.lookupKey(header.Kid)
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidRaterToken
+	}
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return "", err
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return "", ErrInvalidRaterToken
+	}
+	if err := a.validateClaims(claims); err != nil {
+		return "", err
+	}
+
+	return claims.Subject, nil
+}
+
+func (a *JWTRaterAuthenticator) lookupKey(kid string) (crypto.PublicKey, error) {
+	if kid == "" && len(a.keys) == 1 {
+		for _, key := range a.keys {
+			return key, nil
+		}
+	}
+	key, ok := a.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidRaterToken, kid)
+	}
+	return key, nil
+}
+
+func (a *JWTRaterAuthenticator) validateClaims(claims jwtClaims) error {
+	now := a.now()
+
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: missing subject", ErrInvalidRaterToken)
+	}
+	if claims.ExpiresAt == nil || !now.Before(time.Unix(*claims.ExpiresAt, 0).Add(jwtClockSkew)) {
+		return fmt.Errorf("%w: expired", ErrInvalidRaterToken)
+	}
+	if claims.NotBefore != nil && now.Add(jwtClockSkew).Before(time.Unix(*claims.NotBefore, 0)) {
+		return fmt.Errorf("%w: not yet valid", ErrInvalidRaterToken)
+	}
+	if a.issuer != "" && claims.Issuer != a.issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidRaterToken)
+	}
+	if a.audience != "" && !audienceContains(claims.Audience, a.audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidRaterToken)
+	}
+
+	return nil
+}
+
+// audienceContains accepts both the string and array forms of "aud".
+func audienceContains(raw json.RawMessage, expected string) bool {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single == expected
+	}
+
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return false
+	}
+	for _, audience := range many {
+		if audience == expected {
+			return true
+		}
+	}
+	return false
+}
+
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: key does not match alg %s", ErrInvalidRaterToken, alg)
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+			return ErrInvalidRaterToken
+		}
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve != elliptic.P256() || len(signature) != 64 {
+			return fmt.Errorf("%w: key does not match alg %s", ErrInvalidRaterToken, alg)
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return ErrInvalidRaterToken
+		}
+	default:
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidRaterToken, alg)
+	}
+
+	return nil
+}
+
+func decodeJWTSegment(segment string, dst interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS loads the RSA and P-256 signing keys from a JWKS document.
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		var (
+			pub crypto.PublicKey
+			err error
+		)
+		switch key.Kty {
+		case "RSA":
+			pub, err = rsaKeyFromJWK(key)
+		case "EC":
+			pub, err = ecKeyFromJWK(key)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse JWKS key %q: %w", key.Kid, err)
+		}
+		keys[key.Kid] = pub
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func rsaKeyFromJWK(key jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() < 3 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func ecKeyFromJWK(key jwk) (*ecdsa.PublicKey, error) {
+	if key.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported curve %q", key.Crv)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(key.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(key.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(x) != 32 || len(y) != 32 {
+		return nil, errors.New("invalid P-256 coordinate length")
+	}
+
+	// crypto/ecdh rejects points that are not on the curve.
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
@@ -1,10 +1,15 @@
 package handler
 
 import (
+	"bytes"
+	"cinema/boxoffice"
+	"cinema/currency"
 	"cinema/model"
 	"cinema/repository"
 	"cinema/service"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"net/url"
@@ -17,6 +22,8 @@ import (
 
 type MovieHandler struct {
 	service *service.MovieService
+	ratings *service.RatingService
+	rates   *currency.Rates
 }
 
 type createMovieRequest struct {
@@ -29,21 +36,25 @@ type createMovieRequest struct {
 }
 
 type movieResponse struct {
-	ID          string             `json:"id"`
-	Title       string             `json:"title"`
-	Genre       string             `json:"genre"`
-	ReleaseDate string             `json:"releaseDate"`
-	Distributor *string            `json:"distributor,omitempty"`
-	Budget      *int64             `json:"budget,omitempty"`
-	MpaRating   *string            `json:"mpaRating,omitempty"`
-	BoxOffice   *boxOfficeResponse `json:"boxOffice"`
+	ID               string             `json:"id"`
+	Title            string             `json:"title"`
+	Genre            string             `json:"genre"`
+	ReleaseDate      string             `json:"releaseDate"`
+	Distributor      *string            `json:"distributor,omitempty"`
+	Budget           *int64             `json:"budget,omitempty"`
+	Currency         string             `json:"currency,omitempty"`
+	MpaRating        *string            `json:"mpaRating,omitempty"`
+	BoxOffice        *boxOfficeResponse `json:"boxOffice"`
+	EnrichmentStatus string             `json:"enrichmentStatus"`
+	Sources          map[string]string  `json:"sources,omitempty"`
 }
 
 type boxOfficeResponse struct {
-	Revenue     boxOfficeRevenueResponse `json:"revenue"`
-	Currency    string                   `json:"currency"`
-	Source      string                   `json:"source"`
-	LastUpdated string                   `json:"lastUpdated"`
+	Revenue         boxOfficeRevenueResponse `json:"revenue"`
+	Currency        string                   `json:"currency"`
+	Source          string                   `json:"source"`
+	LastUpdated     string                   `json:"lastUpdated"`
+	MatchConfidence *float64                 `json:"matchConfidence,omitempty"`
 }
 
 type boxOfficeRevenueResponse struct {
@@ -51,94 +62,325 @@ type boxOfficeRevenueResponse struct {
 	OpeningWeekendUSA *int64 `json:"openingWeekendUSA,omitempty"`
 }
 
+type fieldChangeResponse struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+type boxOfficeRefreshResponse struct {
+	Mode    string                `json:"mode"`
+	Changes []fieldChangeResponse `json:"changes"`
+	Movie   movieResponse         `json:"movie"`
+}
+
+type movieDetailResponse struct {
+	movieResponse
+	Rating ratingAggregateResponse `json:"rating"`
+}
+
 type moviePageResponse struct {
 	Items      []movieResponse `json:"items"`
 	NextCursor *string         `json:"nextCursor,omitempty"`
 }
 
-func NewMovieHandler(service *service.MovieService) *MovieHandler {
-	return &MovieHandler{service: service}
+// NewMovieHandler takes the exchange rates used for ?currency= conversions;
+// with nil rates only USD is available.
+func NewMovieHandler(service *service.MovieService, ratings *service.RatingService, rates *currency.Rates) *MovieHandler {
+	return &MovieHandler{service: service, ratings: ratings, rates: rates}
 }
 
 func (h *MovieHandler) CreateMovie(c *gin.Context) {
-	var req createMovieRequest
+	params, ok := bindMovieParams(c, "CreateMovie")
+	if !ok {
+		return
+	}
 
-	if err := bindJSONBody(c.Request.Body, &req); err != nil {
+	movie, err := h.service.CreateMovie(c.Request.Context(), params)
+	switch {
+	case err == nil:
+		location := "/movies/" + url.PathEscape(movie.Title)
+		c.Header("Location", location)
+		c.Header("ETag", movieETag(movie))
+		c.JSON(http.StatusCreated, toMovieResponse(movie))
+	case errors.Is(err, service.ErrInvalidInput):
+		writeError(c, http.StatusUnprocessableEntity, "UNPROCESSABLE_ENTITY", "Invalid request payload", nil)
+	case errors.Is(err, repository.ErrMovieAlreadyExists):
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "Movie with the same title already exists", nil)
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create movie", nil)
+	}
+}
+
+func (h *MovieHandler) ReplaceMovie(c *gin.Context) {
+	title := c.Param("title")
+	if strings.TrimSpace(title) == "" {
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "movie title is required", nil)
+		return
+	}
+
+	match, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	params, ok := bindMovieParams(c, "ReplaceMovie")
+	if !ok {
+		return
+	}
+
+	movie, err := h.service.ReplaceMovie(c.Request.Context(), title, match, params)
+	h.writeUpdateResult(c, title, movie, err)
+}
+
+func (h *MovieHandler) PatchMovie(c *gin.Context) {
+	title := c.Param("title")
+	if strings.TrimSpace(title) == "" {
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "movie title is required", nil)
+		return
+	}
+
+	match, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+
+	var fields map[string]json.RawMessage
+	if err := bindJSONBody(c.Request.Body, &fields); err != nil {
 		if errors.Is(err, errJSONBodyTooLarge) {
 			writeError(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "Request body exceeds the maximum allowed size", nil)
 			return
 		}
-		log.Printf("CreateMovie bind error: %v", err)
+		log.Printf("PatchMovie bind error: %v", err)
 		writeError(c, http.StatusUnprocessableEntity, "UNPROCESSABLE_ENTITY", "Malformed JSON payload", nil)
 		return
 	}
 
-	if strings.TrimSpace(req.Title) == "" || strings.TrimSpace(req.Genre) == "" || strings.TrimSpace(req.ReleaseDate) == "" {
-		writeError(c, http.StatusUnprocessableEntity, "UNPROCESSABLE_ENTITY", "title, genre and releaseDate are required", nil)
+	patch, err := toMoviePatch(fields)
+	if err != nil {
+		writeError(c, http.StatusUnprocessableEntity, "UNPROCESSABLE_ENTITY", err.Error(), nil)
 		return
 	}
 
-	params := service.CreateMovieParams{
-		Title:       req.Title,
-		Genre:       req.Genre,
-		ReleaseDate: req.ReleaseDate,
-		Distributor: req.Distributor,
-		Budget:      req.Budget,
-		MpaRating:   req.MpaRating,
-	}
+	movie, err := h.service.PatchMovie(c.Request.Context(), title, match, patch)
+	h.writeUpdateResult(c, title, movie, err)
+}
 
-	movie, err := h.service.CreateMovie(c.Request.Context(), params)
+func (h *MovieHandler) writeUpdateResult(c *gin.Context, title string, movie *model.Movie, err error) {
 	switch {
 	case err == nil:
-		location := "/movies/" + url.PathEscape(movie.Title)
-		c.Header("Location", location)
-		c.JSON(http.StatusCreated, toMovieResponse(movie))
+		if !strings.EqualFold(movie.Title, title) {
+			c.Header("Location", "/movies/"+url.PathEscape(movie.Title))
+		}
+		c.Header("ETag", movieETag(movie))
+		c.JSON(http.StatusOK, toMovieResponse(movie))
 	case errors.Is(err, service.ErrInvalidInput):
 		writeError(c, http.StatusUnprocessableEntity, "UNPROCESSABLE_ENTITY", "Invalid request payload", nil)
+	case errors.Is(err, repository.ErrMovieNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Movie not found", nil)
 	case errors.Is(err, repository.ErrMovieAlreadyExists):
-		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "Movie with the same title already exists", nil)
+		writeError(c, http.StatusConflict, "CONFLICT", "Movie with the same title already exists", nil)
+	case errors.Is(err, service.ErrPreconditionFailed):
+		writePreconditionFailed(c)
+	case errors.Is(err, repository.ErrMovieConflict):
+		writeError(c, http.StatusConflict, "CONFLICT", "Movie was modified concurrently, please retry", nil)
 	default:
-		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create movie", nil)
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update movie", nil)
 	}
 }
 
-func (h *MovieHandler) ListMovies(c *gin.Context) {
-	var (
-		yearParam        *int
-		budgetParam      *int64
-		genreParam       *string
-		distributorParam *string
-		mpaParam         *string
-	)
+func writePreconditionFailed(c *gin.Context) {
+	writeError(c, http.StatusPreconditionFailed, "PRECONDITION_FAILED", "Movie has changed since it was fetched; fetch it again and retry", nil)
+}
 
-	if value := strings.TrimSpace(c.Query("year")); value != "" {
-		parsed, err := strconv.Atoi(value)
+// DeleteMovie soft-deletes by default; pass ?hard=true to purge the movie and
+// its ratings.
+func (h *MovieHandler) DeleteMovie(c *gin.Context) {
+	title := c.Param("title")
+	if strings.TrimSpace(title) == "" {
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "movie title is required", nil)
+		return
+	}
+
+	hard := false
+	if value := strings.TrimSpace(c.Query("hard")); value != "" {
+		parsed, err := strconv.ParseBool(value)
 		if err != nil {
-			writeError(c, http.StatusBadRequest, "BAD_REQUEST", "year must be an integer", nil)
+			writeError(c, http.StatusBadRequest, "BAD_REQUEST", "hard must be a boolean", nil)
 			return
 		}
-		yearParam = &parsed
+		hard = parsed
+	}
+	match, ok := requireIfMatch(c)
+	if !ok {
+		return
 	}
 
-	if value := strings.TrimSpace(c.Query("budget")); value != "" {
-		parsed, err := strconv.ParseInt(value, 10, 64)
-		if err != nil || parsed < 0 {
-			writeError(c, http.StatusBadRequest, "BAD_REQUEST", "budget must be a non-negative integer", nil)
-			return
-		}
-		budgetParam = &parsed
+	err := h.service.DeleteMovie(c.Request.Context(), title, hard, match)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, repository.ErrMovieNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Movie not found", nil)
+	case errors.Is(err, service.ErrPreconditionFailed):
+		writePreconditionFailed(c)
+	case errors.Is(err, repository.ErrMovieConflict):
+		writeError(c, http.StatusConflict, "CONFLICT", "Movie was modified concurrently, please retry", nil)
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete movie", nil)
 	}
+}
 
-	if value := strings.TrimSpace(c.Query("genre")); value != "" {
-		genreParam = &value
+// MovieAction dispatches custom methods of the form
+// POST /movies/{title}/{resource}:{verb}. gin only honours an escaped colon in
+// a route when the server is started through Engine.Run, so the verb is
+// matched here instead of in the route table.
+func (h *MovieHandler) MovieAction(c *gin.Context) {
+	switch c.Param("action") {
+	case "boxoffice:refresh":
+		h.RefreshBoxOffice(c)
+	default:
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Unknown movie action", nil)
 	}
+}
 
-	if value := strings.TrimSpace(c.Query("distributor")); value != "" {
-		distributorParam = &value
+// RefreshBoxOffice re-fetches box office data for a movie. ?mode=force lets
+// the provider overwrite distributor, budget and rating; the default
+// fill-missing only sets attributes that are empty. Unlike edits it does not
+// require If-Match, since it writes provider data rather than the client's,
+// but honours it when sent.
+func (h *MovieHandler) RefreshBoxOffice(c *gin.Context) {
+	title := c.Param("title")
+	if strings.TrimSpace(title) == "" {
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "movie title is required", nil)
+		return
 	}
 
-	if value := strings.TrimSpace(c.Query("mpaRating")); value != "" {
-		mpaParam = &value
+	match := service.VersionMatch{Any: true}
+	if header := strings.TrimSpace(c.GetHeader("If-Match")); header != "" {
+		match = parseIfMatch(header)
+	}
+
+	mode := service.RefreshMode(c.DefaultQuery("mode", string(service.RefreshFillMissing)))
+	movie, changes, err := h.service.RefreshBoxOffice(c.Request.Context(), title, mode, match)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrInvalidInput):
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "mode must be fill-missing or force", nil)
+		return
+	case errors.Is(err, repository.ErrMovieNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Movie not found", nil)
+		return
+	case errors.Is(err, service.ErrPreconditionFailed):
+		writePreconditionFailed(c)
+		return
+	case errors.Is(err, repository.ErrMovieConflict):
+		writeError(c, http.StatusConflict, "CONFLICT", "Movie was modified concurrently, please retry", nil)
+		return
+	case errors.Is(err, boxoffice.ErrCircuitOpen):
+		writeError(c, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Box office provider is temporarily unavailable", nil)
+		return
+	case errors.Is(err, service.ErrBoxOfficeUnavailable):
+		log.Printf("RefreshBoxOffice upstream error: %v", err)
+		writeError(c, http.StatusBadGateway, "BAD_GATEWAY", "Box office provider request failed", nil)
+		return
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to refresh box office data", nil)
+		return
+	}
+
+	resp := boxOfficeRefreshResponse{
+		Mode:    string(mode),
+		Changes: make([]fieldChangeResponse, 0, len(changes)),
+		Movie:   toMovieResponse(movie),
+	}
+	for _, change := range changes {
+		resp.Changes = append(resp.Changes, fieldChangeResponse{Field: change.Field, Old: change.Old, New: change.New})
+	}
+	c.Header("ETag", movieETag(movie))
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *MovieHandler) RestoreMovie(c *gin.Context) {
+	title := c.Param("title")
+	if strings.TrimSpace(title) == "" {
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "movie title is required", nil)
+		return
+	}
+
+	movie, err := h.service.RestoreMovie(c.Request.Context(), title)
+	switch {
+	case err == nil:
+		c.Header("Location", "/movies/"+url.PathEscape(movie.Title))
+		c.Header("ETag", movieETag(movie))
+		c.JSON(http.StatusOK, toMovieResponse(movie))
+	case errors.Is(err, repository.ErrMovieNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "No deleted movie with this title", nil)
+	case errors.Is(err, repository.ErrMovieAlreadyExists):
+		writeError(c, http.StatusConflict, "CONFLICT", "Title is in use by another movie", nil)
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to restore movie", nil)
+	}
+}
+
+func (h *MovieHandler) GetMovie(c *gin.Context) {
+	title := c.Param("title")
+	if strings.TrimSpace(title) == "" {
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "movie title is required", nil)
+		return
+	}
+	conversion, ok := parseCurrencyQuery(c, h.rates)
+	if !ok {
+		return
+	}
+
+	movie, err := h.service.GetMovie(c.Request.Context(), title)
+	switch {
+	case err == nil:
+	case errors.Is(err, repository.ErrMovieNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Movie not found", nil)
+		return
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch movie", nil)
+		return
+	}
+
+	average, count, err := h.ratings.AggregateForMovie(c.Request.Context(), movie.ID)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch rating aggregation", nil)
+		return
+	}
+
+	// Ratings do not change the movie's version, so the aggregate is folded
+	// into the ETag to keep cached detail pages from serving a stale rating.
+	// The version comes first so that the ETag can be sent back in If-Match.
+	tag := fmt.Sprintf("%d-%d-%s", movie.Version, count, strconv.FormatFloat(average, 'f', 1, 64))
+	if conversion != nil {
+		// Converted amounts follow the day's exchange rates.
+		tag += "-" + conversion.to + "-" + conversion.on.UTC().Format("20060102")
+	}
+	etag := `"` + tag + `"`
+	setCacheValidators(c, etag, movie.UpdatedAt)
+	if notModified(c, etag, movie.UpdatedAt) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	response := toMovieResponse(movie)
+	conversion.apply(&response, movie)
+	c.JSON(http.StatusOK, movieDetailResponse{
+		movieResponse: response,
+		Rating:        ratingAggregateResponse{Average: average, Count: count},
+	})
+}
+
+func (h *MovieHandler) ListMovies(c *gin.Context) {
+	filter, ok := parseMovieFilterQuery(c)
+	if !ok {
+		return
+	}
+	conversion, ok := parseCurrencyQuery(c, h.rates)
+	if !ok {
+		return
 	}
 
 	limit := 0
@@ -152,12 +394,13 @@ func (h *MovieHandler) ListMovies(c *gin.Context) {
 	}
 
 	params := service.ListMoviesParams{
-		Q:           strings.TrimSpace(c.Query("q")),
-		Year:        yearParam,
-		Genre:       genreParam,
-		Distributor: distributorParam,
-		BudgetLTE:   budgetParam,
-		MpaRating:   mpaParam,
+		Q:           filter.q,
+		Year:        filter.year,
+		Genre:       filter.genre,
+		Distributor: filter.distributor,
+		BudgetLTE:   filter.budget,
+		RevenueGTE:  filter.minRevenue,
+		MpaRating:   filter.mpaRating,
 		Limit:       limit,
 		Cursor:      strings.TrimSpace(c.Query("cursor")),
 	}
@@ -169,7 +412,9 @@ func (h *MovieHandler) ListMovies(c *gin.Context) {
 			Items: make([]movieResponse, 0, len(movies)),
 		}
 		for _, movie := range movies {
-			response.Items = append(response.Items, toMovieResponse(movie))
+			item := toMovieResponse(movie)
+			conversion.apply(&item, movie)
+			response.Items = append(response.Items, item)
 		}
 		if nextCursor != nil {
 			response.NextCursor = nextCursor
@@ -182,30 +427,171 @@ func (h *MovieHandler) ListMovies(c *gin.Context) {
 	}
 }
 
+// movieFilterQuery holds the search filters accepted by movie listings.
+type movieFilterQuery struct {
+	q           string
+	year        *int
+	budget      *int64
+	minRevenue  *int64
+	genre       *string
+	distributor *string
+	mpaRating   *string
+}
+
+func parseMovieFilterQuery(c *gin.Context) (movieFilterQuery, bool) {
+	filter := movieFilterQuery{q: strings.TrimSpace(c.Query("q"))}
+
+	if value := strings.TrimSpace(c.Query("year")); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "BAD_REQUEST", "year must be an integer", nil)
+			return filter, false
+		}
+		filter.year = &parsed
+	}
+
+	if value := strings.TrimSpace(c.Query("budget")); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			writeError(c, http.StatusBadRequest, "BAD_REQUEST", "budget must be a non-negative integer", nil)
+			return filter, false
+		}
+		filter.budget = &parsed
+	}
+
+	if value := strings.TrimSpace(c.Query("minRevenue")); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			writeError(c, http.StatusBadRequest, "BAD_REQUEST", "minRevenue must be a non-negative integer", nil)
+			return filter, false
+		}
+		filter.minRevenue = &parsed
+	}
+
+	if value := strings.TrimSpace(c.Query("genre")); value != "" {
+		filter.genre = &value
+	}
+
+	if value := strings.TrimSpace(c.Query("distributor")); value != "" {
+		filter.distributor = &value
+	}
+
+	if value := strings.TrimSpace(c.Query("mpaRating")); value != "" {
+		filter.mpaRating = &value
+	}
+
+	return filter, true
+}
+
+func bindMovieParams(c *gin.Context, operation string) (service.CreateMovieParams, bool) {
+	var req createMovieRequest
+
+	if err := bindJSONBody(c.Request.Body, &req); err != nil {
+		if errors.Is(err, errJSONBodyTooLarge) {
+			writeError(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "Request body exceeds the maximum allowed size", nil)
+			return service.CreateMovieParams{}, false
+		}
+		log.Printf("%s bind error: %v", operation, err)
+		writeError(c, http.StatusUnprocessableEntity, "UNPROCESSABLE_ENTITY", "Malformed JSON payload", nil)
+		return service.CreateMovieParams{}, false
+	}
+
+	if strings.TrimSpace(req.Title) == "" || strings.TrimSpace(req.Genre) == "" || strings.TrimSpace(req.ReleaseDate) == "" {
+		writeError(c, http.StatusUnprocessableEntity, "UNPROCESSABLE_ENTITY", "title, genre and releaseDate are required", nil)
+		return service.CreateMovieParams{}, false
+	}
+
+	return service.CreateMovieParams{
+		Title:       req.Title,
+		Genre:       req.Genre,
+		ReleaseDate: req.ReleaseDate,
+		Distributor: req.Distributor,
+		Budget:      req.Budget,
+		MpaRating:   req.MpaRating,
+	}, true
+}
+
+// toMoviePatch maps a merge patch document onto service.MoviePatch. Unknown
+// members are ignored, matching how creation treats extra fields.
+func toMoviePatch(fields map[string]json.RawMessage) (service.MoviePatch, error) {
+	var patch service.MoviePatch
+	if fields == nil {
+		return patch, errors.New("patch document must be a JSON object")
+	}
+
+	for name, raw := range fields {
+		isNull := string(bytes.TrimSpace(raw)) == "null"
+
+		var err error
+		switch name {
+		case "title", "genre", "releaseDate":
+			if isNull {
+				return patch, fmt.Errorf("%s cannot be null", name)
+			}
+			var value string
+			if err = json.Unmarshal(raw, &value); err == nil {
+				switch name {
+				case "title":
+					patch.Title = &value
+				case "genre":
+					patch.Genre = &value
+				default:
+					patch.ReleaseDate = &value
+				}
+			}
+		case "distributor":
+			patch.ClearDistributor = isNull
+			err = json.Unmarshal(raw, &patch.Distributor)
+		case "budget":
+			patch.ClearBudget = isNull
+			err = json.Unmarshal(raw, &patch.Budget)
+		case "mpaRating":
+			patch.ClearMpaRating = isNull
+			err = json.Unmarshal(raw, &patch.MpaRating)
+		}
+		if err != nil {
+			return patch, fmt.Errorf("%s has an invalid type", name)
+		}
+	}
+
+	return patch, nil
+}
+
 func toMovieResponse(movie *model.Movie) movieResponse {
 	response := movieResponse{
-		ID:          movie.ID,
-		Title:       movie.Title,
-		Genre:       movie.Genre,
-		ReleaseDate: movie.ReleaseDate.Format("2006-01-02"),
-		Distributor: movie.Distributor,
-		Budget:      movie.Budget,
-		MpaRating:   movie.MpaRating,
+		ID:               movie.ID,
+		Title:            movie.Title,
+		Genre:            movie.Genre,
+		ReleaseDate:      movie.ReleaseDate.Format("2006-01-02"),
+		Distributor:      movie.Distributor,
+		Budget:           movie.Budget,
+		MpaRating:        movie.MpaRating,
+		EnrichmentStatus: movie.EnrichmentStatus,
+	}
+	if len(movie.Sources) > 0 {
+		response.Sources = movie.Sources
 	}
 
 	if movie.BoxOffice != nil {
-		response.BoxOffice = &boxOfficeResponse{
-			Revenue: boxOfficeRevenueResponse{
-				Worldwide:         movie.BoxOffice.Revenue.Worldwide,
-				OpeningWeekendUSA: movie.BoxOffice.Revenue.OpeningWeekendUS,
-			},
-			Currency: movie.BoxOffice.Currency,
-			Source:   movie.BoxOffice.Source,
-		}
-		if !movie.BoxOffice.LastUpdated.IsZero() {
-			response.BoxOffice.LastUpdated = movie.BoxOffice.LastUpdated.UTC().Format(time.RFC3339)
-		}
+		boxOffice := toBoxOfficeResponse(*movie.BoxOffice)
+		response.BoxOffice = &boxOffice
 	}
 
 	return response
 }
+
+func toBoxOfficeResponse(boxOffice model.BoxOffice) boxOfficeResponse {
+	response := boxOfficeResponse{
+		Revenue: boxOfficeRevenueResponse{
+			Worldwide:         boxOffice.Revenue.Worldwide,
+			OpeningWeekendUSA: boxOffice.Revenue.OpeningWeekendUS,
+		},
+		Currency:        boxOffice.Currency,
+		Source:          boxOffice.Source,
+		MatchConfidence: boxOffice.MatchConfidence,
+	}
+	if !boxOffice.LastUpdated.IsZero() {
+		response.LastUpdated = boxOffice.LastUpdated.UTC().Format(time.RFC3339)
+	}
+	return response
+}
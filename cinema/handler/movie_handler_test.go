@@ -3,64 +3,49 @@ package handler
 import (
 	"bytes"
 	"cinema/boxoffice"
+	"cinema/currency"
 	"cinema/model"
 	"cinema/repository"
 	"cinema/service"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-type testMovieRepository struct {
-	movies map[string]*model.Movie
+// newTestRepositories returns empty in-memory repositories over one store,
+// with a unit of work spanning them.
+func newTestRepositories() (*repository.MemoryMovieRepository, *repository.MemoryRatingRepository, *repository.MemoryUnitOfWork) {
+	store := repository.NewMemoryStore()
+	return repository.NewMemoryMovieRepository(store), repository.NewMemoryRatingRepository(store), repository.NewMemoryUnitOfWork(store)
 }
 
-func newTestMovieRepository() *testMovieRepository {
-	return &testMovieRepository{movies: make(map[string]*model.Movie)}
-}
-
-func (r *testMovieRepository) Create(ctx context.Context, movie *model.Movie) error {
-	key := strings.ToLower(movie.Title)
-	if _, exists := r.movies[key]; exists {
-		return repository.ErrMovieAlreadyExists
-	}
-	clone := *movie
-	r.movies[key] = &clone
-	return nil
-}
-
-func (r *testMovieRepository) UpdateSupplemental(ctx context.Context, movieID string, distributor *string, budget *int64, mpaRating *string, boxOffice *model.BoxOffice) error {
-	for _, movie := range r.movies {
-		if movie.ID == movieID {
-			movie.Distributor = distributor
-			movie.Budget = budget
-			movie.MpaRating = mpaRating
-			movie.BoxOffice = boxOffice
-			return nil
-		}
+func seedMovie(t *testing.T, repo repository.MovieRepository, movie *model.Movie) {
+	t.Helper()
+	if err := repo.Create(context.Background(), movie); err != nil {
+		t.Fatalf("seed %q: %v", movie.Title, err)
 	}
-	return repository.ErrMovieNotFound
 }
 
-func (r *testMovieRepository) GetByTitle(ctx context.Context, title string) (*model.Movie, error) {
-	if movie, ok := r.movies[strings.ToLower(title)]; ok {
-		clone := *movie
-		return &clone, nil
+func seedRating(t *testing.T, repo repository.RatingRepository, movieID, raterID string, value float64) {
+	t.Helper()
+	if _, err := repo.Upsert(context.Background(), &model.Rating{MovieID: movieID, RaterID: raterID, Value: value}); err != nil {
+		t.Fatalf("seed rating by %s: %v", raterID, err)
 	}
-	return nil, repository.ErrMovieNotFound
 }
 
-func (r *testMovieRepository) List(ctx context.Context, params repository.MovieListParams) ([]*model.Movie, error) {
-	result := make([]*model.Movie, 0, len(r.movies))
-	for _, movie := range r.movies {
-		clone := *movie
-		result = append(result, &clone)
+func storedMovie(t *testing.T, repo repository.MovieRepository, title string) *model.Movie {
+	t.Helper()
+	movie, err := repo.GetByTitle(context.Background(), title)
+	if err != nil {
+		t.Fatalf("GetByTitle(%q): %v", title, err)
 	}
-	return result, nil
+	return movie
 }
 
 type testBoxOfficeClient struct{}
@@ -72,9 +57,9 @@ func (testBoxOfficeClient) Fetch(ctx context.Context, title string) (*boxoffice.
 func TestCreateMovieHandlerReturnsCreated(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	repo := newTestMovieRepository()
-	svc := service.NewMovieService(repo, testBoxOfficeClient{})
-	handler := NewMovieHandler(svc)
+	repo, ratingRepo, tx := newTestRepositories()
+	svc := service.NewMovieService(repo, tx, testBoxOfficeClient{}, service.EnrichSync)
+	handler := NewMovieHandler(svc, service.NewRatingService(repo, ratingRepo, tx, service.RankingConfig{}), nil)
 
 	payload := `{
         "title": "Test Movie 1",
@@ -101,9 +86,9 @@ func TestCreateMovieHandlerReturnsCreated(t *testing.T) {
 func TestCreateMovieHandlerAcceptsBOM(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	repo := newTestMovieRepository()
-	svc := service.NewMovieService(repo, testBoxOfficeClient{})
-	handler := NewMovieHandler(svc)
+	repo, ratingRepo, tx := newTestRepositories()
+	svc := service.NewMovieService(repo, tx, testBoxOfficeClient{}, service.EnrichSync)
+	handler := NewMovieHandler(svc, service.NewRatingService(repo, ratingRepo, tx, service.RankingConfig{}), nil)
 
 	basePayload := `{
         "title": "Another Test Movie",
@@ -129,3 +114,285 @@ func TestCreateMovieHandlerAcceptsBOM(t *testing.T) {
 		t.Fatalf("expected status %d, got %d with body %s", http.StatusCreated, w.Code, w.Body.String())
 	}
 }
+
+func TestGetMovieHandlerReturnsDetailAndHonoursETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo, ratingRepo, tx := newTestRepositories()
+	seedMovie(t, repo, &model.Movie{
+		ID:          "m_1",
+		Title:       "Inception",
+		Genre:       "Sci-Fi",
+		ReleaseDate: time.Date(2010, 7, 16, 0, 0, 0, 0, time.UTC),
+	})
+	seedRating(t, ratingRepo, "m_1", "a", 4.5)
+	seedRating(t, ratingRepo, "m_1", "b", 4.0)
+
+	ratingSvc := service.NewRatingService(repo, ratingRepo, tx, service.RankingConfig{})
+	handler := NewMovieHandler(service.NewMovieService(repo, tx, testBoxOfficeClient{}, service.EnrichSync), ratingSvc, nil)
+	router := gin.New()
+	router.GET("/movies/:title", handler.GetMovie)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/movies/inception", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d with body %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Title  string `json:"title"`
+		Rating struct {
+			Average float64 `json:"average"`
+			Count   int     `json:"count"`
+		} `json:"rating"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Title != "Inception" || body.Rating.Count != 2 || body.Rating.Average != 4.3 {
+		t.Fatalf("unexpected detail response: %+v", body)
+	}
+
+	etag := w.Header().Get("ETag")
+	if etag == "" || w.Header().Get("Last-Modified") == "" {
+		t.Fatalf("expected cache validators, got headers %v", w.Header())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/movies/Inception", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("expected status %d, got %d", http.StatusNotModified, w.Code)
+	}
+
+	seedRating(t, ratingRepo, "m_1", "c", 1.0)
+	req = httptest.NewRequest(http.MethodGet, "/movies/Inception", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected a fresh representation after a new rating, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/movies/Missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestPatchMovieHandlerRejectsNullRequiredField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo, ratingRepo, tx := newTestRepositories()
+	seedMovie(t, repo, &model.Movie{ID: "m_1", Title: "Inception", Genre: "Sci-Fi", ReleaseDate: time.Date(2010, 7, 16, 0, 0, 0, 0, time.UTC)})
+	handler := NewMovieHandler(service.NewMovieService(repo, tx, testBoxOfficeClient{}, service.EnrichSync), service.NewRatingService(repo, ratingRepo, tx, service.RankingConfig{}), nil)
+	router := gin.New()
+	router.PATCH("/movies/:title", handler.PatchMovie)
+
+	req := httptest.NewRequest(http.MethodPatch, "/movies/Inception", strings.NewReader(`{"genre":null}`))
+	req.Header.Set("If-Match", `"1"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status %d, got %d with body %s", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/movies/Inception", strings.NewReader(`{"mpaRating":"PG-13","budget":null}`))
+	req.Header.Set("If-Match", `"1"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d with body %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got := storedMovie(t, repo, "Inception").MpaRating; got == nil || *got != "PG-13" {
+		t.Fatalf("expected mpaRating to be patched, got %v", got)
+	}
+}
+
+func TestMovieWritesRequireMatchingIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo, ratingRepo, tx := newTestRepositories()
+	seedMovie(t, repo, &model.Movie{ID: "m_1", Title: "Inception", Genre: "Sci-Fi", ReleaseDate: time.Date(2010, 7, 16, 0, 0, 0, 0, time.UTC)})
+	handler := NewMovieHandler(service.NewMovieService(repo, tx, testBoxOfficeClient{}, service.EnrichSync), service.NewRatingService(repo, ratingRepo, tx, service.RankingConfig{}), nil)
+	router := gin.New()
+	router.GET("/movies/:title", handler.GetMovie)
+	router.PUT("/movies/:title", handler.ReplaceMovie)
+	router.PATCH("/movies/:title", handler.PatchMovie)
+	router.DELETE("/movies/:title", handler.DeleteMovie)
+
+	send := func(method, body, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/movies/Inception", strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	replacement := `{"title":"Inception","genre":"Thriller","releaseDate":"2010-07-16"}`
+	if w := send(http.MethodPut, replacement, ""); w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("expected status %d without If-Match, got %d", http.StatusPreconditionRequired, w.Code)
+	}
+	if w := send(http.MethodDelete, "", ""); w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("expected status %d without If-Match, got %d", http.StatusPreconditionRequired, w.Code)
+	}
+
+	// The detail ETag carries the rating aggregate too; only its version is
+	// compared.
+	fetched := send(http.MethodGet, "", "").Header().Get("ETag")
+	w := send(http.MethodPut, replacement, fetched)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d with body %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if etag := w.Header().Get("ETag"); etag != `"2"` {
+		t.Fatalf("expected ETag %q after the first write, got %q", `"2"`, etag)
+	}
+
+	for _, stale := range []string{fetched, `W/"2"`} {
+		w = send(http.MethodPatch, `{"genre":"Drama"}`, stale)
+		if w.Code != http.StatusPreconditionFailed || !strings.Contains(w.Body.String(), "PRECONDITION_FAILED") {
+			t.Fatalf("expected status %d for If-Match %s, got %d with body %s", http.StatusPreconditionFailed, stale, w.Code, w.Body.String())
+		}
+	}
+	if got := storedMovie(t, repo, "Inception").Genre; got != "Thriller" {
+		t.Fatalf("expected failed preconditions to leave the movie alone, got genre %q", got)
+	}
+
+	if w := send(http.MethodDelete, "", `"1", "2"`); w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d with body %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+}
+
+type fixedBoxOfficeClient struct {
+	record *boxoffice.Record
+}
+
+func (c fixedBoxOfficeClient) Fetch(ctx context.Context, title string) (*boxoffice.Record, error) {
+	return c.record, nil
+}
+
+func TestRefreshBoxOfficeHandlerReturnsDiff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo, ratingRepo, tx := newTestRepositories()
+	ownDistributor := "Legendary"
+	seedMovie(t, repo, &model.Movie{ID: "m_1", Title: "Inception", Genre: "Sci-Fi", ReleaseDate: time.Date(2010, 7, 16, 0, 0, 0, 0, time.UTC), Distributor: &ownDistributor, EnrichmentStatus: model.EnrichmentNotFound})
+
+	distributor := "Warner Bros."
+	budget := int64(160000000)
+	client := fixedBoxOfficeClient{record: &boxoffice.Record{Distributor: &distributor, Budget: &budget, Revenue: boxoffice.Revenue{Worldwide: 836800000}, Currency: "USD"}}
+	handler := NewMovieHandler(service.NewMovieService(repo, tx, client, service.EnrichSync), service.NewRatingService(repo, ratingRepo, tx, service.RankingConfig{}), nil)
+	router := gin.New()
+	router.POST("/movies/:title/ratings", func(c *gin.Context) { c.Status(http.StatusTeapot) })
+	router.POST("/movies/:title/:action", handler.MovieAction)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/movies/Inception/boxoffice:refresh", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d with body %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Changes []struct {
+			Field string      `json:"field"`
+			New   interface{} `json:"new"`
+		} `json:"changes"`
+		Movie struct {
+			Distributor string `json:"distributor"`
+		} `json:"movie"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Movie.Distributor != "Legendary" {
+		t.Fatalf("expected fill-missing to keep the distributor, got %q", body.Movie.Distributor)
+	}
+	fields := make([]string, 0, len(body.Changes))
+	for _, change := range body.Changes {
+		fields = append(fields, change.Field)
+	}
+	if got := strings.Join(fields, ","); got != "budget,boxOffice.revenue.worldwide,boxOffice.currency,enrichmentStatus" {
+		t.Fatalf("unexpected changed fields %s", got)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/movies/Inception/boxoffice:refresh?mode=force", nil))
+	if w.Code != http.StatusOK || *storedMovie(t, repo, "Inception").Distributor != "Warner Bros." {
+		t.Fatalf("expected force to overwrite the distributor, got %d with body %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/movies/Inception/ratings", nil))
+	if w.Code != http.StatusTeapot {
+		t.Fatalf("expected the ratings route to take precedence, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/movies/Inception/boxoffice:refresh?mode=merge", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d for an unknown mode, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestGetMovieHandlerConvertsCurrency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rates, err := currency.LoadRatesCSV(strings.NewReader("date,currency,units_per_usd\n2000-01-01,EUR,0.5\n"))
+	if err != nil {
+		t.Fatalf("LoadRatesCSV returned error: %v", err)
+	}
+	budget, budgetUSD, opening := int64(160000000), int64(160000000), int64(62000000)
+	repo, ratingRepo, tx := newTestRepositories()
+	seedMovie(t, repo, &model.Movie{
+		ID:          "m_1",
+		Title:       "Inception",
+		Genre:       "Sci-Fi",
+		ReleaseDate: time.Date(2010, 7, 16, 0, 0, 0, 0, time.UTC),
+		Budget:      &budget,
+		BudgetUSD:   &budgetUSD,
+		BoxOffice: &model.BoxOffice{
+			Revenue:  model.BoxOfficeRevenue{Worldwide: 800000000, OpeningWeekendUS: &opening},
+			Currency: "USD",
+		},
+	})
+	handler := NewMovieHandler(service.NewMovieService(repo, tx, testBoxOfficeClient{}, service.EnrichSync), service.NewRatingService(repo, ratingRepo, tx, service.RankingConfig{}), rates)
+	router := gin.New()
+	router.GET("/movies/:title", handler.GetMovie)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/movies/Inception?currency=eur", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d with body %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Budget    int64  `json:"budget"`
+		Currency  string `json:"currency"`
+		BoxOffice struct {
+			Revenue struct {
+				Worldwide         int64 `json:"worldwide"`
+				OpeningWeekendUSA int64 `json:"openingWeekendUSA"`
+			} `json:"revenue"`
+			Currency string `json:"currency"`
+		} `json:"boxOffice"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Currency != "EUR" || body.Budget != 80000000 || body.BoxOffice.Currency != "EUR" ||
+		body.BoxOffice.Revenue.Worldwide != 400000000 || body.BoxOffice.Revenue.OpeningWeekendUSA != 31000000 {
+		t.Fatalf("unexpected converted response: %s", w.Body.String())
+	}
+
+	for _, code := range []string{"EURO", "GBP"} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/movies/Inception?currency="+code, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d for %s, got %d", http.StatusBadRequest, code, w.Code)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"cinema/handler/middleware"
+	"cinema/model"
 	"cinema/repository"
 	"cinema/service"
 	"errors"
@@ -8,13 +10,12 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-const raterIDHeader = "X-Rater-Id"
-
 type RatingHandler struct {
 	service *service.RatingService
 }
@@ -29,11 +30,38 @@ type ratingResponse struct {
 	Rating     float64 `json:"rating"`
 }
 
+type ratingPageResponse struct {
+	Items      []ratingResponse `json:"items"`
+	NextCursor *string          `json:"nextCursor,omitempty"`
+}
+
 type ratingAggregateResponse struct {
 	Average float64 `json:"average"`
 	Count   int     `json:"count"`
 }
 
+type ratingDistributionResponse struct {
+	ratingAggregateResponse
+	Histogram []ratingBucketResponse `json:"histogram"`
+	Median    float64                `json:"median"`
+	StdDev    float64                `json:"stdDev"`
+}
+
+type ratingBucketResponse struct {
+	Rating float64 `json:"rating"`
+	Count  int     `json:"count"`
+}
+
+type rankedMovieResponse struct {
+	movieResponse
+	Rating ratingAggregateResponse `json:"rating"`
+	Score  float64                 `json:"score"`
+}
+
+type topRatedResponse struct {
+	Items []rankedMovieResponse `json:"items"`
+}
+
 func NewRatingHandler(service *service.RatingService) *RatingHandler {
 	return &RatingHandler{service: service}
 }
@@ -45,7 +73,7 @@ func (h *RatingHandler) UpsertRating(c *gin.Context) {
 		return
 	}
 
-	raterID := strings.TrimSpace(c.GetHeader(raterIDHeader))
+	raterID := middleware.RaterID(c)
 	if raterID == "" {
 		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information", nil)
 		return
@@ -85,6 +113,128 @@ func (h *RatingHandler) UpsertRating(c *gin.Context) {
 	}
 }
 
+func (h *RatingHandler) GetRating(c *gin.Context) {
+	title := c.Param("title")
+	raterID := c.Param("raterId")
+	if strings.TrimSpace(title) == "" || strings.TrimSpace(raterID) == "" {
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "movie title and rater id are required", nil)
+		return
+	}
+
+	rating, err := h.service.GetRating(c.Request.Context(), title, raterID)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, ratingResponse{
+			MovieTitle: rating.MovieTitle,
+			RaterID:    rating.RaterID,
+			Rating:     rating.Value,
+		})
+	case errors.Is(err, repository.ErrMovieNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Movie not found", nil)
+	case errors.Is(err, repository.ErrRatingNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Rating not found", nil)
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch rating", nil)
+	}
+}
+
+func (h *RatingHandler) DeleteRating(c *gin.Context) {
+	title := c.Param("title")
+	raterID := c.Param("raterId")
+	if strings.TrimSpace(title) == "" || strings.TrimSpace(raterID) == "" {
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "movie title and rater id are required", nil)
+		return
+	}
+
+	requesterID := middleware.RaterID(c)
+	if requesterID == "" {
+		writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information", nil)
+		return
+	}
+
+	err := h.service.DeleteRating(c.Request.Context(), title, raterID, requesterID)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, service.ErrForbidden):
+		writeError(c, http.StatusForbidden, "FORBIDDEN", "No permission to perform this operation", nil)
+	case errors.Is(err, repository.ErrMovieNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Movie not found", nil)
+	case errors.Is(err, repository.ErrRatingNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Rating not found", nil)
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete rating", nil)
+	}
+}
+
+// RemoveRating lets an admin delete any rater's rating, e.g. for
+// moderation.
+func (h *RatingHandler) RemoveRating(c *gin.Context) {
+	title := c.Param("title")
+	raterID := c.Param("raterId")
+	if strings.TrimSpace(title) == "" || strings.TrimSpace(raterID) == "" {
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "movie title and rater id are required", nil)
+		return
+	}
+
+	err := h.service.RemoveRating(c.Request.Context(), title, raterID)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, repository.ErrMovieNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Movie not found", nil)
+	case errors.Is(err, repository.ErrRatingNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Rating not found", nil)
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete rating", nil)
+	}
+}
+
+func (h *RatingHandler) ListRatings(c *gin.Context) {
+	title := c.Param("title")
+	if strings.TrimSpace(title) == "" {
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "movie title is required", nil)
+		return
+	}
+
+	limit := 0
+	if value := strings.TrimSpace(c.Query("limit")); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "BAD_REQUEST", "limit must be an integer", nil)
+			return
+		}
+		limit = parsed
+	}
+
+	ratings, nextCursor, err := h.service.ListRatings(c.Request.Context(), service.ListRatingsParams{
+		MovieTitle: title,
+		Limit:      limit,
+		Cursor:     strings.TrimSpace(c.Query("cursor")),
+	})
+	switch {
+	case err == nil:
+		response := ratingPageResponse{
+			Items:      make([]ratingResponse, 0, len(ratings)),
+			NextCursor: nextCursor,
+		}
+		for _, rating := range ratings {
+			response.Items = append(response.Items, ratingResponse{
+				MovieTitle: rating.MovieTitle,
+				RaterID:    rating.RaterID,
+				Rating:     rating.Value,
+			})
+		}
+		c.JSON(http.StatusOK, response)
+	case errors.Is(err, service.ErrInvalidInput):
+		writeError(c, http.StatusBadRequest, "BAD_REQUEST", "cursor is invalid", nil)
+	case errors.Is(err, repository.ErrMovieNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Movie not found", nil)
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list ratings", nil)
+	}
+}
+
 func (h *RatingHandler) GetAggregatedRating(c *gin.Context) {
 	title := c.Param("title")
 	if strings.TrimSpace(title) == "" {
@@ -92,6 +242,22 @@ func (h *RatingHandler) GetAggregatedRating(c *gin.Context) {
 		return
 	}
 
+	includeHistogram := false
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		switch strings.TrimSpace(include) {
+		case "":
+		case "histogram":
+			includeHistogram = true
+		default:
+			writeError(c, http.StatusBadRequest, "BAD_REQUEST", "include supports only histogram", nil)
+			return
+		}
+	}
+	if includeHistogram {
+		h.getRatingDistribution(c, title)
+		return
+	}
+
 	average, count, err := h.service.GetAggregatedRating(c.Request.Context(), title)
 	switch {
 	case err == nil:
@@ -102,3 +268,71 @@ func (h *RatingHandler) GetAggregatedRating(c *gin.Context) {
 		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch rating aggregation", nil)
 	}
 }
+
+func (h *RatingHandler) getRatingDistribution(c *gin.Context, title string) {
+	dist, err := h.service.GetRatingDistribution(c.Request.Context(), title)
+	switch {
+	case err == nil:
+		response := ratingDistributionResponse{
+			ratingAggregateResponse: ratingAggregateResponse{Average: dist.Average, Count: dist.Count},
+			Histogram:               make([]ratingBucketResponse, 0, len(dist.Histogram)),
+			Median:                  dist.Median,
+			StdDev:                  dist.StdDev,
+		}
+		for bucket, count := range dist.Histogram {
+			response.Histogram = append(response.Histogram, ratingBucketResponse{
+				Rating: model.RatingBucketValue(bucket),
+				Count:  count,
+			})
+		}
+		c.JSON(http.StatusOK, response)
+	case errors.Is(err, repository.ErrMovieNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Movie not found", nil)
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch rating aggregation", nil)
+	}
+}
+
+// TopRated serves the Bayesian leaderboard. It accepts the same filters as
+// the movie listing plus limit.
+func (h *RatingHandler) TopRated(c *gin.Context) {
+	filter, ok := parseMovieFilterQuery(c)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if value := strings.TrimSpace(c.Query("limit")); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "BAD_REQUEST", "limit must be an integer", nil)
+			return
+		}
+		limit = parsed
+	}
+
+	ranked, err := h.service.TopRated(c.Request.Context(), service.TopRatedParams{
+		Q:           filter.q,
+		Year:        filter.year,
+		Genre:       filter.genre,
+		Distributor: filter.distributor,
+		BudgetLTE:   filter.budget,
+		RevenueGTE:  filter.minRevenue,
+		MpaRating:   filter.mpaRating,
+		Limit:       limit,
+	})
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to rank movies", nil)
+		return
+	}
+
+	response := topRatedResponse{Items: make([]rankedMovieResponse, 0, len(ranked))}
+	for _, entry := range ranked {
+		response.Items = append(response.Items, rankedMovieResponse{
+			movieResponse: toMovieResponse(entry.Movie),
+			Rating:        ratingAggregateResponse{Average: entry.Average, Count: entry.Count},
+			Score:         entry.Score,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"cinema/model"
+	"cinema/repository"
+	"cinema/service"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TokenHandler struct {
+	service *service.TokenService
+}
+
+type mintTokenRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn *string  `json:"expiresIn"`
+}
+
+type tokenResponse struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type mintedTokenResponse struct {
+	tokenResponse
+	Token string `json:"token"`
+}
+
+type tokenListResponse struct {
+	Items []tokenResponse `json:"items"`
+}
+
+func NewTokenHandler(service *service.TokenService) *TokenHandler {
+	return &TokenHandler{service: service}
+}
+
+func (h *TokenHandler) MintToken(c *gin.Context) {
+	var req mintTokenRequest
+	if err := bindJSONBody(c.Request.Body, &req); err != nil {
+		if errors.Is(err, errJSONBodyTooLarge) {
+			writeError(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "Request body exceeds the maximum allowed size", nil)
+			return
+		}
+		writeError(c, http.StatusUnprocessableEntity, "UNPROCESSABLE_ENTITY", "Malformed JSON payload", nil)
+		return
+	}
+
+	params := service.MintTokenParams{Name: req.Name, Scopes: req.Scopes}
+	if req.ExpiresIn != nil {
+		ttl, err := time.ParseDuration(strings.TrimSpace(*req.ExpiresIn))
+		if err != nil || ttl <= 0 {
+			writeError(c, http.StatusUnprocessableEntity, "UNPROCESSABLE_ENTITY", "expiresIn must be a positive duration such as 720h", nil)
+			return
+		}
+		params.TTL = ttl
+	}
+
+	plaintext, token, err := h.service.Mint(c.Request.Context(), params)
+	switch {
+	case err == nil:
+		c.Header("Location", "/admin/tokens/"+token.ID)
+		c.JSON(http.StatusCreated, mintedTokenResponse{tokenResponse: toTokenResponse(token), Token: plaintext})
+	case errors.Is(err, service.ErrInvalidInput):
+		writeError(c, http.StatusUnprocessableEntity, "UNPROCESSABLE_ENTITY", "name and at least one known scope are required", gin.H{"scopes": model.KnownScopes})
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to mint token", nil)
+	}
+}
+
+func (h *TokenHandler) ListTokens(c *gin.Context) {
+	tokens, err := h.service.List(c.Request.Context())
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list tokens", nil)
+		return
+	}
+
+	items := make([]tokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		items = append(items, toTokenResponse(token))
+	}
+	c.JSON(http.StatusOK, tokenListResponse{Items: items})
+}
+
+func (h *TokenHandler) RevokeToken(c *gin.Context) {
+	err := h.service.Revoke(c.Request.Context(), c.Param("id"))
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, repository.ErrTokenNotFound):
+		writeError(c, http.StatusNotFound, "NOT_FOUND", "Token not found", nil)
+	default:
+		writeError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to revoke token", nil)
+	}
+}
+
+func toTokenResponse(token *model.APIToken) tokenResponse {
+	return tokenResponse{
+		ID:        token.ID,
+		Name:      token.Name,
+		Scopes:    token.Scopes,
+		ExpiresAt: token.ExpiresAt,
+		RevokedAt: token.RevokedAt,
+		CreatedAt: token.CreatedAt,
+	}
+}
@@ -2,15 +2,23 @@ package main
 
 import (
 	"cinema/boxoffice"
+	"cinema/currency"
 	"cinema/db"
 	"cinema/handler"
 	"cinema/handler/middleware"
+	"cinema/model"
 	"cinema/repository"
 	"cinema/service"
+	"context"
+	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,52 +28,142 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
+// serverWriteTimeout bounds how long a handler may take to respond. Provider
+// lookups made while a request waits must finish well inside it.
+const serverWriteTimeout = 10 * time.Second
+
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Println(".env file not found, falling back to environment variables")
 	}
 
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
+	runServer()
+}
+
+func runServer() {
 	port := getEnvOrDefault("PORT", "8080")
 	appEnv := strings.ToLower(getEnvOrDefault("APP_ENV", "production"))
 	authToken := os.Getenv("AUTH_TOKEN")
 	if authToken == "" {
-		log.Fatal("AUTH_TOKEN must be provided for write operations")
+		log.Println("AUTH_TOKEN is not set; only tokens minted via /admin/tokens are accepted")
 	}
 
-	dbURL := os.Getenv("DB_URL")
-	if dbURL == "" {
-		log.Fatal("DB_URL must be provided to connect to the database")
-	}
+	// STORAGE=database (the default) stores data in the database named by
+	// DB_URL, Postgres or, for a sqlite: URL, a SQLite file. STORAGE=memory
+	// runs without a database, e.g. for demos and local development;
+	// everything is lost on restart. Features that depend on Postgres (async
+	// enrichment, the box office refresher, the shared cache store and
+	// conflict tracking) need postgresDB, which is nil otherwise.
+	var (
+		postgresDB   *sql.DB
+		movieRepo    repository.MovieRepository
+		ratingRepo   repository.RatingRepository
+		tokenRepo    repository.APITokenRepository
+		snapshotRepo repository.BoxOfficeSnapshotRepository
+		unitOfWork   repository.UnitOfWork
+	)
+	switch storage := strings.ToLower(getEnvOrDefault("STORAGE", "database")); storage {
+	case "database", "postgres":
+		sqlDB, backend := openDatabaseFromEnv()
+		defer sqlDB.Close()
 
-	boxOfficeURL := os.Getenv("BOXOFFICE_URL")
-	if boxOfficeURL == "" {
-		log.Fatal("BOXOFFICE_URL must be provided for box office integration")
+		if backend == db.SQLite {
+			movieRepo = repository.NewSQLiteMovieRepository(sqlDB)
+			ratingRepo = repository.NewSQLiteRatingRepository(sqlDB)
+			tokenRepo = repository.NewSQLiteAPITokenRepository(sqlDB)
+			snapshotRepo = repository.NewSQLiteBoxOfficeSnapshotRepository(sqlDB)
+			unitOfWork = repository.NewSQLiteUnitOfWork(sqlDB)
+			break
+		}
+		postgresDB = sqlDB
+		movieRepo = repository.NewPostgresMovieRepository(sqlDB)
+		ratingRepo = repository.NewPostgresRatingRepository(sqlDB)
+		tokenRepo = repository.NewPostgresAPITokenRepository(sqlDB)
+		snapshotRepo = repository.NewPostgresBoxOfficeSnapshotRepository(sqlDB)
+		unitOfWork = repository.NewPostgresUnitOfWork(sqlDB)
+	case "memory":
+		log.Println("STORAGE=memory keeps all data in process memory; it is lost on restart")
+		store := repository.NewMemoryStore()
+		movieRepo = repository.NewMemoryMovieRepository(store)
+		ratingRepo = repository.NewMemoryRatingRepository(store)
+		tokenRepo = repository.NewMemoryAPITokenRepository(store)
+		snapshotRepo = repository.NewMemoryBoxOfficeSnapshotRepository(store)
+		unitOfWork = repository.NewMemoryUnitOfWork(store)
+	default:
+		log.Fatalf("STORAGE must be database or memory, got %q", storage)
 	}
 
-	boxOfficeAPIKey := os.Getenv("BOXOFFICE_API_KEY")
-	if boxOfficeAPIKey == "" {
-		log.Fatal("BOXOFFICE_API_KEY must be provided for box office integration")
+	rates := exchangeRatesFromEnv()
+	boxOfficeProviders := boxOfficeClientFromEnv(rates)
+	var boxOfficeClient boxoffice.Client = boxOfficeProviders
+	boxOfficeCache := boxOfficeCacheFromEnv(boxOfficeClient, postgresDB)
+	if boxOfficeCache != nil {
+		boxOfficeClient = boxOfficeCache
 	}
 
-	sqlDB, err := db.NewConnection(dbURL)
-	if err != nil {
-		log.Fatalf("failed to connect database: %v", err)
-	}
-	defer sqlDB.Close()
+	// SIGINT and SIGTERM stop the server and the background jobs; the worker
+	// finishes the jobs it has claimed before the process exits.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var background sync.WaitGroup
 
-	movieRepo := repository.NewPostgresMovieRepository(sqlDB)
-	ratingRepo := repository.NewPostgresRatingRepository(sqlDB)
+	enrichmentMode := service.EnrichmentMode(strings.ToLower(getEnvOrDefault("ENRICHMENT_MODE", string(service.EnrichSync))))
+	switch enrichmentMode {
+	case service.EnrichSync, service.EnrichAsync:
+	default:
+		log.Fatalf("ENRICHMENT_MODE must be sync or async, got %q", enrichmentMode)
+	}
+	if enrichmentMode == service.EnrichAsync && postgresDB == nil {
+		log.Fatal("ENRICHMENT_MODE=async requires a Postgres database")
+	}
+	// With Postgres the worker also runs in sync mode, to finish lookups
+	// that did not fit in a request.
+	if postgresDB != nil {
+		worker := service.NewEnrichmentWorker(
+			repository.NewPostgresEnrichmentJobRepository(postgresDB),
+			boxOfficeClient,
+			service.EnrichmentWorkerOptions{
+				Concurrency: positiveIntFromEnv("ENRICHMENT_WORKERS", 4),
+				MaxAttempts: positiveIntFromEnv("ENRICHMENT_MAX_ATTEMPTS", 8),
+			},
+		)
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			worker.Run(ctx)
+		}()
+	}
 
-	httpClient := &http.Client{
-		Timeout: 5 * time.Second,
+	// The refresher bypasses the cache so that it always sees fresh data.
+	if interval := os.Getenv("BOXOFFICE_REFRESH_INTERVAL"); interval != "" {
+		if postgresDB == nil {
+			log.Fatal("BOXOFFICE_REFRESH_INTERVAL requires a Postgres database")
+		}
+		opts := boxOfficeRefreshOptionsFromEnv()
+		opts.Interval = durationFromEnv("BOXOFFICE_REFRESH_INTERVAL", time.Hour)
+		refresher := service.NewBoxOfficeRefresher(repository.NewPostgresBoxOfficeRefreshRepository(postgresDB), boxOfficeProviders, opts)
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			refresher.Run(ctx)
+		}()
 	}
-	boxOfficeClient := boxoffice.NewHTTPClient(boxOfficeURL, boxOfficeAPIKey, httpClient)
 
-	movieService := service.NewMovieService(movieRepo, boxOfficeClient)
-	ratingService := service.NewRatingService(movieRepo, ratingRepo)
+	movieService := service.NewMovieService(movieRepo, unitOfWork, boxOfficeClient, enrichmentMode)
+	movieService.SetLookupBudget(lookupBudgetFromEnv(), postgresDB != nil)
+	ratingService := service.NewRatingService(movieRepo, ratingRepo, unitOfWork, rankingConfigFromEnv())
+	tokenService := service.NewTokenService(tokenRepo, authToken)
+	historyService := service.NewBoxOfficeHistoryService(movieRepo, snapshotRepo)
 
-	movieHandler := handler.NewMovieHandler(movieService)
+	movieHandler := handler.NewMovieHandler(movieService, ratingService, rates)
 	ratingHandler := handler.NewRatingHandler(ratingService)
+	tokenHandler := handler.NewTokenHandler(tokenService)
+	boxOfficeHandler := handler.NewBoxOfficeHandler(historyService, boxOfficeCache, boxOfficeProviders.Providers())
 
 	switch appEnv {
 	case "development", "dev":
@@ -110,25 +208,349 @@ func main() {
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/swagger.json")))
 
-	authMiddleware := middleware.RequireBearerToken(authToken)
+	// Every write route declares the scope it needs. AUTH_TOKEN, when set, is
+	// accepted as an admin token.
+	requireMoviesWrite := middleware.RequireScope(tokenService, model.ScopeMoviesWrite)
+	requireAdmin := middleware.RequireScope(tokenService, model.ScopeAdmin)
+	raterMiddleware := middleware.RequireRater(raterAuthenticatorFromEnv())
 
 	router.GET("/movies", movieHandler.ListMovies)
-	router.POST("/movies", authMiddleware, movieHandler.CreateMovie)
-	router.POST("/movies/:title/ratings", ratingHandler.UpsertRating)
+	// gin matches the static /movies/top before /movies/:title, so a movie
+	// titled "top" can only be read through GET /movies?q=top.
+	router.GET("/movies/top", ratingHandler.TopRated)
+	router.POST("/movies", requireMoviesWrite, movieHandler.CreateMovie)
+	router.GET("/movies/:title", movieHandler.GetMovie)
+	router.PUT("/movies/:title", requireMoviesWrite, movieHandler.ReplaceMovie)
+	router.PATCH("/movies/:title", requireMoviesWrite, movieHandler.PatchMovie)
+	router.DELETE("/movies/:title", requireMoviesWrite, movieHandler.DeleteMovie)
+	router.POST("/movies/:title/:action", requireMoviesWrite, movieHandler.MovieAction)
+	router.GET("/movies/:title/boxoffice/history", boxOfficeHandler.History)
+	router.POST("/admin/movies/:title/restore", requireAdmin, movieHandler.RestoreMovie)
+	router.DELETE("/admin/movies/:title/ratings/:raterId", requireAdmin, ratingHandler.RemoveRating)
+	router.GET("/admin/tokens", requireAdmin, tokenHandler.ListTokens)
+	router.POST("/admin/tokens", requireAdmin, tokenHandler.MintToken)
+	router.DELETE("/admin/tokens/:id", requireAdmin, tokenHandler.RevokeToken)
+	router.GET("/admin/boxoffice/status", requireAdmin, boxOfficeHandler.Status)
+	if postgresDB != nil {
+		conflictHandler := handler.NewDataConflictHandler(service.NewDataConflictService(movieRepo, repository.NewPostgresDataConflictRepository(postgresDB)))
+		router.GET("/admin/conflicts", requireAdmin, conflictHandler.ListConflicts)
+		router.POST("/admin/conflicts/:id/resolve", requireAdmin, conflictHandler.ResolveConflict)
+	}
+	router.POST("/movies/:title/ratings", raterMiddleware, ratingHandler.UpsertRating)
+	router.GET("/movies/:title/ratings", ratingHandler.ListRatings)
+	router.GET("/movies/:title/ratings/:raterId", ratingHandler.GetRating)
+	router.DELETE("/movies/:title/ratings/:raterId", raterMiddleware, ratingHandler.DeleteRating)
 	router.GET("/movies/:title/rating", ratingHandler.GetAggregatedRating)
 
 	server := &http.Server{
 		Addr:              ":" + port,
 		Handler:           router,
 		ReadHeaderTimeout: 5 * time.Second,
-		WriteTimeout:      10 * time.Second,
+		WriteTimeout:      serverWriteTimeout,
 		IdleTimeout:       60 * time.Second,
 	}
 
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
 	log.Printf("server listening on port %s (env=%s)", port, appEnv)
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+
+	select {
+	case err := <-serverErr:
 		log.Fatalf("server stopped unexpectedly: %v", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Println("shutting down: draining requests and background jobs")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverWriteTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("server shutdown: %v", err)
+	}
+	background.Wait()
+}
+
+// openDatabaseFromEnv connects to DB_URL and brings the schema up to date.
+// Auto-migration is on by default, since the server cannot run against an
+// outdated schema; DB_AUTO_MIGRATE=false leaves it to `./app migrate`. On
+// Postgres the advisory lock makes it safe for several instances to start at
+// once.
+func openDatabaseFromEnv() (*sql.DB, db.Backend) {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		log.Fatal("DB_URL must be provided to connect to the database")
+	}
+
+	backend := db.BackendOf(dbURL)
+	sqlDB, err := db.NewConnection(dbURL)
+	if err != nil {
+		log.Fatalf("failed to connect database: %v", err)
+	}
+
+	autoMigrate, err := strconv.ParseBool(getEnvOrDefault("DB_AUTO_MIGRATE", "true"))
+	if err != nil {
+		log.Fatalf("DB_AUTO_MIGRATE must be true or false, got %q", os.Getenv("DB_AUTO_MIGRATE"))
+	}
+	if autoMigrate {
+		migrator, err := db.NewMigrator(sqlDB, backend)
+		if err != nil {
+			log.Fatalf("failed to load migrations: %v", err)
+		}
+		applied, err := migrator.Up(context.Background(), 0)
+		if err != nil {
+			log.Fatalf("failed to migrate database: %v", err)
+		}
+		log.Printf("database migrated, %d migrations applied", len(applied))
+	}
+
+	return sqlDB, backend
+}
+
+// raterAuthenticatorFromEnv selects how rater identity is established:
+// "hmac" (the default, tokens signed with RATER_TOKEN_SECRET), "jwt"
+// (verified against RATER_JWKS_FILE) or "header", which trusts X-Rater-Id and
+// must be chosen explicitly.
+func raterAuthenticatorFromEnv() middleware.RaterAuthenticator {
+	mode := strings.ToLower(getEnvOrDefault("RATER_AUTH_MODE", "hmac"))
+	switch mode {
+	case "header":
+		log.Println("rater authentication uses the legacy X-Rater-Id header; rater identity is not verified")
+		return middleware.HeaderRaterAuthenticator{}
+	case "hmac":
+		secret := os.Getenv("RATER_TOKEN_SECRET")
+		if err := middleware.CheckRaterTokenSecret([]byte(secret)); err != nil {
+			log.Fatalf("RATER_TOKEN_SECRET is required when RATER_AUTH_MODE=hmac (set RATER_AUTH_MODE=header to trust X-Rater-Id instead): %v", err)
+		}
+		return middleware.NewHMACRaterAuthenticator([]byte(secret))
+	case "jwt":
+		jwksFile := os.Getenv("RATER_JWKS_FILE")
+		if jwksFile == "" {
+			log.Fatal("RATER_JWKS_FILE must be provided when RATER_AUTH_MODE=jwt")
+		}
+		auth, err := middleware.NewJWTRaterAuthenticatorFromFile(jwksFile, os.Getenv("RATER_JWT_ISSUER"), os.Getenv("RATER_JWT_AUDIENCE"))
+		if err != nil {
+			log.Fatalf("failed to load rater JWKS: %v", err)
+		}
+		return auth
+	default:
+		log.Fatalf("RATER_AUTH_MODE must be one of header, hmac or jwt, got %q", mode)
+		return nil
+	}
+}
+
+// exchangeRatesFromEnv loads the EXCHANGE_RATES_FILE rate table. Without one
+// only USD amounts can be normalised or converted.
+func exchangeRatesFromEnv() *currency.Rates {
+	path := os.Getenv("EXCHANGE_RATES_FILE")
+	if path == "" {
+		log.Println("EXCHANGE_RATES_FILE is not set; amounts in other currencies are not normalised to USD")
+		return nil
+	}
+
+	rates, err := currency.LoadRatesFile(path)
+	if err != nil {
+		log.Fatalf("failed to load exchange rates: %v", err)
+	}
+	return rates
+}
+
+// boxOfficeClientFromEnv chains the box office sources in priority order: the
+// BOXOFFICE_OVERRIDES_FILE corrections, the provider at BOXOFFICE_URL and,
+// when BOXOFFICE_SECONDARY_URL is set, a second licensed provider. Each HTTP
+// provider gets its own retries and circuit breaker.
+func boxOfficeClientFromEnv(rates *currency.Rates) *boxoffice.CompositeClient {
+	var providers []boxoffice.Provider
+
+	if path := os.Getenv("BOXOFFICE_OVERRIDES_FILE"); path != "" {
+		overrides, err := boxoffice.LoadOverrideFile(path)
+		if err != nil {
+			log.Fatalf("failed to load box office overrides: %v", err)
+		}
+		providers = append(providers, boxoffice.Provider{Name: "override", Client: boxoffice.NewNormalizingClient(overrides, rates)})
+	}
+
+	boxOfficeURL := os.Getenv("BOXOFFICE_URL")
+	if boxOfficeURL == "" {
+		log.Fatal("BOXOFFICE_URL must be provided for box office integration")
+	}
+	boxOfficeAPIKey := os.Getenv("BOXOFFICE_API_KEY")
+	if boxOfficeAPIKey == "" {
+		log.Fatal("BOXOFFICE_API_KEY must be provided for box office integration")
 	}
+	providers = append(providers, httpProvider(getEnvOrDefault("BOXOFFICE_NAME", "primary"), boxOfficeURL, boxOfficeAPIKey, rates))
+
+	if secondaryURL := os.Getenv("BOXOFFICE_SECONDARY_URL"); secondaryURL != "" {
+		secondaryAPIKey := os.Getenv("BOXOFFICE_SECONDARY_API_KEY")
+		if secondaryAPIKey == "" {
+			log.Fatal("BOXOFFICE_SECONDARY_API_KEY must be provided with BOXOFFICE_SECONDARY_URL")
+		}
+		providers = append(providers, httpProvider(getEnvOrDefault("BOXOFFICE_SECONDARY_NAME", "secondary"), secondaryURL, secondaryAPIKey, rates))
+	}
+
+	return boxoffice.NewCompositeClient(providers...)
+}
+
+func httpProvider(name, baseURL, apiKey string, rates *currency.Rates) boxoffice.Provider {
+	httpClient := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+	matching := boxoffice.NewMatchingClient(boxoffice.NewHTTPClient(baseURL, apiKey, httpClient), matchOptionsFromEnv())
+	return boxoffice.Provider{
+		Name:   name,
+		Client: boxoffice.NewResilientClient(boxoffice.NewNormalizingClient(matching, rates), resilienceOptionsFromEnv()),
+	}
+}
+
+// matchOptionsFromEnv configures fuzzy title matching. BOXOFFICE_MATCH_VARIANTS=0
+// only looks up titles as entered; BOXOFFICE_MATCH_CHECK_YEAR=false accepts
+// records whatever their release year.
+func matchOptionsFromEnv() boxoffice.MatchOptions {
+	opts := boxoffice.MatchOptions{MaxVariants: 4, CheckReleaseYear: true}
+
+	if value := os.Getenv("BOXOFFICE_MATCH_VARIANTS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			log.Fatalf("BOXOFFICE_MATCH_VARIANTS must be a non-negative integer, got %q", value)
+		}
+		opts.MaxVariants = parsed
+		if parsed == 0 {
+			opts.MaxVariants = -1
+		}
+	}
+
+	if value := os.Getenv("BOXOFFICE_MATCH_CHECK_YEAR"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("BOXOFFICE_MATCH_CHECK_YEAR must be true or false, got %q", value)
+		}
+		opts.CheckReleaseYear = parsed
+	}
+
+	return opts
+}
+
+func boxOfficeRefreshOptionsFromEnv() service.BoxOfficeRefreshOptions {
+	opts := service.BoxOfficeRefreshOptions{
+		MaxAge:      durationFromEnv("BOXOFFICE_REFRESH_MAX_AGE", 7*24*time.Hour),
+		Concurrency: positiveIntFromEnv("BOXOFFICE_REFRESH_CONCURRENCY", 2),
+	}
+
+	if value := os.Getenv("BOXOFFICE_REFRESH_RATE"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 {
+			log.Fatalf("BOXOFFICE_REFRESH_RATE must be a positive number of requests per second, got %q", value)
+		}
+		opts.RatePerSecond = parsed
+	}
+
+	return opts
+}
+
+// boxOfficeCacheFromEnv wraps the box office client in a cache unless
+// BOXOFFICE_CACHE_SIZE is 0. BOXOFFICE_CACHE_STORE=postgres additionally
+// shares entries between instances via the boxoffice_cache table.
+func boxOfficeCacheFromEnv(client boxoffice.Client, postgresDB *sql.DB) *boxoffice.CachingClient {
+	opts := boxoffice.CacheOptions{
+		Capacity:    1024,
+		TTL:         durationFromEnv("BOXOFFICE_CACHE_TTL", 24*time.Hour),
+		NegativeTTL: durationFromEnv("BOXOFFICE_CACHE_NEGATIVE_TTL", time.Hour),
+	}
+
+	if value := os.Getenv("BOXOFFICE_CACHE_SIZE"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			log.Fatalf("BOXOFFICE_CACHE_SIZE must be a non-negative integer, got %q", value)
+		}
+		if parsed == 0 {
+			return nil
+		}
+		opts.Capacity = parsed
+	}
+
+	switch store := strings.ToLower(getEnvOrDefault("BOXOFFICE_CACHE_STORE", "memory")); store {
+	case "memory":
+	case "postgres":
+		if postgresDB == nil {
+			log.Fatal("BOXOFFICE_CACHE_STORE=postgres requires a Postgres database")
+		}
+		opts.Store = repository.NewPostgresBoxOfficeCacheStore(postgresDB)
+	default:
+		log.Fatalf("BOXOFFICE_CACHE_STORE must be memory or postgres, got %q", store)
+	}
+
+	return boxoffice.NewCachingClient(client, opts)
+}
+
+// resilienceOptionsFromEnv configures retries and the circuit breaker around
+// the box office provider. Unset values fall back to the client defaults.
+func resilienceOptionsFromEnv() boxoffice.ResilienceOptions {
+	return boxoffice.ResilienceOptions{
+		MaxAttempts:      positiveIntFromEnv("BOXOFFICE_RETRY_ATTEMPTS", 3),
+		MaxDelay:         durationFromEnv("BOXOFFICE_RETRY_MAX_DELAY", 5*time.Second),
+		FailureThreshold: positiveIntFromEnv("BOXOFFICE_BREAKER_THRESHOLD", 5),
+		OpenTimeout:      durationFromEnv("BOXOFFICE_BREAKER_COOLDOWN", 30*time.Second),
+		Budget:           lookupBudgetFromEnv(),
+	}
+}
+
+// lookupBudgetFromEnv is the total time a box office lookup may take,
+// retries included. It must leave the server time to write the response.
+func lookupBudgetFromEnv() time.Duration {
+	budget := durationFromEnv("BOXOFFICE_LOOKUP_BUDGET", 8*time.Second)
+	if budget >= serverWriteTimeout {
+		log.Fatalf("BOXOFFICE_LOOKUP_BUDGET must be below the %s write timeout, got %s", serverWriteTimeout, budget)
+	}
+	return budget
+}
+
+func positiveIntFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		log.Fatalf("%s must be a positive integer, got %q", key, value)
+	}
+	return parsed
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		log.Fatalf("%s must be a positive duration such as 30m, got %q", key, value)
+	}
+	return parsed
+}
+
+func rankingConfigFromEnv() service.RankingConfig {
+	config := service.RankingConfig{MinVotes: 10}
+
+	if value := os.Getenv("RANKING_MIN_VOTES"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			log.Fatalf("RANKING_MIN_VOTES must be a non-negative integer, got %q", value)
+		}
+		config.MinVotes = parsed
+	}
+
+	if value := os.Getenv("RANKING_PRIOR_MEAN"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0.5 || parsed > 5.0 {
+			log.Fatalf("RANKING_PRIOR_MEAN must be a number between 0.5 and 5.0, got %q", value)
+		}
+		config.PriorMean = &parsed
+	}
+
+	return config
 }
 
 func getEnvOrDefault(key, fallback string) string {
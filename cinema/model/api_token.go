@@ -0,0 +1,40 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+const (
+	ScopeMoviesWrite = "movies:write"
+	ScopeAdmin       = "admin"
+)
+
+// ErrUnauthenticated is returned when a token is unknown, revoked or expired.
+var ErrUnauthenticated = errors.New("invalid or expired token")
+
+// KnownScopes lists every scope a token may be granted.
+var KnownScopes = []string{ScopeMoviesWrite, ScopeAdmin}
+
+// APIToken describes a write client. Only a hash of the secret is stored.
+type APIToken struct {
+	ID        string
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// HasScope reports whether the token grants scope; admin implies every scope.
+func (t *APIToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope) || slices.Contains(t.Scopes, ScopeAdmin)
+}
+
+func (t *APIToken) Active(now time.Time) bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// BoxOfficeRefreshRun records the progress of a sweep over stale box office
+// data. Movies are visited in (CreatedAt, ID) order; the cursor is the last
+// movie handled.
+type BoxOfficeRefreshRun struct {
+	ID              int64
+	StaleBefore     time.Time
+	CursorCreatedAt *time.Time
+	CursorID        string
+	Refreshed       int
+	NotFound        int
+	Failed          int
+	StartedAt       time.Time
+	FinishedAt      *time.Time
+	// Claims counts how often the run has been claimed; progress is only saved
+	// while it is unchanged, i.e. while no one else has taken the run over.
+	Claims int
+}
@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// BoxOfficeSnapshot is the box office data of a movie as returned by one
+// successful lookup.
+type BoxOfficeSnapshot struct {
+	BoxOffice
+	RecordedAt time.Time
+}
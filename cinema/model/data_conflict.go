@@ -0,0 +1,82 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Conflicting attributes, named as in the API representation of a movie and
+// as the keys of Movie.Sources.
+const (
+	ConflictReleaseDate = "releaseDate"
+	ConflictDistributor = "distributor"
+	ConflictBudget      = "budget"
+	ConflictMpaRating   = "mpaRating"
+)
+
+// Conflict statuses. An open conflict is resolved by keeping our value or by
+// taking the provider's. It goes stale instead if the provider's value is
+// accepted after our value has changed.
+const (
+	ConflictOpen           = "open"
+	ConflictAcceptedOurs   = "accepted_ours"
+	ConflictAcceptedTheirs = "accepted_theirs"
+	ConflictStale          = "stale"
+)
+
+// DataConflict records an attribute on which a box office provider disagrees
+// with the stored movie. Values are rendered as text: dates as YYYY-MM-DD and
+// budgets as whole US dollars.
+type DataConflict struct {
+	ID         int64
+	MovieID    string
+	MovieTitle string
+	Field      string
+	Ours       string
+	Theirs     string
+	// Source names the provider that reported Theirs, if known.
+	Source     string
+	Status     string
+	CreatedAt  time.Time
+	ResolvedAt *time.Time
+}
+
+// ConflictsWith lists the attributes on which the enrichment disagrees with
+// movie, typically after it has been merged in. Attributes either side lacks
+// are not conflicts; neither are names that differ only in case or spacing.
+// Budgets are compared in US dollars.
+func (e Enrichment) ConflictsWith(movie *Movie) []DataConflict {
+	var conflicts []DataConflict
+	add := func(field, ours, theirs string) {
+		conflicts = append(conflicts, DataConflict{
+			MovieID: movie.ID,
+			Field:   field,
+			Ours:    ours,
+			Theirs:  theirs,
+			Source:  e.Sources[field],
+			Status:  ConflictOpen,
+		})
+	}
+
+	if e.ReleaseDate != nil && !movie.ReleaseDate.IsZero() {
+		ours, theirs := movie.ReleaseDate.Format("2006-01-02"), e.ReleaseDate.Format("2006-01-02")
+		if ours != theirs {
+			add(ConflictReleaseDate, ours, theirs)
+		}
+	}
+	if e.Distributor != nil && movie.Distributor != nil && !sameName(*movie.Distributor, *e.Distributor) {
+		add(ConflictDistributor, *movie.Distributor, *e.Distributor)
+	}
+	if e.BudgetUSD != nil && movie.BudgetUSD != nil && *e.BudgetUSD != *movie.BudgetUSD {
+		add(ConflictBudget, strconv.FormatInt(*movie.BudgetUSD, 10), strconv.FormatInt(*e.BudgetUSD, 10))
+	}
+	if e.MpaRating != nil && movie.MpaRating != nil && !sameName(*movie.MpaRating, *e.MpaRating) {
+		add(ConflictMpaRating, *movie.MpaRating, *e.MpaRating)
+	}
+	return conflicts
+}
+
+func sameName(a, b string) bool {
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
+}
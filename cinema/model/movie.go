@@ -2,6 +2,14 @@ package model
 
 import "time"
 
+// Enrichment statuses track the box office lookup for a movie.
+const (
+	EnrichmentPending  = "pending"
+	EnrichmentComplete = "complete"
+	EnrichmentNotFound = "not_found"
+	EnrichmentFailed   = "failed"
+)
+
 type Movie struct {
 	ID          string
 	Title       string
@@ -9,10 +17,50 @@ type Movie struct {
 	ReleaseDate time.Time
 	Distributor *string
 	Budget      *int64
+	// BudgetUSD is Budget in US dollars, used for sorting and filtering.
+	// User-entered budgets are taken to be in USD already.
+	BudgetUSD        *int64
+	MpaRating        *string
+	BoxOffice        *BoxOffice
+	EnrichmentStatus string
+	// Sources maps enriched attributes ("distributor", "budget", "mpaRating",
+	// "revenue") to the box office provider that supplied them.
+	Sources map[string]string
+	// Conflicts lists disagreements with the provider found by the latest box
+	// office lookup. Repositories record them when the movie is written; they
+	// are not loaded back.
+	Conflicts []DataConflict
+	// Version starts at 1 and is incremented by every write to the movie.
+	Version   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Enrichment is the outcome of a box office lookup. Distributor, Budget and
+// MpaRating only fill in attributes the movie does not have yet; ReleaseDate
+// is never applied and only serves to detect conflicts. Sources names the
+// provider behind each attribute, keyed as in Movie.Sources.
+type Enrichment struct {
+	Status      string
+	ReleaseDate *time.Time
+	Distributor *string
+	Budget      *int64
+	BudgetUSD   *int64
 	MpaRating   *string
 	BoxOffice   *BoxOffice
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	Sources     map[string]string
+}
+
+// EnrichmentJob is a queued box office lookup for a movie.
+type EnrichmentJob struct {
+	ID          int64
+	MovieID     string
+	Title       string
+	ReleaseDate time.Time
+	// Version is the movie's version when the job was claimed; the outcome
+	// is only applied if the movie is still at it.
+	Version  int64
+	Attempts int
 }
 
 type BoxOffice struct {
@@ -20,9 +68,15 @@ type BoxOffice struct {
 	Currency    string
 	Source      string
 	LastUpdated time.Time
+	// MatchConfidence rates from 0 to 1 how closely the provider's title
+	// matched the movie's; nil when the data was not matched by title.
+	MatchConfidence *float64
 }
 
 type BoxOfficeRevenue struct {
 	Worldwide        int64
 	OpeningWeekendUS *int64
+	// WorldwideUSD is Worldwide converted to US dollars at the rate in effect
+	// on LastUpdated; nil when no rate was available.
+	WorldwideUSD *int64
 }
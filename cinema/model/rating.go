@@ -10,3 +10,22 @@ type Rating struct {
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
+
+// RatingHistogram counts ratings per 0.5 step: index 0 holds 0.5, index 9 holds 5.0.
+type RatingHistogram [10]int
+
+func RatingBucket(value float64) int {
+	return int(value*2+0.5) - 1
+}
+
+func RatingBucketValue(bucket int) float64 {
+	return float64(bucket+1) / 2
+}
+
+// RankedMovie is a movie placed on the leaderboard by its Bayesian score.
+type RankedMovie struct {
+	Movie   *Movie
+	Average float64
+	Count   int
+	Score   float64
+}
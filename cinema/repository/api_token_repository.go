@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"errors"
+)
+
+var ErrTokenNotFound = errors.New("api token not found")
+
+type APITokenRepository interface {
+	Create(ctx context.Context, token *model.APIToken, hash []byte) error
+	GetByHash(ctx context.Context, hash []byte) (*model.APIToken, error)
+	List(ctx context.Context) ([]*model.APIToken, error)
+	Revoke(ctx context.Context, id string) error
+}
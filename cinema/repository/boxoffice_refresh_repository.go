@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRefreshRunInProgress = errors.New("a box office refresh run is already in progress")
+	ErrRefreshRunLost       = errors.New("the box office refresh run was taken over or finished by another process")
+)
+
+type BoxOfficeRefreshRepository interface {
+	// ClaimRun resumes the unfinished run if its lease has expired, or starts
+	// a new one covering movies not checked since staleBefore. The claim is
+	// held for lease; ErrRefreshRunInProgress means another process holds it.
+	ClaimRun(ctx context.Context, staleBefore time.Time, lease time.Duration) (*model.BoxOfficeRefreshRun, error)
+	// ListStale returns up to limit stale movies after the run's cursor.
+	ListStale(ctx context.Context, run *model.BoxOfficeRefreshRun, limit int) ([]*model.Movie, error)
+	// ApplyEnrichment fails with ErrMovieConflict unless the movie is still
+	// at expectedVersion.
+	ApplyEnrichment(ctx context.Context, movieID string, expectedVersion int64, enrichment model.Enrichment) error
+	// SaveProgress stores the run's cursor and counters and renews the lease.
+	// It and FinishRun fail with ErrRefreshRunLost once the run has been
+	// claimed again or finished since run was claimed.
+	SaveProgress(ctx context.Context, run *model.BoxOfficeRefreshRun, lease time.Duration) error
+	FinishRun(ctx context.Context, run *model.BoxOfficeRefreshRun) error
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"time"
+)
+
+type BoxOfficeSnapshotRepository interface {
+	// ListSnapshots returns the snapshots of a movie recorded in [from, to),
+	// oldest first. A nil bound leaves that end of the range open.
+	ListSnapshots(ctx context.Context, movieID string, from, to *time.Time) ([]model.BoxOfficeSnapshot, error)
+}
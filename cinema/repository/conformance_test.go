@@ -0,0 +1,618 @@
+package repository
+
+import (
+	"cinema/db"
+	"cinema/model"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// backend is one storage implementation under test. Each call to open returns
+// repositories over an empty store.
+type backend struct {
+	movies  MovieRepository
+	ratings RatingRepository
+	tx      UnitOfWork
+}
+
+func TestMemoryRepositoriesConform(t *testing.T) {
+	runConformance(t, func(t *testing.T) backend {
+		store := NewMemoryStore()
+		return backend{movies: NewMemoryMovieRepository(store), ratings: NewMemoryRatingRepository(store), tx: NewMemoryUnitOfWork(store)}
+	})
+}
+
+// TestPostgresRepositoriesConform runs against the database at TEST_DB_URL,
+// which it migrates and empties; it is skipped when the variable is unset.
+func TestPostgresRepositoriesConform(t *testing.T) {
+	sqlDB := openTestPostgres(t)
+
+	runConformance(t, func(t *testing.T) backend {
+		truncateMovies(t, sqlDB)
+		return backend{movies: NewPostgresMovieRepository(sqlDB), ratings: NewPostgresRatingRepository(sqlDB), tx: NewPostgresUnitOfWork(sqlDB)}
+	})
+}
+
+// openTestPostgres connects to and migrates the database at TEST_DB_URL,
+// skipping the test when the variable is unset.
+func openTestPostgres(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DB_URL")
+	if dsn == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+
+	sqlDB, err := db.NewConnection(dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := db.NewMigrator(sqlDB, db.Postgres)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background(), 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return sqlDB
+}
+
+func truncateMovies(t *testing.T, sqlDB *sql.DB) {
+	t.Helper()
+	if _, err := sqlDB.Exec(`TRUNCATE movies CASCADE`); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+}
+
+// TestSQLiteRepositoriesConform gives each subtest a fresh database file.
+func TestSQLiteRepositoriesConform(t *testing.T) {
+	runConformance(t, func(t *testing.T) backend {
+		sqlDB, err := db.NewConnection("sqlite://" + filepath.Join(t.TempDir(), "cinema.db"))
+		if err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+		t.Cleanup(func() { sqlDB.Close() })
+
+		migrator, err := db.NewMigrator(sqlDB, db.SQLite)
+		if err != nil {
+			t.Fatalf("load migrations: %v", err)
+		}
+		if _, err := migrator.Up(context.Background(), 0); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+		return backend{movies: NewSQLiteMovieRepository(sqlDB), ratings: NewSQLiteRatingRepository(sqlDB), tx: NewSQLiteUnitOfWork(sqlDB)}
+	})
+}
+
+func runConformance(t *testing.T, open func(t *testing.T) backend) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, b backend)
+	}{
+		{"TitlesAreUniqueIgnoringCase", testTitlesAreUniqueIgnoringCase},
+		{"UpdateChecksVersionAndTitle", testUpdateChecksVersionAndTitle},
+		{"SoftDeleteFreesTitleUntilRestore", testSoftDeleteFreesTitleUntilRestore},
+		{"LookupIncludingDeletedPrefersLiveMovie", testLookupIncludingDeletedPrefersLiveMovie},
+		{"ListFiltersAndPagesByKeyset", testListFiltersAndPagesByKeyset},
+		{"RatingUpsertReportsCreation", testRatingUpsertReportsCreation},
+		{"RatingListPagesByKeyset", testRatingListPagesByKeyset},
+		{"DeleteRemovesRatings", testDeleteRemovesRatings},
+		{"TopRatedRanksByBayesianScore", testTopRatedRanksByBayesianScore},
+		{"CreateReturnsStoredRow", testCreateReturnsStoredRow},
+		{"UnitOfWorkCommitsOrRollsBack", testUnitOfWorkCommitsOrRollsBack},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, open(t))
+		})
+	}
+}
+
+func createMovie(t *testing.T, repo MovieRepository, movie model.Movie) *model.Movie {
+	t.Helper()
+	movie.ID = uuid.NewString()
+	if movie.Genre == "" {
+		movie.Genre = "Drama"
+	}
+	if movie.ReleaseDate.IsZero() {
+		movie.ReleaseDate = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if err := repo.Create(context.Background(), &movie); err != nil {
+		t.Fatalf("Create(%q) returned error: %v", movie.Title, err)
+	}
+	return &movie
+}
+
+func upsertRating(t *testing.T, repo RatingRepository, movieID, raterID string, value float64) bool {
+	t.Helper()
+	created, err := repo.Upsert(context.Background(), &model.Rating{MovieID: movieID, RaterID: raterID, Value: value})
+	if err != nil {
+		t.Fatalf("Upsert(%s) returned error: %v", raterID, err)
+	}
+	return created
+}
+
+func testTitlesAreUniqueIgnoringCase(t *testing.T, b backend) {
+	ctx := context.Background()
+	created := createMovie(t, b.movies, model.Movie{Title: "Heat"})
+	if created.CreatedAt.IsZero() || !created.UpdatedAt.Equal(created.CreatedAt) || created.EnrichmentStatus != model.EnrichmentComplete {
+		t.Fatalf("expected Create to set timestamps and the default status, got %+v", created)
+	}
+
+	duplicate := model.Movie{ID: uuid.NewString(), Title: "HEAT", Genre: "Crime", ReleaseDate: created.ReleaseDate}
+	if err := b.movies.Create(ctx, &duplicate); !errors.Is(err, ErrMovieAlreadyExists) {
+		t.Fatalf("expected ErrMovieAlreadyExists, got %v", err)
+	}
+
+	movie, err := b.movies.GetByTitle(ctx, "heat")
+	if err != nil {
+		t.Fatalf("GetByTitle returned error: %v", err)
+	}
+	if movie.ID != created.ID || movie.Title != "Heat" {
+		t.Fatalf("unexpected movie %+v", movie)
+	}
+	if _, err := b.movies.GetByTitle(ctx, "Hea"); !errors.Is(err, ErrMovieNotFound) {
+		t.Fatalf("expected ErrMovieNotFound for a partial title, got %v", err)
+	}
+}
+
+func testUpdateChecksVersionAndTitle(t *testing.T, b backend) {
+	ctx := context.Background()
+	movie := createMovie(t, b.movies, model.Movie{Title: "Original"})
+	createMovie(t, b.movies, model.Movie{Title: "Taken"})
+
+	if movie.Version != 1 {
+		t.Fatalf("expected a new movie at version 1, got %d", movie.Version)
+	}
+	stale, created := movie.Version, movie.UpdatedAt
+	budget := int64(1000)
+	movie.Title, movie.Budget, movie.BudgetUSD = "Renamed", &budget, &budget
+	if err := b.movies.Update(ctx, movie, stale); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	if movie.Version != stale+1 || !movie.UpdatedAt.After(created) {
+		t.Fatalf("expected Update to advance the version and UpdatedAt, got %d and %v", movie.Version, movie.UpdatedAt)
+	}
+	if err := b.movies.Update(ctx, movie, stale); !errors.Is(err, ErrMovieConflict) {
+		t.Fatalf("expected ErrMovieConflict for a stale version, got %v", err)
+	}
+	if err := b.movies.UpdateBoxOffice(ctx, movie, stale); !errors.Is(err, ErrMovieConflict) {
+		t.Fatalf("expected UpdateBoxOffice to check the version, got %v", err)
+	}
+
+	movie.Title = "taken"
+	if err := b.movies.Update(ctx, movie, movie.Version); !errors.Is(err, ErrMovieAlreadyExists) {
+		t.Fatalf("expected ErrMovieAlreadyExists, got %v", err)
+	}
+
+	missing := &model.Movie{ID: uuid.NewString(), Title: "Missing", Genre: "Drama", ReleaseDate: movie.ReleaseDate}
+	if err := b.movies.Update(ctx, missing, stale); !errors.Is(err, ErrMovieNotFound) {
+		t.Fatalf("expected ErrMovieNotFound, got %v", err)
+	}
+
+	stored, err := b.movies.GetByTitle(ctx, "RENAMED")
+	if err != nil {
+		t.Fatalf("GetByTitle returned error: %v", err)
+	}
+	if stored.Budget == nil || *stored.Budget != budget || stored.Version != movie.Version || !stored.UpdatedAt.Equal(movie.UpdatedAt) {
+		t.Fatalf("unexpected stored movie %+v", stored)
+	}
+}
+
+func testSoftDeleteFreesTitleUntilRestore(t *testing.T, b backend) {
+	ctx := context.Background()
+	first := createMovie(t, b.movies, model.Movie{Title: "Ghost"})
+	if err := b.movies.SoftDelete(ctx, first.ID, first.Version+1); !errors.Is(err, ErrMovieConflict) {
+		t.Fatalf("expected ErrMovieConflict for a wrong version, got %v", err)
+	}
+	if err := b.movies.SoftDelete(ctx, first.ID, first.Version); err != nil {
+		t.Fatalf("SoftDelete returned error: %v", err)
+	}
+	if err := b.movies.SoftDelete(ctx, first.ID, first.Version+1); !errors.Is(err, ErrMovieNotFound) {
+		t.Fatalf("expected a second SoftDelete to fail with ErrMovieNotFound, got %v", err)
+	}
+	if _, err := b.movies.GetByTitle(ctx, "Ghost"); !errors.Is(err, ErrMovieNotFound) {
+		t.Fatalf("expected the soft-deleted movie to be hidden, got %v", err)
+	}
+
+	second := createMovie(t, b.movies, model.Movie{Title: "ghost"})
+	if _, err := b.movies.Restore(ctx, "Ghost"); !errors.Is(err, ErrMovieAlreadyExists) {
+		t.Fatalf("expected ErrMovieAlreadyExists while the title is reused, got %v", err)
+	}
+
+	if err := b.movies.Delete(ctx, second.ID, second.Version+1); !errors.Is(err, ErrMovieConflict) {
+		t.Fatalf("expected ErrMovieConflict for a wrong version, got %v", err)
+	}
+	if err := b.movies.Delete(ctx, second.ID, second.Version); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	restored, err := b.movies.Restore(ctx, "GHOST")
+	if err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	if restored.ID != first.ID || restored.Version != first.Version+2 {
+		t.Fatalf("expected %s to be restored at version %d, got %s at %d", first.ID, first.Version+2, restored.ID, restored.Version)
+	}
+	if _, err := b.movies.Restore(ctx, "Ghost"); !errors.Is(err, ErrMovieNotFound) {
+		t.Fatalf("expected ErrMovieNotFound with nothing left to restore, got %v", err)
+	}
+}
+
+func testLookupIncludingDeletedPrefersLiveMovie(t *testing.T, b backend) {
+	ctx := context.Background()
+	older := createMovie(t, b.movies, model.Movie{Title: "Ghost"})
+	if err := b.movies.SoftDelete(ctx, older.ID, older.Version); err != nil {
+		t.Fatalf("SoftDelete returned error: %v", err)
+	}
+	newer := createMovie(t, b.movies, model.Movie{Title: "ghost"})
+	if err := b.movies.SoftDelete(ctx, newer.ID, newer.Version); err != nil {
+		t.Fatalf("SoftDelete returned error: %v", err)
+	}
+
+	found, err := b.movies.GetByTitleIncludingDeleted(ctx, "GHOST")
+	if err != nil {
+		t.Fatalf("GetByTitleIncludingDeleted returned error: %v", err)
+	}
+	if found.ID != newer.ID {
+		t.Fatalf("expected the most recently deleted movie %s, got %s", newer.ID, found.ID)
+	}
+
+	live := createMovie(t, b.movies, model.Movie{Title: "Ghost"})
+	if found, err = b.movies.GetByTitleIncludingDeleted(ctx, "Ghost"); err != nil || found.ID != live.ID {
+		t.Fatalf("expected the live movie %s, got %+v and %v", live.ID, found, err)
+	}
+	if _, err := b.movies.GetByTitleIncludingDeleted(ctx, "Missing"); !errors.Is(err, ErrMovieNotFound) {
+		t.Fatalf("expected ErrMovieNotFound, got %v", err)
+	}
+}
+
+func testListFiltersAndPagesByKeyset(t *testing.T, b backend) {
+	ctx := context.Background()
+	small, large := int64(10_000_000), int64(200_000_000)
+	revenue := int64(500_000_000)
+	distributor := "Warner Bros."
+	titles := []string{"The Dark Knight", "Dark City", "Heat", "100%_Real", "Darkman"}
+	movies := []model.Movie{
+		{Title: titles[0], Genre: "Action", ReleaseDate: time.Date(2008, 7, 18, 0, 0, 0, 0, time.UTC), Budget: &large, BudgetUSD: &large, Distributor: &distributor,
+			BoxOffice: &model.BoxOffice{Revenue: model.BoxOfficeRevenue{Worldwide: revenue, WorldwideUSD: &revenue}, Currency: "USD"}},
+		{Title: titles[1], Genre: "Sci-Fi", ReleaseDate: time.Date(1998, 2, 27, 0, 0, 0, 0, time.UTC), Budget: &small, BudgetUSD: &small},
+		{Title: titles[2], Genre: "Crime", ReleaseDate: time.Date(1995, 12, 15, 0, 0, 0, 0, time.UTC)},
+		{Title: titles[3], Genre: "Documentary", ReleaseDate: time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Title: titles[4], Genre: "action", ReleaseDate: time.Date(1990, 8, 24, 0, 0, 0, 0, time.UTC)},
+	}
+	ids := make(map[string]string)
+	for _, movie := range movies {
+		ids[createMovie(t, b.movies, movie).ID] = movie.Title
+	}
+	deleted := createMovie(t, b.movies, model.Movie{Title: "Dark Water"})
+	if err := b.movies.SoftDelete(ctx, deleted.ID, deleted.Version); err != nil {
+		t.Fatalf("SoftDelete returned error: %v", err)
+	}
+
+	year, genre := 2008, "ACTION"
+	cases := []struct {
+		name   string
+		filter MovieFilter
+		want   []string
+	}{
+		{"all live movies", MovieFilter{}, titles},
+		{"search ignores case", MovieFilter{Q: "dARK"}, []string{titles[0], titles[1], titles[4]}},
+		{"search honours wildcards", MovieFilter{Q: "0%_r"}, []string{titles[3]}},
+		{"search escapes wildcards", MovieFilter{Q: `\%\_`}, []string{titles[3]}},
+		{"year", MovieFilter{Year: &year}, []string{titles[0], titles[3]}},
+		{"genre ignores case", MovieFilter{Genre: &genre}, []string{titles[0], titles[4]}},
+		{"distributor", MovieFilter{Distributor: &distributor}, []string{titles[0]}},
+		{"budget skips unknown budgets", MovieFilter{BudgetLTE: &small}, []string{titles[1]}},
+		{"revenue", MovieFilter{RevenueGTE: &revenue}, []string{titles[0]}},
+	}
+	for _, tc := range cases {
+		listed, err := b.movies.List(ctx, MovieListParams{MovieFilter: tc.filter, Limit: 10})
+		if err != nil {
+			t.Fatalf("%s: List returned error: %v", tc.name, err)
+		}
+		got := make([]string, 0, len(listed))
+		for _, movie := range listed {
+			got = append(got, movie.Title)
+		}
+		if !equalStrings(got, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+
+	var (
+		paged []string
+		after *MovieCursor
+	)
+	for page := 0; ; page++ {
+		listed, err := b.movies.List(ctx, MovieListParams{Limit: 2, After: after})
+		if err != nil {
+			t.Fatalf("List returned error: %v", err)
+		}
+		if len(listed) == 0 {
+			break
+		}
+		if page > len(titles) {
+			t.Fatalf("pagination does not terminate")
+		}
+		for _, movie := range listed {
+			paged = append(paged, ids[movie.ID])
+		}
+		last := listed[len(listed)-1]
+		after = &MovieCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	if !equalStrings(paged, titles) {
+		t.Fatalf("expected pages in creation order %v, got %v", titles, paged)
+	}
+}
+
+func testRatingUpsertReportsCreation(t *testing.T, b backend) {
+	ctx := context.Background()
+	movie := createMovie(t, b.movies, model.Movie{Title: "Heat"})
+
+	if !upsertRating(t, b.ratings, movie.ID, "alice", 4.5) {
+		t.Fatalf("expected the first rating to be created")
+	}
+	first, err := b.ratings.Get(ctx, movie.ID, "alice")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if upsertRating(t, b.ratings, movie.ID, "alice", 3) {
+		t.Fatalf("expected the second rating to update the first")
+	}
+	upsertRating(t, b.ratings, movie.ID, "bob", 5)
+
+	updated, err := b.ratings.Get(ctx, movie.ID, "alice")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if updated.Value != 3 || !updated.CreatedAt.Equal(first.CreatedAt) || !updated.UpdatedAt.After(first.UpdatedAt) {
+		t.Fatalf("expected the update to keep created_at and advance updated_at, got %+v after %+v", updated, first)
+	}
+
+	average, count, err := b.ratings.AggregateByMovieID(ctx, movie.ID)
+	if err != nil || average != 4 || count != 2 {
+		t.Fatalf("expected average 4 over 2 ratings, got %v over %d (%v)", average, count, err)
+	}
+	histogram, err := b.ratings.HistogramByMovieID(ctx, movie.ID)
+	if err != nil || histogram != (model.RatingHistogram{5: 1, 9: 1}) {
+		t.Fatalf("unexpected histogram %v (%v)", histogram, err)
+	}
+
+	if err := b.ratings.Delete(ctx, movie.ID, "alice"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if err := b.ratings.Delete(ctx, movie.ID, "alice"); !errors.Is(err, ErrRatingNotFound) {
+		t.Fatalf("expected ErrRatingNotFound, got %v", err)
+	}
+	if _, err := b.ratings.Get(ctx, movie.ID, "alice"); !errors.Is(err, ErrRatingNotFound) {
+		t.Fatalf("expected ErrRatingNotFound, got %v", err)
+	}
+	average, count, err = b.ratings.AggregateByMovieID(ctx, movie.ID)
+	if err != nil || average != 5 || count != 1 {
+		t.Fatalf("expected average 5 over 1 rating, got %v over %d (%v)", average, count, err)
+	}
+}
+
+func testRatingListPagesByKeyset(t *testing.T, b backend) {
+	ctx := context.Background()
+	movie := createMovie(t, b.movies, model.Movie{Title: "Heat"})
+	other := createMovie(t, b.movies, model.Movie{Title: "Ronin"})
+
+	raters := []string{"carol", "alice", "bob", "dave", "erin"}
+	for _, rater := range raters {
+		upsertRating(t, b.ratings, movie.ID, rater, 4)
+	}
+	upsertRating(t, b.ratings, other.ID, "frank", 2)
+	// Updating a rating does not move it in the listing.
+	upsertRating(t, b.ratings, movie.ID, "carol", 1)
+
+	var (
+		listed []string
+		after  *RatingCursor
+	)
+	for page := 0; ; page++ {
+		ratings, err := b.ratings.List(ctx, RatingListParams{MovieID: movie.ID, Limit: 2, After: after})
+		if err != nil {
+			t.Fatalf("List returned error: %v", err)
+		}
+		if len(ratings) == 0 {
+			break
+		}
+		if page > len(raters) {
+			t.Fatalf("pagination does not terminate")
+		}
+		for _, rating := range ratings {
+			listed = append(listed, rating.RaterID)
+		}
+		last := ratings[len(ratings)-1]
+		after = &RatingCursor{CreatedAt: last.CreatedAt, RaterID: last.RaterID}
+	}
+	if !equalStrings(listed, raters) {
+		t.Fatalf("expected ratings in creation order %v, got %v", raters, listed)
+	}
+}
+
+func testDeleteRemovesRatings(t *testing.T, b backend) {
+	ctx := context.Background()
+	movie := createMovie(t, b.movies, model.Movie{Title: "Heat"})
+	upsertRating(t, b.ratings, movie.ID, "alice", 4)
+
+	if err := b.movies.SoftDelete(ctx, movie.ID, movie.Version); err != nil {
+		t.Fatalf("SoftDelete returned error: %v", err)
+	}
+	if _, err := b.ratings.Get(ctx, movie.ID, "alice"); err != nil {
+		t.Fatalf("expected a soft delete to keep ratings, got %v", err)
+	}
+
+	if err := b.movies.Delete(ctx, movie.ID, movie.Version+1); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if err := b.movies.Delete(ctx, movie.ID, movie.Version+1); !errors.Is(err, ErrMovieNotFound) {
+		t.Fatalf("expected ErrMovieNotFound, got %v", err)
+	}
+	if _, err := b.ratings.Get(ctx, movie.ID, "alice"); !errors.Is(err, ErrRatingNotFound) {
+		t.Fatalf("expected a hard delete to remove ratings, got %v", err)
+	}
+}
+
+func testCreateReturnsStoredRow(t *testing.T, b backend) {
+	movie := createMovie(t, b.movies, model.Movie{Title: "Heat", ReleaseDate: time.Date(1995, 12, 15, 0, 0, 0, 0, time.UTC)})
+	if movie.CreatedAt.IsZero() || !movie.UpdatedAt.Equal(movie.CreatedAt) {
+		t.Fatalf("expected matching creation timestamps, got %v and %v", movie.CreatedAt, movie.UpdatedAt)
+	}
+	if movie.EnrichmentStatus != model.EnrichmentComplete {
+		t.Fatalf("expected the default enrichment status, got %q", movie.EnrichmentStatus)
+	}
+
+	stored, err := b.movies.GetByTitle(context.Background(), "Heat")
+	if err != nil {
+		t.Fatalf("GetByTitle returned error: %v", err)
+	}
+	if stored.ID != movie.ID || !stored.UpdatedAt.Equal(movie.UpdatedAt) || !stored.ReleaseDate.Equal(movie.ReleaseDate) {
+		t.Fatalf("expected Create to return the stored row %+v, got %+v", stored, movie)
+	}
+}
+
+func testUnitOfWorkCommitsOrRollsBack(t *testing.T, b backend) {
+	ctx := context.Background()
+	movie := createMovie(t, b.movies, model.Movie{Title: "Heat"})
+	failure := errors.New("abort")
+
+	err := b.tx.WithTx(ctx, func(repos Repositories) error {
+		found, err := repos.Movies.GetByTitle(ctx, "Heat")
+		if err != nil {
+			return err
+		}
+		if _, err := repos.Ratings.Upsert(ctx, &model.Rating{MovieID: found.ID, RaterID: "alice", Value: 4}); err != nil {
+			return err
+		}
+		if err := repos.Movies.SoftDelete(ctx, found.ID, found.Version); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected WithTx to return the error from fn, got %v", err)
+	}
+	if _, err := b.movies.GetByTitle(ctx, "Heat"); err != nil {
+		t.Fatalf("expected the soft delete to be rolled back, got %v", err)
+	}
+	if _, err := b.ratings.Get(ctx, movie.ID, "alice"); !errors.Is(err, ErrRatingNotFound) {
+		t.Fatalf("expected the rating to be rolled back, got %v", err)
+	}
+
+	err = b.tx.WithTx(ctx, func(repos Repositories) error {
+		created := &model.Movie{ID: uuid.NewString(), Title: "Ronin", Genre: "Action", ReleaseDate: movie.ReleaseDate}
+		if err := repos.Movies.Create(ctx, created); err != nil {
+			return err
+		}
+		_, err := repos.Ratings.Upsert(ctx, &model.Rating{MovieID: created.ID, RaterID: "alice", Value: 5})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTx returned error: %v", err)
+	}
+	created, err := b.movies.GetByTitle(ctx, "Ronin")
+	if err != nil {
+		t.Fatalf("expected the created movie to be committed, got %v", err)
+	}
+	if _, count, err := b.ratings.AggregateByMovieID(ctx, created.ID); err != nil || count != 1 {
+		t.Fatalf("expected the rating to be committed, got %d ratings and %v", count, err)
+	}
+
+	if _, err := b.ratings.Upsert(ctx, &model.Rating{MovieID: movie.ID, RaterID: "bob", Value: 3}); err != nil {
+		t.Fatalf("Upsert returned error: %v", err)
+	}
+	err = b.tx.WithTx(ctx, func(repos Repositories) error {
+		found, err := repos.Movies.GetByTitle(ctx, "Heat")
+		if err != nil {
+			return err
+		}
+		if err := repos.Movies.Delete(ctx, found.ID, found.Version); err != nil {
+			return err
+		}
+		if err := repos.Movies.Create(ctx, &model.Movie{ID: uuid.NewString(), Title: "Collateral", Genre: "Crime", ReleaseDate: movie.ReleaseDate}); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected WithTx to return the error from fn, got %v", err)
+	}
+	if _, count, err := b.ratings.AggregateByMovieID(ctx, movie.ID); err != nil || count != 1 {
+		t.Fatalf("expected the hard delete to be rolled back with its ratings, got %d ratings and %v", count, err)
+	}
+	if _, err := b.movies.GetByTitle(ctx, "Collateral"); !errors.Is(err, ErrMovieNotFound) {
+		t.Fatalf("expected the created movie to be rolled back, got %v", err)
+	}
+}
+
+func testTopRatedRanksByBayesianScore(t *testing.T, b backend) {
+	ctx := context.Background()
+	popular := createMovie(t, b.movies, model.Movie{Title: "Popular", Genre: "Crime"})
+	niche := createMovie(t, b.movies, model.Movie{Title: "Niche", Genre: "Crime"})
+	other := createMovie(t, b.movies, model.Movie{Title: "Other", Genre: "Comedy"})
+	gone := createMovie(t, b.movies, model.Movie{Title: "Gone", Genre: "Crime"})
+	createMovie(t, b.movies, model.Movie{Title: "Unrated", Genre: "Crime"})
+
+	for _, rater := range []string{"a", "b", "c", "d"} {
+		upsertRating(t, b.ratings, popular.ID, rater, 4)
+	}
+	upsertRating(t, b.ratings, niche.ID, "a", 5)
+	upsertRating(t, b.ratings, other.ID, "a", 1)
+	upsertRating(t, b.ratings, gone.ID, "a", 1)
+	upsertRating(t, b.ratings, gone.ID, "b", 1)
+	if err := b.movies.SoftDelete(ctx, gone.ID, gone.Version); err != nil {
+		t.Fatalf("SoftDelete returned error: %v", err)
+	}
+
+	// The global mean is 3 over all eight ratings, the deleted movie's
+	// included. Popular and niche then tie at 11/3 and the tie goes to the
+	// movie with more votes.
+	ranked, err := b.ratings.TopRated(ctx, TopRatedParams{MinVotes: 2, Limit: 10})
+	if err != nil {
+		t.Fatalf("TopRated returned error: %v", err)
+	}
+	if len(ranked) != 3 || ranked[0].Movie.ID != popular.ID || ranked[1].Movie.ID != niche.ID || ranked[2].Movie.ID != other.ID {
+		t.Fatalf("unexpected ranking %+v", ranked)
+	}
+	if ranked[0].Count != 4 || ranked[0].Average != 4 || !approxEqual(ranked[0].Score, 11.0/3) {
+		t.Fatalf("unexpected leader %+v", ranked[0])
+	}
+
+	genre := "crime"
+	prior := 5.0
+	ranked, err = b.ratings.TopRated(ctx, TopRatedParams{MovieFilter: MovieFilter{Genre: &genre}, PriorMean: &prior, MinVotes: 2, Limit: 1})
+	if err != nil {
+		t.Fatalf("TopRated returned error: %v", err)
+	}
+	if len(ranked) != 1 || ranked[0].Movie.ID != niche.ID || !approxEqual(ranked[0].Score, 5) {
+		t.Fatalf("expected niche to lead under a prior of 5, got %+v", ranked)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func approxEqual(a, b float64) bool {
+	const epsilon = 1e-9
+	return a-b < epsilon && b-a < epsilon
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrConflictNotFound = errors.New("data conflict not found")
+	ErrConflictResolved = errors.New("data conflict already resolved")
+	ErrConflictStale    = errors.New("the movie has changed since the data conflict was raised")
+)
+
+type DataConflictCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+type DataConflictListParams struct {
+	Status string
+	// MovieID restricts the listing to one movie when set.
+	MovieID string
+	Limit   int
+	After   *DataConflictCursor
+}
+
+type DataConflictRepository interface {
+	// List returns conflicts oldest first, with their movie titles.
+	List(ctx context.Context, params DataConflictListParams) ([]*model.DataConflict, error)
+	// Resolve closes an open conflict with status model.ConflictAcceptedOurs
+	// or model.ConflictAcceptedTheirs. Accepting theirs writes the provider's
+	// value to the movie in the same transaction, provided the movie still
+	// holds our value; otherwise the conflict is marked stale and
+	// ErrConflictStale is returned.
+	Resolve(ctx context.Context, id int64, status string) (*model.DataConflict, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"time"
+)
+
+// EnrichmentOutcome tells ProcessNext what to do with a claimed job: apply
+// Enrichment and remove the job, or, when RetryAt is set, reschedule it.
+type EnrichmentOutcome struct {
+	Enrichment model.Enrichment
+	RetryAt    *time.Time
+	Error      string
+}
+
+type EnrichmentJobRepository interface {
+	// ProcessNext leases the oldest due job, skipping jobs leased by other
+	// workers, and calls handle outside any transaction. The outcome is
+	// persisted afterwards unless the lease was lost meanwhile. It reports
+	// false if no job was due.
+	ProcessNext(ctx context.Context, handle func(context.Context, model.EnrichmentJob) EnrichmentOutcome) (bool, error)
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"slices"
+	"sort"
+)
+
+type MemoryAPITokenRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryAPITokenRepository(store *MemoryStore) *MemoryAPITokenRepository {
+	return &MemoryAPITokenRepository{store: store}
+}
+
+func (r *MemoryAPITokenRepository) Create(ctx context.Context, token *model.APIToken, hash []byte) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token.CreatedAt = s.timestamp()
+	s.tokens[token.ID] = &memoryToken{token: cloneAPIToken(token), hash: string(hash)}
+	return nil
+}
+
+func (r *MemoryAPITokenRepository) GetByHash(ctx context.Context, hash []byte) (*model.APIToken, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, stored := range s.tokens {
+		if stored.hash == string(hash) {
+			return cloneAPIToken(stored.token), nil
+		}
+	}
+	return nil, ErrTokenNotFound
+}
+
+func (r *MemoryAPITokenRepository) List(ctx context.Context) ([]*model.APIToken, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tokens []*model.APIToken
+	for _, stored := range s.tokens {
+		tokens = append(tokens, cloneAPIToken(stored.token))
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if !tokens[i].CreatedAt.Equal(tokens[j].CreatedAt) {
+			return tokens[i].CreatedAt.Before(tokens[j].CreatedAt)
+		}
+		return tokens[i].ID < tokens[j].ID
+	})
+	return tokens, nil
+}
+
+// Revoke keeps the original revocation time of an already revoked token.
+func (r *MemoryAPITokenRepository) Revoke(ctx context.Context, id string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.tokens[id]
+	if !ok {
+		return ErrTokenNotFound
+	}
+	if stored.token.RevokedAt == nil {
+		stored.token.RevokedAt = timePtr(s.timestamp())
+	}
+	return nil
+}
+
+func cloneAPIToken(token *model.APIToken) *model.APIToken {
+	copied := *token
+	copied.Scopes = slices.Clone(token.Scopes)
+	if token.ExpiresAt != nil {
+		copied.ExpiresAt = timePtr(*token.ExpiresAt)
+	}
+	if token.RevokedAt != nil {
+		copied.RevokedAt = timePtr(*token.RevokedAt)
+	}
+	return &copied
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"time"
+)
+
+type MemoryBoxOfficeSnapshotRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryBoxOfficeSnapshotRepository(store *MemoryStore) *MemoryBoxOfficeSnapshotRepository {
+	return &MemoryBoxOfficeSnapshotRepository{store: store}
+}
+
+func (r *MemoryBoxOfficeSnapshotRepository) ListSnapshots(ctx context.Context, movieID string, from, to *time.Time) ([]model.BoxOfficeSnapshot, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Snapshots are appended in recording order already.
+	snapshots := make([]model.BoxOfficeSnapshot, 0)
+	for _, snapshot := range s.snapshots[movieID] {
+		if from != nil && snapshot.RecordedAt.Before(*from) {
+			continue
+		}
+		if to != nil && !snapshot.RecordedAt.Before(*to) {
+			continue
+		}
+		snapshot.Revenue.OpeningWeekendUS = cloneInt(snapshot.Revenue.OpeningWeekendUS)
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
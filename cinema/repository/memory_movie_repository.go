@@ -0,0 +1,351 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MemoryMovieRepository keeps movies in a MemoryStore with the semantics of
+// PostgresMovieRepository. Movies created with EnrichmentPending are not
+// queued, since there is no enrichment worker without Postgres, and
+// conflicts with the provider are not recorded.
+type MemoryMovieRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryMovieRepository(store *MemoryStore) *MemoryMovieRepository {
+	return &MemoryMovieRepository{store: store}
+}
+
+func (r *MemoryMovieRepository) Create(ctx context.Context, movie *model.Movie) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.movies[movie.ID]; exists || s.liveByTitle(movie.Title) != nil {
+		return ErrMovieAlreadyExists
+	}
+	if movie.EnrichmentStatus == "" {
+		movie.EnrichmentStatus = model.EnrichmentComplete
+	}
+
+	now := s.timestamp()
+	movie.Version = 1
+	movie.CreatedAt, movie.UpdatedAt = now, now
+	s.touchMovie(movie.ID)
+	s.movies[movie.ID] = &memoryMovie{movie: cloneMovie(movie)}
+
+	if movie.EnrichmentStatus == model.EnrichmentComplete && movie.BoxOffice != nil {
+		s.appendSnapshot(movie.ID, movie.BoxOffice, now)
+	}
+	return nil
+}
+
+func (r *MemoryMovieRepository) Update(ctx context.Context, movie *model.Movie, expectedVersion int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.writable(movie.ID, expectedVersion)
+	if err != nil {
+		return err
+	}
+	if other := s.liveByTitle(movie.Title); other != nil && other.movie.ID != movie.ID {
+		return ErrMovieAlreadyExists
+	}
+
+	updated := cloneMovie(movie)
+	current := stored.movie
+	current.Title = updated.Title
+	current.Genre = updated.Genre
+	current.ReleaseDate = updated.ReleaseDate
+	current.Distributor = updated.Distributor
+	current.Budget = updated.Budget
+	current.BudgetUSD = updated.BudgetUSD
+	current.MpaRating = updated.MpaRating
+	current.Sources = updated.Sources
+	current.Version++
+	current.UpdatedAt = s.timestamp()
+
+	movie.Version, movie.UpdatedAt = current.Version, current.UpdatedAt
+	return nil
+}
+
+func (r *MemoryMovieRepository) UpdateBoxOffice(ctx context.Context, movie *model.Movie, expectedVersion int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.writable(movie.ID, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	updated := cloneMovie(movie)
+	current := stored.movie
+	current.Distributor = updated.Distributor
+	current.Budget = updated.Budget
+	current.BudgetUSD = updated.BudgetUSD
+	current.MpaRating = updated.MpaRating
+	current.BoxOffice = updated.BoxOffice
+	current.EnrichmentStatus = updated.EnrichmentStatus
+	current.Sources = updated.Sources
+	current.Version++
+	current.UpdatedAt = s.timestamp()
+
+	if movie.EnrichmentStatus == model.EnrichmentComplete && movie.BoxOffice != nil {
+		s.appendSnapshot(movie.ID, movie.BoxOffice, current.UpdatedAt)
+	}
+
+	movie.Version, movie.UpdatedAt = current.Version, current.UpdatedAt
+	return nil
+}
+
+func (r *MemoryMovieRepository) GetByTitle(ctx context.Context, title string) (*model.Movie, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored := s.liveByTitle(title)
+	if stored == nil {
+		return nil, ErrMovieNotFound
+	}
+	return cloneMovie(stored.movie), nil
+}
+
+func (r *MemoryMovieRepository) GetByTitleIncludingDeleted(ctx context.Context, title string) (*model.Movie, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored := s.liveByTitle(title)
+	if stored == nil {
+		stored = s.latestDeletedByTitle(title)
+	}
+	if stored == nil {
+		return nil, ErrMovieNotFound
+	}
+	return cloneMovie(stored.movie), nil
+}
+
+func (r *MemoryMovieRepository) SoftDelete(ctx context.Context, movieID string, expectedVersion int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.writable(movieID, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	now := s.timestamp()
+	stored.deletedAt = &now
+	stored.movie.Version++
+	stored.movie.UpdatedAt = now
+	return nil
+}
+
+// Delete permanently removes a movie together with its ratings and box
+// office history.
+func (r *MemoryMovieRepository) Delete(ctx context.Context, movieID string, expectedVersion int64) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.movies[movieID]
+	if !ok {
+		return ErrMovieNotFound
+	}
+	if stored.movie.Version != expectedVersion {
+		return ErrMovieConflict
+	}
+	s.touchMovie(movieID)
+	s.touchRatings(movieID)
+	s.touchSnapshots(movieID)
+	delete(s.movies, movieID)
+	delete(s.ratings, movieID)
+	delete(s.snapshots, movieID)
+	return nil
+}
+
+func (r *MemoryMovieRepository) Restore(ctx context.Context, title string) (*model.Movie, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest := s.latestDeletedByTitle(title)
+	if latest == nil {
+		return nil, ErrMovieNotFound
+	}
+	if s.liveByTitle(title) != nil {
+		return nil, ErrMovieAlreadyExists
+	}
+
+	s.touchMovie(latest.movie.ID)
+	latest.deletedAt = nil
+	latest.movie.Version++
+	latest.movie.UpdatedAt = s.timestamp()
+	return cloneMovie(latest.movie), nil
+}
+
+func (r *MemoryMovieRepository) List(ctx context.Context, params MovieListParams) ([]*model.Movie, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	match, err := movieFilterMatcher(params.MovieFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	var movies []*model.Movie
+	for _, stored := range s.movies {
+		if stored.deletedAt != nil || !match(stored.movie) {
+			continue
+		}
+		if params.After != nil && !afterMovieCursor(stored.movie, params.After) {
+			continue
+		}
+		movies = append(movies, stored.movie)
+	}
+
+	sort.Slice(movies, func(i, j int) bool {
+		return movieKeyLess(movies[i], movies[j])
+	})
+	if len(movies) > params.Limit {
+		movies = movies[:max(params.Limit, 0)]
+	}
+
+	for i, movie := range movies {
+		movies[i] = cloneMovie(movie)
+	}
+	return movies, nil
+}
+
+// liveByTitle finds the movie that currently holds title, compared
+// case-insensitively. Callers hold the lock.
+func (s *MemoryStore) liveByTitle(title string) *memoryMovie {
+	for _, stored := range s.movies {
+		if stored.deletedAt == nil && sameText(stored.movie.Title, title) {
+			return stored
+		}
+	}
+	return nil
+}
+
+// latestDeletedByTitle finds the most recently soft-deleted movie with
+// title, compared case-insensitively. Callers hold the lock.
+func (s *MemoryStore) latestDeletedByTitle(title string) *memoryMovie {
+	var latest *memoryMovie
+	for _, stored := range s.movies {
+		if stored.deletedAt == nil || !sameText(stored.movie.Title, title) {
+			continue
+		}
+		if latest == nil || stored.deletedAt.After(*latest.deletedAt) {
+			latest = stored
+		}
+	}
+	return latest
+}
+
+// writable applies the version check shared by the conditional writes, which
+// then modify the returned movie in place. Callers hold the write lock.
+func (s *MemoryStore) writable(movieID string, expectedVersion int64) (*memoryMovie, error) {
+	stored, ok := s.movies[movieID]
+	if !ok || stored.deletedAt != nil {
+		return nil, ErrMovieNotFound
+	}
+	if stored.movie.Version != expectedVersion {
+		return nil, ErrMovieConflict
+	}
+	s.touchMovie(movieID)
+	return stored, nil
+}
+
+// sameText compares like LOWER(a) = LOWER(b).
+func sameText(a, b string) bool {
+	return strings.ToLower(a) == strings.ToLower(b)
+}
+
+func afterMovieCursor(movie *model.Movie, cursor *MovieCursor) bool {
+	return movie.CreatedAt.After(cursor.CreatedAt) ||
+		(movie.CreatedAt.Equal(cursor.CreatedAt) && movie.ID > cursor.ID)
+}
+
+func movieKeyLess(a, b *model.Movie) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+// movieFilterMatcher is the in-memory counterpart of movieFilterClauses. It
+// does not check for soft deletion.
+func movieFilterMatcher(filter MovieFilter) (func(*model.Movie) bool, error) {
+	var search *regexp.Regexp
+	if filter.Q != "" {
+		pattern, err := regexp.Compile(ilikePattern("%" + filter.Q + "%"))
+		if err != nil {
+			return nil, err
+		}
+		search = pattern
+	}
+
+	return func(movie *model.Movie) bool {
+		if search != nil && !search.MatchString(movie.Title) {
+			return false
+		}
+		if filter.Year != nil && movie.ReleaseDate.Year() != *filter.Year {
+			return false
+		}
+		if filter.Genre != nil && *filter.Genre != "" && !sameText(movie.Genre, *filter.Genre) {
+			return false
+		}
+		if filter.Distributor != nil && *filter.Distributor != "" &&
+			(movie.Distributor == nil || !sameText(*movie.Distributor, *filter.Distributor)) {
+			return false
+		}
+		if filter.BudgetLTE != nil && (movie.BudgetUSD == nil || *movie.BudgetUSD > *filter.BudgetLTE) {
+			return false
+		}
+		if filter.RevenueGTE != nil {
+			if movie.BoxOffice == nil || movie.BoxOffice.Revenue.WorldwideUSD == nil || *movie.BoxOffice.Revenue.WorldwideUSD < *filter.RevenueGTE {
+				return false
+			}
+		}
+		if filter.MpaRating != nil && *filter.MpaRating != "" &&
+			(movie.MpaRating == nil || !sameText(*movie.MpaRating, *filter.MpaRating)) {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// ilikePattern translates an ILIKE pattern into an anchored, case-insensitive
+// regular expression: % and _ are wildcards unless escaped with a backslash,
+// as in Postgres.
+func ilikePattern(like string) string {
+	var b strings.Builder
+	b.WriteString(`(?is)^`)
+	escaped := false
+	for _, r := range like {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			b.WriteString(`.*`)
+		case r == '_':
+			b.WriteString(`.`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString(`$`)
+	return b.String()
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"sort"
+)
+
+// MemoryRatingRepository keeps ratings in a MemoryStore with the semantics of
+// PostgresRatingRepository. Aggregates are computed on read rather than
+// maintained incrementally.
+type MemoryRatingRepository struct {
+	store *MemoryStore
+}
+
+func NewMemoryRatingRepository(store *MemoryStore) *MemoryRatingRepository {
+	return &MemoryRatingRepository{store: store}
+}
+
+// Upsert fails with ErrMovieNotFound for an unknown movie, where Postgres
+// reports a foreign key violation. Like Postgres it accepts ratings for
+// soft-deleted movies; the service checks the movie first.
+func (r *MemoryRatingRepository) Upsert(ctx context.Context, rating *model.Rating) (bool, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.movies[rating.MovieID]; !ok {
+		return false, ErrMovieNotFound
+	}
+
+	s.touchRatings(rating.MovieID)
+	byRater, ok := s.ratings[rating.MovieID]
+	if !ok {
+		byRater = make(map[string]*model.Rating)
+		s.ratings[rating.MovieID] = byRater
+	}
+
+	now := s.timestamp()
+	if existing, ok := byRater[rating.RaterID]; ok {
+		existing.Value = rating.Value
+		existing.UpdatedAt = now
+		return false, nil
+	}
+
+	byRater[rating.RaterID] = &model.Rating{
+		MovieID:   rating.MovieID,
+		RaterID:   rating.RaterID,
+		Value:     rating.Value,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	return true, nil
+}
+
+func (r *MemoryRatingRepository) Get(ctx context.Context, movieID, raterID string) (*model.Rating, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rating, ok := s.ratings[movieID][raterID]
+	if !ok {
+		return nil, ErrRatingNotFound
+	}
+	copied := *rating
+	return &copied, nil
+}
+
+func (r *MemoryRatingRepository) Delete(ctx context.Context, movieID, raterID string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.ratings[movieID][raterID]; !ok {
+		return ErrRatingNotFound
+	}
+	s.touchRatings(movieID)
+	delete(s.ratings[movieID], raterID)
+	return nil
+}
+
+func (r *MemoryRatingRepository) List(ctx context.Context, params RatingListParams) ([]*model.Rating, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ratings []*model.Rating
+	for _, rating := range s.ratings[params.MovieID] {
+		if params.After != nil && !afterRatingCursor(rating, params.After) {
+			continue
+		}
+		copied := *rating
+		ratings = append(ratings, &copied)
+	}
+
+	sort.Slice(ratings, func(i, j int) bool {
+		if !ratings[i].CreatedAt.Equal(ratings[j].CreatedAt) {
+			return ratings[i].CreatedAt.Before(ratings[j].CreatedAt)
+		}
+		return ratings[i].RaterID < ratings[j].RaterID
+	})
+	if len(ratings) > params.Limit {
+		ratings = ratings[:max(params.Limit, 0)]
+	}
+	return ratings, nil
+}
+
+func (r *MemoryRatingRepository) AggregateByMovieID(ctx context.Context, movieID string) (float64, int, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sum, count := s.ratingTotals(movieID)
+	if count == 0 {
+		return 0, 0, nil
+	}
+	return sum / float64(count), count, nil
+}
+
+func (r *MemoryRatingRepository) HistogramByMovieID(ctx context.Context, movieID string) (model.RatingHistogram, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var histogram model.RatingHistogram
+	for _, rating := range s.ratings[movieID] {
+		if bucket := model.RatingBucket(rating.Value); bucket >= 0 && bucket < len(histogram) {
+			histogram[bucket]++
+		}
+	}
+	return histogram, nil
+}
+
+// TopRated ranks like the Postgres query: ratings of soft-deleted movies
+// still count towards the global mean, but only live movies are ranked.
+func (r *MemoryRatingRepository) TopRated(ctx context.Context, params TopRatedParams) ([]*model.RankedMovie, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	match, err := movieFilterMatcher(params.MovieFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		totalSum   float64
+		totalCount int
+		ranked     []*model.RankedMovie
+	)
+	for movieID, stored := range s.movies {
+		sum, count := s.ratingTotals(movieID)
+		totalSum += sum
+		totalCount += count
+		if count == 0 || stored.deletedAt != nil || !match(stored.movie) {
+			continue
+		}
+		ranked = append(ranked, &model.RankedMovie{
+			Movie:   stored.movie,
+			Average: sum / float64(count),
+			Count:   count,
+		})
+	}
+
+	var prior float64
+	switch {
+	case params.PriorMean != nil:
+		prior = *params.PriorMean
+	case totalCount > 0:
+		prior = totalSum / float64(totalCount)
+	}
+
+	minVotes := float64(params.MinVotes)
+	for _, entry := range ranked {
+		votes := float64(entry.Count)
+		entry.Score = (votes*entry.Average + minVotes*prior) / (votes + minVotes)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Movie.ID < b.Movie.ID
+	})
+	if len(ranked) > params.Limit {
+		ranked = ranked[:max(params.Limit, 0)]
+	}
+
+	for _, entry := range ranked {
+		entry.Movie = cloneMovie(entry.Movie)
+	}
+	return ranked, nil
+}
+
+// ratingTotals sums a movie's ratings. Callers hold the lock.
+func (s *MemoryStore) ratingTotals(movieID string) (float64, int) {
+	var sum float64
+	for _, rating := range s.ratings[movieID] {
+		sum += rating.Value
+	}
+	return sum, len(s.ratings[movieID])
+}
+
+func afterRatingCursor(rating *model.Rating, cursor *RatingCursor) bool {
+	return rating.CreatedAt.After(cursor.CreatedAt) ||
+		(rating.CreatedAt.Equal(cursor.CreatedAt) && rating.RaterID > cursor.RaterID)
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"cinema/model"
+	"sync"
+	"time"
+)
+
+// MemoryStore holds the data behind the in-memory repositories, playing the
+// part of the database: repositories created from the same store see each
+// other's writes, and deleting a movie takes its ratings and box office
+// history with it. It is safe for concurrent use.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	now       func() time.Time
+	last      time.Time
+	movies    map[string]*memoryMovie
+	ratings   map[string]map[string]*model.Rating
+	snapshots map[string][]model.BoxOfficeSnapshot
+	tokens    map[string]*memoryToken
+	// undo is set on the view a unit of work writes through.
+	undo *undoLog
+}
+
+type memoryMovie struct {
+	movie     *model.Movie
+	deletedAt *time.Time
+}
+
+type memoryToken struct {
+	token *model.APIToken
+	hash  string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:       time.Now,
+		movies:    make(map[string]*memoryMovie),
+		ratings:   make(map[string]map[string]*model.Rating),
+		snapshots: make(map[string][]model.BoxOfficeSnapshot),
+		tokens:    make(map[string]*memoryToken),
+	}
+}
+
+// timestamp returns the time for a write at the precision Postgres stores.
+// Unlike NOW() it never repeats, so that every write moves updated_at.
+// Callers hold the write lock.
+func (s *MemoryStore) timestamp() time.Time {
+	now := s.now().UTC().Truncate(time.Microsecond)
+	if !now.After(s.last) {
+		now = s.last.Add(time.Microsecond)
+	}
+	s.last = now
+	return now
+}
+
+// undoLog holds the state of every row a unit of work has touched as it was
+// before the first write, nil for rows that did not exist, so that a failed
+// unit of work can put it back. Only touched rows are copied.
+type undoLog struct {
+	movies    map[string]*memoryMovie
+	ratings   map[string]map[string]*model.Rating
+	snapshots map[string][]model.BoxOfficeSnapshot
+}
+
+// view returns a store that shares s's data but logs the before-image of
+// every row written through it. Callers hold the write lock until they have
+// either kept the view's writes or rolled them back.
+func (s *MemoryStore) view() *MemoryStore {
+	return &MemoryStore{
+		now:       s.now,
+		last:      s.last,
+		movies:    s.movies,
+		ratings:   s.ratings,
+		snapshots: s.snapshots,
+		tokens:    s.tokens,
+		undo: &undoLog{
+			movies:    make(map[string]*memoryMovie),
+			ratings:   make(map[string]map[string]*model.Rating),
+			snapshots: make(map[string][]model.BoxOfficeSnapshot),
+		},
+	}
+}
+
+// rollback restores every row touched through the view. Callers hold the
+// write lock.
+func (s *MemoryStore) rollback() {
+	for id, before := range s.undo.movies {
+		if before == nil {
+			delete(s.movies, id)
+		} else {
+			s.movies[id] = before
+		}
+	}
+	for movieID, before := range s.undo.ratings {
+		if before == nil {
+			delete(s.ratings, movieID)
+		} else {
+			s.ratings[movieID] = before
+		}
+	}
+	for movieID, before := range s.undo.snapshots {
+		if before == nil {
+			delete(s.snapshots, movieID)
+		} else {
+			s.snapshots[movieID] = before
+		}
+	}
+}
+
+// touchMovie, touchRatings and touchSnapshots record a row's before-image
+// ahead of its first write in a unit of work. Callers hold the write lock.
+func (s *MemoryStore) touchMovie(id string) {
+	if s.undo == nil {
+		return
+	}
+	if _, seen := s.undo.movies[id]; seen {
+		return
+	}
+	var before *memoryMovie
+	if stored, ok := s.movies[id]; ok {
+		before = &memoryMovie{movie: cloneMovie(stored.movie)}
+		if stored.deletedAt != nil {
+			deletedAt := *stored.deletedAt
+			before.deletedAt = &deletedAt
+		}
+	}
+	s.undo.movies[id] = before
+}
+
+func (s *MemoryStore) touchRatings(movieID string) {
+	if s.undo == nil {
+		return
+	}
+	if _, seen := s.undo.ratings[movieID]; seen {
+		return
+	}
+	var before map[string]*model.Rating
+	if byRater, ok := s.ratings[movieID]; ok {
+		before = make(map[string]*model.Rating, len(byRater))
+		for raterID, rating := range byRater {
+			value := *rating
+			before[raterID] = &value
+		}
+	}
+	s.undo.ratings[movieID] = before
+}
+
+func (s *MemoryStore) touchSnapshots(movieID string) {
+	if s.undo == nil {
+		return
+	}
+	if _, seen := s.undo.snapshots[movieID]; seen {
+		return
+	}
+	var before []model.BoxOfficeSnapshot
+	if snapshots, ok := s.snapshots[movieID]; ok {
+		before = append(make([]model.BoxOfficeSnapshot, 0, len(snapshots)), snapshots...)
+	}
+	s.undo.snapshots[movieID] = before
+}
+
+// appendSnapshot mirrors its Postgres namesake. Callers hold the write lock.
+func (s *MemoryStore) appendSnapshot(movieID string, boxOffice *model.BoxOffice, recordedAt time.Time) {
+	snapshot := model.BoxOfficeSnapshot{
+		BoxOffice: model.BoxOffice{
+			Revenue: model.BoxOfficeRevenue{
+				Worldwide:        boxOffice.Revenue.Worldwide,
+				OpeningWeekendUS: cloneInt(boxOffice.Revenue.OpeningWeekendUS),
+			},
+			Currency:    boxOffice.Currency,
+			Source:      boxOffice.Source,
+			LastUpdated: boxOffice.LastUpdated,
+		},
+		RecordedAt: recordedAt,
+	}
+	s.touchSnapshots(movieID)
+	s.snapshots[movieID] = append(s.snapshots[movieID], snapshot)
+}
+
+// cloneMovie deep-copies a movie so that callers never share memory with the
+// store. Conflicts are not stored, as with the Postgres repository.
+func cloneMovie(movie *model.Movie) *model.Movie {
+	copied := *movie
+	copied.Distributor = cloneString(movie.Distributor)
+	copied.Budget = cloneInt(movie.Budget)
+	copied.BudgetUSD = cloneInt(movie.BudgetUSD)
+	copied.MpaRating = cloneString(movie.MpaRating)
+	copied.Conflicts = nil
+	if movie.BoxOffice != nil {
+		boxOffice := *movie.BoxOffice
+		boxOffice.Revenue.OpeningWeekendUS = cloneInt(movie.BoxOffice.Revenue.OpeningWeekendUS)
+		boxOffice.Revenue.WorldwideUSD = cloneInt(movie.BoxOffice.Revenue.WorldwideUSD)
+		if movie.BoxOffice.MatchConfidence != nil {
+			confidence := *movie.BoxOffice.MatchConfidence
+			boxOffice.MatchConfidence = &confidence
+		}
+		copied.BoxOffice = &boxOffice
+	}
+	if movie.Sources != nil {
+		copied.Sources = make(map[string]string, len(movie.Sources))
+		for field, provider := range movie.Sources {
+			copied.Sources[field] = provider
+		}
+	}
+	return &copied
+}
+
+func cloneString(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
+func cloneInt(value *int64) *int64 {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
@@ -10,6 +10,7 @@ import (
 var (
 	ErrMovieNotFound      = errors.New("movie not found")
 	ErrMovieAlreadyExists = errors.New("movie already exists")
+	ErrMovieConflict      = errors.New("movie was modified concurrently")
 )
 
 type MovieCursor struct {
@@ -17,20 +18,46 @@ type MovieCursor struct {
 	ID        string
 }
 
-type MovieListParams struct {
+// MovieFilter holds the search filters shared by every movie listing.
+type MovieFilter struct {
 	Q           string
 	Year        *int
 	Genre       *string
 	Distributor *string
 	BudgetLTE   *int64
-	MpaRating   *string
-	Limit       int
-	After       *MovieCursor
+	// RevenueGTE bounds worldwide box office revenue from below. Like
+	// BudgetLTE it compares amounts normalised to US dollars.
+	RevenueGTE *int64
+	MpaRating  *string
+}
+
+type MovieListParams struct {
+	MovieFilter
+	Limit int
+	After *MovieCursor
 }
 
 type MovieRepository interface {
+	// Create inserts a movie and replaces it with the row as stored, so no
+	// second lookup is needed. Movies created with model.EnrichmentPending are
+	// queued for enrichment atomically.
 	Create(ctx context.Context, movie *model.Movie) error
-	UpdateSupplemental(ctx context.Context, movieID string, distributor *string, budget *int64, mpaRating *string, boxOffice *model.BoxOffice) error
+	// Update overwrites the editable attributes of a movie, provided it is
+	// still at expectedVersion; otherwise it fails with ErrMovieConflict. On
+	// success movie.Version and movie.UpdatedAt are refreshed.
+	Update(ctx context.Context, movie *model.Movie, expectedVersion int64) error
+	// UpdateBoxOffice stores the supplemental attributes, box office data and
+	// enrichment status of a movie under the same version check as Update.
+	UpdateBoxOffice(ctx context.Context, movie *model.Movie, expectedVersion int64) error
 	GetByTitle(ctx context.Context, title string) (*model.Movie, error)
+	// GetByTitleIncludingDeleted falls back to the most recently soft-deleted
+	// movie with the title when no live movie holds it, so that soft-deleted
+	// movies can still be purged.
+	GetByTitleIncludingDeleted(ctx context.Context, title string) (*model.Movie, error)
+	// SoftDelete and Delete remove a movie provided it is still at
+	// expectedVersion. Delete also purges soft-deleted movies.
+	SoftDelete(ctx context.Context, movieID string, expectedVersion int64) error
+	Delete(ctx context.Context, movieID string, expectedVersion int64) error
+	Restore(ctx context.Context, title string) (*model.Movie, error)
 	List(ctx context.Context, params MovieListParams) ([]*model.Movie, error)
 }
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+type PostgresAPITokenRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAPITokenRepository(db *sql.DB) *PostgresAPITokenRepository {
+	return &PostgresAPITokenRepository{db: db}
+}
+
+const apiTokenColumns = "id, name, scopes, expires_at, revoked_at, created_at"
+
+func (r *PostgresAPITokenRepository) Create(ctx context.Context, token *model.APIToken, hash []byte) error {
+	const query = `
+        INSERT INTO api_tokens (id, name, token_hash, scopes, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING created_at
+    `
+
+	var expiresAt interface{}
+	if token.ExpiresAt != nil {
+		expiresAt = *token.ExpiresAt
+	}
+
+	return r.db.QueryRowContext(ctx, query, token.ID, token.Name, hash, strings.Join(token.Scopes, " "), expiresAt).Scan(&token.CreatedAt)
+}
+
+func (r *PostgresAPITokenRepository) GetByHash(ctx context.Context, hash []byte) (*model.APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens WHERE token_hash = $1`
+
+	token, err := scanAPIToken(r.db.QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *PostgresAPITokenRepository) List(ctx context.Context) ([]*model.APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens ORDER BY created_at ASC, id ASC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*model.APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// Revoke is idempotent: revoking an already revoked token keeps the original
+// revocation time.
+func (r *PostgresAPITokenRepository) Revoke(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE api_tokens SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+func scanAPIToken(row rowScanner) (*model.APIToken, error) {
+	var (
+		token     model.APIToken
+		scopes    string
+		expiresAt sql.NullTime
+		revokedAt sql.NullTime
+	)
+
+	if err := row.Scan(&token.ID, &token.Name, &scopes, &expiresAt, &revokedAt, &token.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Fields(scopes)
+	if expiresAt.Valid {
+		token.ExpiresAt = timePtr(expiresAt.Time)
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = timePtr(revokedAt.Time)
+	}
+	return &token, nil
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"cinema/boxoffice"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+// PostgresBoxOfficeCacheStore persists box office lookups so that the cache
+// survives restarts and is shared between instances.
+type PostgresBoxOfficeCacheStore struct {
+	db *sql.DB
+}
+
+func NewPostgresBoxOfficeCacheStore(db *sql.DB) *PostgresBoxOfficeCacheStore {
+	return &PostgresBoxOfficeCacheStore{db: db}
+}
+
+func (s *PostgresBoxOfficeCacheStore) Get(ctx context.Context, key string) (*boxoffice.CacheEntry, error) {
+	const query = `
+        SELECT record, expires_at
+        FROM boxoffice_cache
+        WHERE title_key = $1 AND expires_at > NOW()
+    `
+
+	var (
+		raw   []byte
+		entry boxoffice.CacheEntry
+	)
+	if err := s.db.QueryRowContext(ctx, query, key).Scan(&raw, &entry.ExpiresAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if raw != nil {
+		var record boxoffice.Record
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, err
+		}
+		entry.Record = &record
+	}
+	return &entry, nil
+}
+
+func (s *PostgresBoxOfficeCacheStore) Put(ctx context.Context, key string, entry boxoffice.CacheEntry) error {
+	const query = `
+        INSERT INTO boxoffice_cache (title_key, record, expires_at, fetched_at)
+        VALUES ($1, $2, $3, NOW())
+        ON CONFLICT (title_key) DO UPDATE
+        SET record = EXCLUDED.record, expires_at = EXCLUDED.expires_at, fetched_at = EXCLUDED.fetched_at
+    `
+
+	var record interface{}
+	if entry.Record != nil {
+		raw, err := json.Marshal(entry.Record)
+		if err != nil {
+			return err
+		}
+		record = raw
+	}
+
+	_, err := s.db.ExecContext(ctx, query, key, record, entry.ExpiresAt)
+	return err
+}
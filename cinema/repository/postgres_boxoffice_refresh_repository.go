@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type PostgresBoxOfficeRefreshRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresBoxOfficeRefreshRepository(db *sql.DB) *PostgresBoxOfficeRefreshRepository {
+	return &PostgresBoxOfficeRefreshRepository{db: db}
+}
+
+const refreshRunColumns = "id, stale_before, cursor_created_at, cursor_id, refreshed, not_found, failed, started_at, finished_at, claims"
+
+func (r *PostgresBoxOfficeRefreshRepository) ClaimRun(ctx context.Context, staleBefore time.Time, lease time.Duration) (*model.BoxOfficeRefreshRun, error) {
+	resume := `
+        UPDATE boxoffice_refresh_runs
+        SET lease_expires_at = NOW() + $1 * INTERVAL '1 millisecond',
+            claims = claims + 1
+        WHERE finished_at IS NULL AND lease_expires_at < NOW()
+        RETURNING ` + refreshRunColumns
+
+	run, err := scanRefreshRun(r.db.QueryRowContext(ctx, resume, lease.Milliseconds()))
+	if err == nil {
+		return run, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// Either nothing is running or another process holds the lease; the
+	// partial unique index lets only one unfinished run exist.
+	start := `
+        INSERT INTO boxoffice_refresh_runs (stale_before, lease_expires_at)
+        VALUES ($1, NOW() + $2 * INTERVAL '1 millisecond')
+        RETURNING ` + refreshRunColumns
+
+	run, err = scanRefreshRun(r.db.QueryRowContext(ctx, start, staleBefore, lease.Milliseconds()))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrRefreshRunInProgress
+		}
+		return nil, err
+	}
+	return run, nil
+}
+
+func (r *PostgresBoxOfficeRefreshRepository) ListStale(ctx context.Context, run *model.BoxOfficeRefreshRun, limit int) ([]*model.Movie, error) {
+	query := `
+        SELECT ` + movieColumns + `
+        FROM movies
+        WHERE deleted_at IS NULL
+          AND (boxoffice_checked_at IS NULL OR boxoffice_checked_at < $1)
+          AND ($2::timestamptz IS NULL OR created_at > $2 OR (created_at = $2 AND id > $3))
+        ORDER BY created_at ASC, id ASC
+        LIMIT $4
+    `
+
+	var cursorCreatedAt, cursorID interface{}
+	if run.CursorCreatedAt != nil {
+		cursorCreatedAt = *run.CursorCreatedAt
+		cursorID = run.CursorID
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, run.StaleBefore, cursorCreatedAt, cursorID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movies []*model.Movie
+	for rows.Next() {
+		movie, err := scanMovie(rows)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, movie)
+	}
+
+	return movies, rows.Err()
+}
+
+func (r *PostgresBoxOfficeRefreshRepository) ApplyEnrichment(ctx context.Context, movieID string, expectedVersion int64, enrichment model.Enrichment) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := applyEnrichment(ctx, tx, movieID, expectedVersion, enrichment); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresBoxOfficeRefreshRepository) SaveProgress(ctx context.Context, run *model.BoxOfficeRefreshRun, lease time.Duration) error {
+	const query = `
+        UPDATE boxoffice_refresh_runs
+        SET cursor_created_at = $2,
+            cursor_id = $3,
+            refreshed = $4,
+            not_found = $5,
+            failed = $6,
+            lease_expires_at = NOW() + $7 * INTERVAL '1 millisecond'
+        WHERE id = $1 AND claims = $8 AND finished_at IS NULL
+    `
+
+	var cursorCreatedAt, cursorID interface{}
+	if run.CursorCreatedAt != nil {
+		cursorCreatedAt = *run.CursorCreatedAt
+		cursorID = run.CursorID
+	}
+
+	res, err := r.db.ExecContext(ctx, query, run.ID, cursorCreatedAt, cursorID, run.Refreshed, run.NotFound, run.Failed, lease.Milliseconds(), run.Claims)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrRefreshRunLost
+	}
+	return nil
+}
+
+func (r *PostgresBoxOfficeRefreshRepository) FinishRun(ctx context.Context, run *model.BoxOfficeRefreshRun) error {
+	err := r.db.QueryRowContext(ctx, `
+        UPDATE boxoffice_refresh_runs
+        SET finished_at = NOW()
+        WHERE id = $1 AND claims = $2 AND finished_at IS NULL
+        RETURNING finished_at
+    `, run.ID, run.Claims).Scan(&run.FinishedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRefreshRunLost
+	}
+	return err
+}
+
+func scanRefreshRun(row rowScanner) (*model.BoxOfficeRefreshRun, error) {
+	var (
+		run             model.BoxOfficeRefreshRun
+		cursorCreatedAt sql.NullTime
+		cursorID        sql.NullString
+		finishedAt      sql.NullTime
+	)
+
+	if err := row.Scan(&run.ID, &run.StaleBefore, &cursorCreatedAt, &cursorID, &run.Refreshed, &run.NotFound, &run.Failed, &run.StartedAt, &finishedAt, &run.Claims); err != nil {
+		return nil, err
+	}
+
+	if cursorCreatedAt.Valid {
+		run.CursorCreatedAt = timePtr(cursorCreatedAt.Time)
+		run.CursorID = cursorID.String
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = timePtr(finishedAt.Time)
+	}
+	return &run, nil
+}
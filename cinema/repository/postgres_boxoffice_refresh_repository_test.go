@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPostgresRefreshRunFencesOutALostClaim(t *testing.T) {
+	sqlDB := openTestPostgres(t)
+	ctx := context.Background()
+	if _, err := sqlDB.ExecContext(ctx, `DELETE FROM boxoffice_refresh_runs`); err != nil {
+		t.Fatalf("clear runs: %v", err)
+	}
+	runs := NewPostgresBoxOfficeRefreshRepository(sqlDB)
+
+	first, err := runs.ClaimRun(ctx, time.Now(), time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimRun returned error: %v", err)
+	}
+	if _, err := runs.ClaimRun(ctx, time.Now(), time.Minute); !errors.Is(err, ErrRefreshRunInProgress) {
+		t.Fatalf("expected the leased run to be in progress, got %v", err)
+	}
+
+	// Once the lease expires, another scheduler resumes the run.
+	if _, err := sqlDB.ExecContext(ctx, `UPDATE boxoffice_refresh_runs SET lease_expires_at = NOW() - INTERVAL '1 second'`); err != nil {
+		t.Fatalf("expire lease: %v", err)
+	}
+	second, err := runs.ClaimRun(ctx, time.Now(), time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimRun returned error: %v", err)
+	}
+	if second.ID != first.ID || second.Claims != first.Claims+1 {
+		t.Fatalf("expected run %d to be claimed again, got run %d with %d claims", first.ID, second.ID, second.Claims)
+	}
+
+	// The first scheduler can neither save its progress nor finish the run.
+	first.Refreshed = 99
+	if err := runs.SaveProgress(ctx, first, time.Minute); !errors.Is(err, ErrRefreshRunLost) {
+		t.Fatalf("expected SaveProgress to report the lost run, got %v", err)
+	}
+	if err := runs.FinishRun(ctx, first); !errors.Is(err, ErrRefreshRunLost) {
+		t.Fatalf("expected FinishRun to report the lost run, got %v", err)
+	}
+
+	second.Refreshed = 3
+	if err := runs.SaveProgress(ctx, second, time.Minute); err != nil {
+		t.Fatalf("SaveProgress returned error: %v", err)
+	}
+	if err := runs.FinishRun(ctx, second); err != nil {
+		t.Fatalf("FinishRun returned error: %v", err)
+	}
+	if err := runs.FinishRun(ctx, second); !errors.Is(err, ErrRefreshRunLost) {
+		t.Fatalf("expected a finished run to stay finished, got %v", err)
+	}
+
+	var refreshed int
+	if err := sqlDB.QueryRowContext(ctx, `SELECT refreshed FROM boxoffice_refresh_runs WHERE id = $1`, first.ID).Scan(&refreshed); err != nil || refreshed != 3 {
+		t.Fatalf("expected the progress of the scheduler holding the lease, got %d (%v)", refreshed, err)
+	}
+}
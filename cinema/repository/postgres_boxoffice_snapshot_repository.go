@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PostgresBoxOfficeSnapshotRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresBoxOfficeSnapshotRepository(db *sql.DB) *PostgresBoxOfficeSnapshotRepository {
+	return &PostgresBoxOfficeSnapshotRepository{db: db}
+}
+
+func (r *PostgresBoxOfficeSnapshotRepository) ListSnapshots(ctx context.Context, movieID string, from, to *time.Time) ([]model.BoxOfficeSnapshot, error) {
+	clauses := []string{"movie_id = $1"}
+	args := []interface{}{movieID}
+	if from != nil {
+		args = append(args, *from)
+		clauses = append(clauses, "recorded_at >= $"+strconv.Itoa(len(args)))
+	}
+	if to != nil {
+		args = append(args, *to)
+		clauses = append(clauses, "recorded_at < $"+strconv.Itoa(len(args)))
+	}
+
+	query := `
+        SELECT worldwide, opening_weekend_usa, currency, source, provider_updated_at, recorded_at
+        FROM box_office_snapshots
+        WHERE ` + strings.Join(clauses, " AND ") + `
+        ORDER BY recorded_at, id
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := make([]model.BoxOfficeSnapshot, 0)
+	for rows.Next() {
+		var (
+			snapshot          model.BoxOfficeSnapshot
+			openingWeekend    sql.NullInt64
+			providerUpdatedAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&snapshot.Revenue.Worldwide,
+			&openingWeekend,
+			&snapshot.Currency,
+			&snapshot.Source,
+			&providerUpdatedAt,
+			&snapshot.RecordedAt,
+		); err != nil {
+			return nil, err
+		}
+		if openingWeekend.Valid {
+			value := openingWeekend.Int64
+			snapshot.Revenue.OpeningWeekendUS = &value
+		}
+		if providerUpdatedAt.Valid {
+			snapshot.LastUpdated = providerUpdatedAt.Time
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// appendSnapshot records box office data returned by a successful lookup.
+// Callers run it in the transaction that stores the same data on the movie.
+func appendSnapshot(ctx context.Context, db execer, movieID string, boxOffice *model.BoxOffice) error {
+	const query = `
+        INSERT INTO box_office_snapshots (movie_id, worldwide, opening_weekend_usa, currency, source, provider_updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `
+
+	var providerUpdatedAt interface{}
+	if !boxOffice.LastUpdated.IsZero() {
+		providerUpdatedAt = boxOffice.LastUpdated
+	}
+
+	_, err := db.ExecContext(
+		ctx,
+		query,
+		movieID,
+		boxOffice.Revenue.Worldwide,
+		nullableInt(boxOffice.Revenue.OpeningWeekendUS),
+		boxOffice.Currency,
+		boxOffice.Source,
+		providerUpdatedAt,
+	)
+	return err
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type PostgresDataConflictRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresDataConflictRepository(db *sql.DB) *PostgresDataConflictRepository {
+	return &PostgresDataConflictRepository{db: db}
+}
+
+const dataConflictColumns = "c.id, c.movie_id, m.title, c.field, c.our_value, c.their_value, c.source, c.status, c.created_at, c.resolved_at"
+
+func (r *PostgresDataConflictRepository) List(ctx context.Context, params DataConflictListParams) ([]*model.DataConflict, error) {
+	clauses := []string{"c.status = $1"}
+	args := []interface{}{params.Status}
+
+	if params.MovieID != "" {
+		args = append(args, params.MovieID)
+		clauses = append(clauses, fmt.Sprintf("c.movie_id = $%d", len(args)))
+	}
+	if params.After != nil {
+		args = append(args, params.After.CreatedAt, params.After.ID)
+		clauses = append(clauses, fmt.Sprintf("(c.created_at, c.id) > ($%d, $%d)", len(args)-1, len(args)))
+	}
+	args = append(args, params.Limit)
+
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM data_conflicts c
+        JOIN movies m ON m.id = c.movie_id
+        WHERE %s
+        ORDER BY c.created_at ASC, c.id ASC
+        LIMIT $%d
+    `, dataConflictColumns, strings.Join(clauses, " AND "), len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []*model.DataConflict
+	for rows.Next() {
+		conflict, err := scanDataConflict(rows)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts, rows.Err()
+}
+
+// conflictAssignments writes a provider value, passed as $2, to the movie
+// column behind each conflicting attribute. Budgets are stored in USD, so the
+// normalised amount is the same value.
+var conflictAssignments = map[string]string{
+	model.ConflictReleaseDate: "release_date = $2::date",
+	model.ConflictDistributor: "distributor = $2",
+	model.ConflictBudget:      "budget = $2::bigint, budget_usd = $2::bigint",
+	model.ConflictMpaRating:   "mpa_rating = $2",
+}
+
+// conflictValues renders the movie column behind each conflicting attribute
+// the way DataConflict.Ours records it.
+var conflictValues = map[string]string{
+	model.ConflictReleaseDate: "to_char(release_date, 'YYYY-MM-DD')",
+	model.ConflictDistributor: "distributor",
+	model.ConflictBudget:      "budget_usd::text",
+	model.ConflictMpaRating:   "mpa_rating",
+}
+
+func (r *PostgresDataConflictRepository) Resolve(ctx context.Context, id int64, status string) (*model.DataConflict, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	conflict, err := scanDataConflict(tx.QueryRowContext(ctx, `
+        SELECT `+dataConflictColumns+`
+        FROM data_conflicts c
+        JOIN movies m ON m.id = c.movie_id
+        WHERE c.id = $1
+        FOR UPDATE OF c
+    `, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrConflictNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if conflict.Status != model.ConflictOpen {
+		return nil, ErrConflictResolved
+	}
+
+	if status == model.ConflictAcceptedTheirs {
+		assignment, ok := conflictAssignments[conflict.Field]
+		if !ok {
+			return nil, fmt.Errorf("cannot apply conflicting field %q", conflict.Field)
+		}
+		// The provider becomes the source of the value; without a known
+		// provider the previous provenance no longer applies. The value is
+		// only replaced if it is still the one the conflict was raised on.
+		res, err := tx.ExecContext(ctx, `
+            UPDATE movies
+            SET `+assignment+`,
+                sources = CASE WHEN $4 = '' THEN sources - $3::text ELSE sources || jsonb_build_object($3::text, $4::text) END,
+                version = version + 1,
+                updated_at = NOW()
+            WHERE id = $1 AND deleted_at IS NULL AND `+conflictValues[conflict.Field]+` = $5
+        `, conflict.MovieID, conflict.Theirs, conflict.Field, conflict.Source, conflict.Ours)
+		if err != nil {
+			return nil, err
+		}
+		err = requireAffected(ctx, tx, res, conflict.MovieID)
+		if errors.Is(err, ErrMovieConflict) {
+			if _, err := tx.ExecContext(ctx, `UPDATE data_conflicts SET status = $2, resolved_at = NOW() WHERE id = $1`, id, model.ConflictStale); err != nil {
+				return nil, err
+			}
+			if err := tx.Commit(); err != nil {
+				return nil, err
+			}
+			return nil, ErrConflictStale
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var resolvedAt time.Time
+	err = tx.QueryRowContext(ctx, `
+        UPDATE data_conflicts
+        SET status = $2, resolved_at = NOW()
+        WHERE id = $1
+        RETURNING resolved_at
+    `, id, status).Scan(&resolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	conflict.Status = status
+	conflict.ResolvedAt = &resolvedAt
+	return conflict, tx.Commit()
+}
+
+// recordConflicts stores conflicts found by a box office lookup, refreshing
+// the open conflict on the same attribute if there is one. A disagreement an
+// admin has already settled in favour of our value is not raised again.
+// Callers run it in the transaction that stores the lookup.
+func recordConflicts(ctx context.Context, db execer, conflicts []model.DataConflict) error {
+	const query = `
+        INSERT INTO data_conflicts (movie_id, field, our_value, their_value, source)
+        SELECT $1, $2, $3, $4, $5
+        WHERE NOT EXISTS (
+            SELECT 1 FROM data_conflicts
+            WHERE movie_id = $1 AND field = $2 AND our_value = $3 AND their_value = $4 AND status = 'accepted_ours'
+        )
+        ON CONFLICT (movie_id, field) WHERE status = 'open'
+        DO UPDATE SET our_value = EXCLUDED.our_value, their_value = EXCLUDED.their_value, source = EXCLUDED.source
+    `
+
+	for _, conflict := range conflicts {
+		if _, err := db.ExecContext(ctx, query, conflict.MovieID, conflict.Field, conflict.Ours, conflict.Theirs, conflict.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanDataConflict(row rowScanner) (*model.DataConflict, error) {
+	var (
+		conflict   model.DataConflict
+		resolvedAt sql.NullTime
+	)
+	if err := row.Scan(&conflict.ID, &conflict.MovieID, &conflict.MovieTitle, &conflict.Field, &conflict.Ours, &conflict.Theirs, &conflict.Source, &conflict.Status, &conflict.CreatedAt, &resolvedAt); err != nil {
+		return nil, err
+	}
+	if resolvedAt.Valid {
+		conflict.ResolvedAt = timePtr(resolvedAt.Time)
+	}
+	return &conflict, nil
+}
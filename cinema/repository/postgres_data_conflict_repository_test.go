@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPostgresAcceptingTheirsRequiresOurValue(t *testing.T) {
+	sqlDB := openTestPostgres(t)
+	truncateMovies(t, sqlDB)
+	ctx := context.Background()
+	movies, conflicts := NewPostgresMovieRepository(sqlDB), NewPostgresDataConflictRepository(sqlDB)
+
+	ours, edited := "Warner Bros.", "Regency"
+	heat := createMovie(t, movies, model.Movie{Title: "Heat", Distributor: &ours})
+	ronin := createMovie(t, movies, model.Movie{Title: "Ronin", Distributor: &ours})
+	for _, movie := range []*model.Movie{heat, ronin} {
+		if err := recordConflicts(ctx, sqlDB, []model.DataConflict{{MovieID: movie.ID, Field: model.ConflictDistributor, Ours: ours, Theirs: "MGM", Source: "tmdb"}}); err != nil {
+			t.Fatalf("recordConflicts returned error: %v", err)
+		}
+	}
+	open, err := conflicts.List(ctx, DataConflictListParams{Status: model.ConflictOpen, Limit: 10})
+	if err != nil || len(open) != 2 {
+		t.Fatalf("expected 2 open conflicts, got %d (%v)", len(open), err)
+	}
+
+	// Heat's distributor is edited after the conflict was raised.
+	if _, err := sqlDB.ExecContext(ctx, `UPDATE movies SET distributor = $2 WHERE id = $1`, heat.ID, edited); err != nil {
+		t.Fatalf("edit movie: %v", err)
+	}
+
+	for _, conflict := range open {
+		resolved, err := conflicts.Resolve(ctx, conflict.ID, model.ConflictAcceptedTheirs)
+		switch conflict.MovieID {
+		case heat.ID:
+			if !errors.Is(err, ErrConflictStale) {
+				t.Fatalf("expected the edited movie's conflict to go stale, got %v", err)
+			}
+		case ronin.ID:
+			if err != nil || resolved.Status != model.ConflictAcceptedTheirs {
+				t.Fatalf("expected the conflict to be resolved, got %+v (%v)", resolved, err)
+			}
+		}
+	}
+
+	for title, want := range map[string]string{"Heat": edited, "Ronin": "MGM"} {
+		movie, err := movies.GetByTitle(ctx, title)
+		if err != nil {
+			t.Fatalf("GetByTitle(%q) returned error: %v", title, err)
+		}
+		if movie.Distributor == nil || *movie.Distributor != want {
+			t.Fatalf("expected %q to be distributed by %q, got %v", title, want, movie.Distributor)
+		}
+	}
+	stale, err := conflicts.List(ctx, DataConflictListParams{Status: model.ConflictStale, Limit: 10})
+	if err != nil || len(stale) != 1 || stale[0].MovieID != heat.ID {
+		t.Fatalf("expected Heat's conflict to be stale, got %+v (%v)", stale, err)
+	}
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type PostgresEnrichmentJobRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresEnrichmentJobRepository(db *sql.DB) *PostgresEnrichmentJobRepository {
+	return &PostgresEnrichmentJobRepository{db: db}
+}
+
+// enrichmentJobLease is how long a claimed job is reserved for its worker.
+// handle is cut off after half of it, so the outcome is normally written
+// while the lease still holds.
+const enrichmentJobLease = 2 * time.Minute
+
+// ProcessNext leases the job in one short transaction, calls handle outside
+// any transaction and writes the outcome in a second one. A crashed worker's
+// job is claimed again once its lease expires. Claiming bumps attempts, which
+// fences the outcome: if the lease was taken over in the meantime, the stale
+// outcome is dropped.
+func (r *PostgresEnrichmentJobRepository) ProcessNext(ctx context.Context, handle func(context.Context, model.EnrichmentJob) EnrichmentOutcome) (bool, error) {
+	const claim = `
+        WITH next AS (
+            SELECT id
+            FROM enrichment_jobs
+            WHERE run_at <= NOW() AND (lease_expires_at IS NULL OR lease_expires_at <= NOW())
+            ORDER BY run_at, id
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        UPDATE enrichment_jobs j
+        SET attempts = j.attempts + 1,
+            lease_expires_at = NOW() + $1 * INTERVAL '1 millisecond'
+        FROM next, movies m
+        WHERE j.id = next.id AND m.id = j.movie_id
+        RETURNING j.id, j.movie_id, m.title, m.release_date, m.version, j.attempts
+    `
+
+	var (
+		job     model.EnrichmentJob
+		claimed int
+	)
+	err := r.db.QueryRowContext(ctx, claim, enrichmentJobLease.Milliseconds()).Scan(&job.ID, &job.MovieID, &job.Title, &job.ReleaseDate, &job.Version, &claimed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	job.Attempts = claimed - 1
+
+	handleCtx, cancel := context.WithTimeout(ctx, enrichmentJobLease/2)
+	outcome := handle(handleCtx, job)
+	cancel()
+
+	err = inTx(ctx, r.db, func(tx dbtx) error {
+		if outcome.RetryAt != nil {
+			_, err := tx.ExecContext(ctx, `
+                UPDATE enrichment_jobs
+                SET run_at = $3, last_error = $4, lease_expires_at = NULL
+                WHERE id = $1 AND attempts = $2
+            `, job.ID, claimed, *outcome.RetryAt, outcome.Error)
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM enrichment_jobs WHERE id = $1 AND attempts = $2`, job.ID, claimed)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+			// Another worker owns the job now; its outcome wins.
+			return err
+		}
+		return applyEnrichment(ctx, tx, job.MovieID, job.Version, outcome.Enrichment)
+	})
+	if errors.Is(err, ErrMovieConflict) {
+		// The movie was edited during the lookup; look it up again against
+		// its new version.
+		_, err = r.db.ExecContext(ctx, `
+            UPDATE enrichment_jobs
+            SET run_at = NOW(), last_error = $3, lease_expires_at = NULL
+            WHERE id = $1 AND attempts = $2
+        `, job.ID, claimed, "movie changed during the lookup")
+	}
+	return true, err
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+type queryExecer interface {
+	execer
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// applyEnrichment records the enrichment status and fills in attributes that
+// are still unset, so edits made while the lookup was running are kept; the
+// provider of each filled attribute is recorded in sources. Box office data
+// is replaced, and appended to the movie's history, when the lookup returned
+// any; every answer from the provider counts as a check for the refresh
+// scheduler. Attributes on which the provider disagrees with the movie are
+// recorded as conflicts. Like every other movie write it requires the movie
+// to still be at expectedVersion and bumps the version, failing with
+// ErrMovieConflict otherwise; a movie deleted in the meantime is left alone.
+// Callers run it in a transaction.
+func applyEnrichment(ctx context.Context, db dbtx, movieID string, expectedVersion int64, enrichment model.Enrichment) error {
+	const query = `
+        UPDATE movies
+        SET distributor = COALESCE(distributor, $2),
+            budget = COALESCE(budget, $3),
+            budget_usd = CASE WHEN budget IS NULL OR (budget_usd IS NULL AND budget = $3) THEN $8 ELSE budget_usd END,
+            mpa_rating = COALESCE(mpa_rating, $4),
+            box_office = COALESCE($5, box_office),
+            worldwide_usd = CASE WHEN $5::jsonb IS NOT NULL THEN $9 ELSE worldwide_usd END,
+            enrichment_status = $6,
+            sources = sources || jsonb_strip_nulls(jsonb_build_object(
+                'distributor', CASE WHEN distributor IS NULL THEN $7::jsonb->>'distributor' END,
+                'budget', CASE WHEN budget IS NULL THEN $7::jsonb->>'budget' END,
+                'mpaRating', CASE WHEN mpa_rating IS NULL THEN $7::jsonb->>'mpaRating' END,
+                'revenue', CASE WHEN $5::jsonb IS NOT NULL THEN $7::jsonb->>'revenue' END
+            )),
+            boxoffice_checked_at = CASE WHEN $6 = 'failed' THEN boxoffice_checked_at ELSE NOW() END,
+            version = version + 1,
+            updated_at = NOW()
+        WHERE id = $1 AND deleted_at IS NULL AND version = $10
+        RETURNING release_date, distributor, budget_usd, mpa_rating
+    `
+
+	boxOfficeJSON, err := marshalBoxOffice(enrichment.BoxOffice)
+	if err != nil {
+		return err
+	}
+	sourcesJSON, err := marshalSources(enrichment.Sources)
+	if err != nil {
+		return err
+	}
+
+	var (
+		movie       = model.Movie{ID: movieID}
+		distributor sql.NullString
+		budgetUSD   sql.NullInt64
+		mpaRating   sql.NullString
+	)
+	err = db.QueryRowContext(
+		ctx,
+		query,
+		movieID,
+		nullableString(enrichment.Distributor),
+		nullableInt(enrichment.Budget),
+		nullableString(enrichment.MpaRating),
+		boxOfficeJSON,
+		enrichment.Status,
+		sourcesJSON,
+		nullableInt(enrichment.BudgetUSD),
+		worldwideUSD(enrichment.BoxOffice),
+		expectedVersion,
+	).Scan(&movie.ReleaseDate, &distributor, &budgetUSD, &mpaRating)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := notFoundOrConflict(ctx, db, movieID); !errors.Is(err, ErrMovieNotFound) {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if distributor.Valid {
+		movie.Distributor = &distributor.String
+	}
+	if budgetUSD.Valid {
+		movie.BudgetUSD = &budgetUSD.Int64
+	}
+	if mpaRating.Valid {
+		movie.MpaRating = &mpaRating.String
+	}
+	if err := recordConflicts(ctx, db, enrichment.ConflictsWith(&movie)); err != nil {
+		return err
+	}
+
+	if enrichment.BoxOffice == nil {
+		return nil
+	}
+	return appendSnapshot(ctx, db, movieID, enrichment.BoxOffice)
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"testing"
+)
+
+func TestPostgresEnrichmentRequiresTheMovieVersion(t *testing.T) {
+	sqlDB := openTestPostgres(t)
+	truncateMovies(t, sqlDB)
+	ctx := context.Background()
+	movies, jobs := NewPostgresMovieRepository(sqlDB), NewPostgresEnrichmentJobRepository(sqlDB)
+	movie := createMovie(t, movies, model.Movie{Title: "Heat", EnrichmentStatus: model.EnrichmentPending})
+
+	distributor := "Warner Bros."
+	enrich := func(ctx context.Context, job model.EnrichmentJob) EnrichmentOutcome {
+		return EnrichmentOutcome{Enrichment: model.Enrichment{Status: model.EnrichmentComplete, Distributor: &distributor}}
+	}
+
+	// An edit during the lookup sends the job back to the queue.
+	processed, err := jobs.ProcessNext(ctx, func(ctx context.Context, job model.EnrichmentJob) EnrichmentOutcome {
+		edited := *movie
+		edited.Genre = "Crime"
+		if err := movies.Update(ctx, &edited, job.Version); err != nil {
+			t.Errorf("Update returned error: %v", err)
+		}
+		return enrich(ctx, job)
+	})
+	if err != nil || !processed {
+		t.Fatalf("expected the job to be processed, got %v (%v)", processed, err)
+	}
+	edited, err := movies.GetByTitle(ctx, "Heat")
+	if err != nil {
+		t.Fatalf("GetByTitle returned error: %v", err)
+	}
+	if edited.Distributor != nil || edited.EnrichmentStatus != model.EnrichmentPending || edited.Version != movie.Version+1 {
+		t.Fatalf("expected the stale enrichment to be dropped, got %+v", edited)
+	}
+
+	processed, err = jobs.ProcessNext(ctx, enrich)
+	if err != nil || !processed {
+		t.Fatalf("expected the job to be retried, got %v (%v)", processed, err)
+	}
+	enriched, err := movies.GetByTitle(ctx, "Heat")
+	if err != nil {
+		t.Fatalf("GetByTitle returned error: %v", err)
+	}
+	if enriched.Distributor == nil || *enriched.Distributor != distributor || enriched.Genre != "Crime" {
+		t.Fatalf("expected the enrichment to be applied on top of the edit, got %+v", enriched)
+	}
+	if enriched.Version != edited.Version+1 {
+		t.Fatalf("expected enrichment to bump the version to %d, got %d", edited.Version+1, enriched.Version)
+	}
+}
+
+func TestPostgresEnrichmentJobsAreLeased(t *testing.T) {
+	sqlDB := openTestPostgres(t)
+	truncateMovies(t, sqlDB)
+	ctx := context.Background()
+	movies, jobs := NewPostgresMovieRepository(sqlDB), NewPostgresEnrichmentJobRepository(sqlDB)
+	createMovie(t, movies, model.Movie{Title: "Heat", EnrichmentStatus: model.EnrichmentPending})
+
+	stale, fresh := "Stale Pictures", "Warner Bros."
+	processed, err := jobs.ProcessNext(ctx, func(ctx context.Context, job model.EnrichmentJob) EnrichmentOutcome {
+		if job.Attempts != 0 {
+			t.Errorf("expected the first claim to see 0 attempts, got %d", job.Attempts)
+		}
+		// While the lease holds, no other worker gets the job.
+		if again, err := jobs.ProcessNext(ctx, nil); again || err != nil {
+			t.Errorf("expected the leased job to be skipped, got %v (%v)", again, err)
+		}
+
+		// Once it expires, another worker takes the job over and finishes it.
+		if _, err := sqlDB.ExecContext(ctx, `UPDATE enrichment_jobs SET lease_expires_at = NOW() - INTERVAL '1 second'`); err != nil {
+			t.Errorf("expire lease: %v", err)
+		}
+		taken, err := jobs.ProcessNext(ctx, func(ctx context.Context, job model.EnrichmentJob) EnrichmentOutcome {
+			return EnrichmentOutcome{Enrichment: model.Enrichment{Status: model.EnrichmentComplete, Distributor: &fresh}}
+		})
+		if !taken || err != nil {
+			t.Errorf("expected the expired lease to be taken over, got %v (%v)", taken, err)
+		}
+		return EnrichmentOutcome{Enrichment: model.Enrichment{Status: model.EnrichmentComplete, Distributor: &stale}}
+	})
+	if err != nil || !processed {
+		t.Fatalf("expected the job to be processed, got %v (%v)", processed, err)
+	}
+
+	enriched, err := movies.GetByTitle(ctx, "Heat")
+	if err != nil {
+		t.Fatalf("GetByTitle returned error: %v", err)
+	}
+	if enriched.Distributor == nil || *enriched.Distributor != fresh {
+		t.Fatalf("expected the outcome of the worker holding the lease, got %+v", enriched.Distributor)
+	}
+	var remaining int
+	if err := sqlDB.QueryRowContext(ctx, `SELECT COUNT(*) FROM enrichment_jobs`).Scan(&remaining); err != nil || remaining != 0 {
+		t.Fatalf("expected the job to be done, got %d jobs (%v)", remaining, err)
+	}
+}
@@ -11,28 +11,112 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
 type PostgresMovieRepository struct {
-	db *sql.DB
+	db dbtx
+	// lockReads makes GetByTitle lock the row it returns; it is set inside a
+	// unit of work.
+	lockReads bool
 }
 
 func NewPostgresMovieRepository(db *sql.DB) *PostgresMovieRepository {
 	return &PostgresMovieRepository{db: db}
 }
 
+// Create inserts the movie and replaces it with the stored row. A movie
+// created with EnrichmentPending gets an enrichment job in the same
+// transaction; box office data looked up on creation starts the movie's
+// history, and conflicts with the provider are recorded.
 func (r *PostgresMovieRepository) Create(ctx context.Context, movie *model.Movie) error {
+	query := `
+        INSERT INTO movies (id, title, genre, release_date, distributor, budget, mpa_rating, box_office, enrichment_status, sources, budget_usd, worldwide_usd, boxoffice_checked_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, CASE WHEN $9 IN ('complete', 'not_found') THEN NOW() END)
+        RETURNING ` + movieColumns
+
+	boxOfficeJSON, err := marshalBoxOffice(movie.BoxOffice)
+	if err != nil {
+		return err
+	}
+	sourcesJSON, err := marshalSources(movie.Sources)
+	if err != nil {
+		return err
+	}
+	if movie.EnrichmentStatus == "" {
+		movie.EnrichmentStatus = model.EnrichmentComplete
+	}
+
+	var stored *model.Movie
+	err = inTx(ctx, r.db, func(tx dbtx) error {
+		var err error
+		stored, err = scanMovie(tx.QueryRowContext(
+			ctx,
+			query,
+			movie.ID,
+			movie.Title,
+			movie.Genre,
+			movie.ReleaseDate,
+			nullableString(movie.Distributor),
+			nullableInt(movie.Budget),
+			nullableString(movie.MpaRating),
+			boxOfficeJSON,
+			movie.EnrichmentStatus,
+			sourcesJSON,
+			nullableInt(movie.BudgetUSD),
+			worldwideUSD(movie.BoxOffice),
+		))
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrMovieAlreadyExists
+			}
+			return err
+		}
+
+		switch {
+		case movie.EnrichmentStatus == model.EnrichmentPending:
+			if _, err := tx.ExecContext(ctx, `INSERT INTO enrichment_jobs (movie_id) VALUES ($1)`, movie.ID); err != nil {
+				return err
+			}
+		case movie.EnrichmentStatus == model.EnrichmentComplete && movie.BoxOffice != nil:
+			if err := appendSnapshot(ctx, tx, movie.ID, movie.BoxOffice); err != nil {
+				return err
+			}
+		}
+		return recordConflicts(ctx, tx, movie.Conflicts)
+	})
+	if err != nil {
+		return err
+	}
+
+	*movie = *stored
+	return nil
+}
+
+func (r *PostgresMovieRepository) Update(ctx context.Context, movie *model.Movie, expectedVersion int64) error {
 	const query = `
-        INSERT INTO movies (id, title, genre, release_date, distributor, budget, mpa_rating, box_office)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        UPDATE movies
+        SET title = $2,
+            genre = $3,
+            release_date = $4,
+            distributor = $5,
+            budget = $6,
+            mpa_rating = $7,
+            sources = $9,
+            budget_usd = $10,
+            version = version + 1,
+            updated_at = NOW()
+        WHERE id = $1 AND version = $8 AND deleted_at IS NULL
+        RETURNING version, updated_at
     `
 
-	boxOfficeJSON, err := marshalBoxOffice(movie.BoxOffice)
+	sourcesJSON, err := marshalSources(movie.Sources)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.db.ExecContext(
+	err = r.db.QueryRowContext(
 		ctx,
 		query,
 		movie.ID,
@@ -42,88 +126,283 @@ func (r *PostgresMovieRepository) Create(ctx context.Context, movie *model.Movie
 		nullableString(movie.Distributor),
 		nullableInt(movie.Budget),
 		nullableString(movie.MpaRating),
-		boxOfficeJSON,
-	)
-	if err != nil {
-		if isUniqueViolation(err) {
-			return ErrMovieAlreadyExists
-		}
+		expectedVersion,
+		sourcesJSON,
+		nullableInt(movie.BudgetUSD),
+	).Scan(&movie.Version, &movie.UpdatedAt)
+	switch {
+	case err == nil:
+		return nil
+	case isUniqueViolation(err):
+		return ErrMovieAlreadyExists
+	case !errors.Is(err, sql.ErrNoRows):
 		return err
 	}
 
-	return nil
+	return notFoundOrConflict(ctx, r.db, movie.ID)
 }
 
-func (r *PostgresMovieRepository) UpdateSupplemental(ctx context.Context, movieID string, distributor *string, budget *int64, mpaRating *string, boxOffice *model.BoxOffice) error {
+// UpdateBoxOffice also appends the box office data to the movie's history
+// when the lookup behind it succeeded, and records conflicts with the
+// provider.
+func (r *PostgresMovieRepository) UpdateBoxOffice(ctx context.Context, movie *model.Movie, expectedVersion int64) error {
 	const query = `
         UPDATE movies
         SET distributor = $2,
             budget = $3,
             mpa_rating = $4,
             box_office = $5,
+            enrichment_status = $6,
+            sources = $8,
+            budget_usd = $9,
+            worldwide_usd = $10,
+            boxoffice_checked_at = NOW(),
+            version = version + 1,
             updated_at = NOW()
-        WHERE id = $1
+        WHERE id = $1 AND version = $7 AND deleted_at IS NULL
+        RETURNING version, updated_at
     `
 
-	boxOfficeJSON, err := marshalBoxOffice(boxOffice)
+	boxOfficeJSON, err := marshalBoxOffice(movie.BoxOffice)
 	if err != nil {
 		return err
 	}
-
-	res, err := r.db.ExecContext(
-		ctx,
-		query,
-		movieID,
-		nullableString(distributor),
-		nullableInt(budget),
-		nullableString(mpaRating),
-		boxOfficeJSON,
-	)
+	sourcesJSON, err := marshalSources(movie.Sources)
 	if err != nil {
 		return err
 	}
 
-	affected, err := res.RowsAffected()
-	if err != nil {
+	return inTx(ctx, r.db, func(tx dbtx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			movie.ID,
+			nullableString(movie.Distributor),
+			nullableInt(movie.Budget),
+			nullableString(movie.MpaRating),
+			boxOfficeJSON,
+			movie.EnrichmentStatus,
+			expectedVersion,
+			sourcesJSON,
+			nullableInt(movie.BudgetUSD),
+			worldwideUSD(movie.BoxOffice),
+		).Scan(&movie.Version, &movie.UpdatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFoundOrConflict(ctx, tx, movie.ID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if movie.EnrichmentStatus == model.EnrichmentComplete && movie.BoxOffice != nil {
+			if err := appendSnapshot(ctx, tx, movie.ID, movie.BoxOffice); err != nil {
+				return err
+			}
+		}
+		return recordConflicts(ctx, tx, movie.Conflicts)
+	})
+}
+
+// notFoundOrConflict explains why a conditional write matched no row: either
+// the movie is gone or someone else wrote to it first.
+func notFoundOrConflict(ctx context.Context, db dbtx, movieID string) error {
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM movies WHERE id = $1 AND deleted_at IS NULL)`, movieID).Scan(&exists); err != nil {
 		return err
 	}
-	if affected == 0 {
+	if !exists {
 		return ErrMovieNotFound
 	}
-	return nil
+	return ErrMovieConflict
 }
 
 func (r *PostgresMovieRepository) GetByTitle(ctx context.Context, title string) (*model.Movie, error) {
-	const query = `
-        SELECT id, title, genre, release_date, distributor, budget, mpa_rating, box_office, created_at, updated_at
+	query := `
+        SELECT ` + movieColumns + `
+        FROM movies
+        WHERE LOWER(title) = LOWER($1) AND deleted_at IS NULL
+    `
+	if r.lockReads {
+		query += "FOR UPDATE"
+	}
+
+	movie, err := scanMovie(r.db.QueryRowContext(ctx, query, title))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMovieNotFound
+		}
+		return nil, err
+	}
+
+	return movie, nil
+}
+
+func (r *PostgresMovieRepository) GetByTitleIncludingDeleted(ctx context.Context, title string) (*model.Movie, error) {
+	query := `
+        SELECT ` + movieColumns + `
         FROM movies
         WHERE LOWER(title) = LOWER($1)
+        ORDER BY deleted_at IS NOT NULL, deleted_at DESC
+        LIMIT 1
+    `
+	if r.lockReads {
+		query += "FOR UPDATE"
+	}
+
+	movie, err := scanMovie(r.db.QueryRowContext(ctx, query, title))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMovieNotFound
+		}
+		return nil, err
+	}
+
+	return movie, nil
+}
+
+// SoftDelete hides a movie from reads and frees its title for reuse. Ratings
+// are kept so that a restore brings them back.
+func (r *PostgresMovieRepository) SoftDelete(ctx context.Context, movieID string, expectedVersion int64) error {
+	const query = `
+        UPDATE movies
+        SET deleted_at = NOW(),
+            version = version + 1,
+            updated_at = NOW()
+        WHERE id = $1 AND version = $2 AND deleted_at IS NULL
     `
 
+	return inTx(ctx, r.db, func(tx dbtx) error {
+		res, err := tx.ExecContext(ctx, query, movieID, expectedVersion)
+		if err != nil {
+			return err
+		}
+		return requireAffected(ctx, tx, res, movieID)
+	})
+}
+
+// Delete permanently removes a movie; its ratings go with it via ON DELETE CASCADE.
+func (r *PostgresMovieRepository) Delete(ctx context.Context, movieID string, expectedVersion int64) error {
+	return inTx(ctx, r.db, func(tx dbtx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM movies WHERE id = $1 AND version = $2`, movieID, expectedVersion)
+		if err != nil {
+			return err
+		}
+		return requireAffected(ctx, tx, res, movieID)
+	})
+}
+
+// Restore brings back the most recently soft-deleted movie with the given
+// title. It fails with ErrMovieAlreadyExists if the title has been reused.
+func (r *PostgresMovieRepository) Restore(ctx context.Context, title string) (*model.Movie, error) {
+	query := `
+        UPDATE movies
+        SET deleted_at = NULL,
+            version = version + 1,
+            updated_at = NOW()
+        WHERE id = (
+            SELECT id
+            FROM movies
+            WHERE LOWER(title) = LOWER($1) AND deleted_at IS NOT NULL
+            ORDER BY deleted_at DESC
+            LIMIT 1
+        )
+        RETURNING ` + movieColumns
+
+	movie, err := scanMovie(r.db.QueryRowContext(ctx, query, title))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrMovieNotFound
+		case isUniqueViolation(err):
+			return nil, ErrMovieAlreadyExists
+		}
+		return nil, err
+	}
+
+	return movie, nil
+}
+
+func (r *PostgresMovieRepository) List(ctx context.Context, params MovieListParams) ([]*model.Movie, error) {
+	base := strings.Builder{}
+	base.WriteString(`
+        SELECT ` + movieColumns + `
+        FROM movies
+    `)
+
+	clauses, args, idx := movieFilterClauses(params.MovieFilter, 1)
+
+	if params.After != nil {
+		clauses = append(clauses, fmt.Sprintf("(created_at > $%d OR (created_at = $%d AND id > $%d))", idx, idx, idx+1))
+		args = append(args, params.After.CreatedAt, params.After.ID)
+		idx += 2
+	}
+
+	base.WriteString("WHERE ")
+	base.WriteString(strings.Join(clauses, " AND "))
+	base.WriteString("\n")
+
+	base.WriteString("ORDER BY created_at ASC, id ASC\n")
+	base.WriteString(fmt.Sprintf("LIMIT $%d", idx))
+	args = append(args, params.Limit)
+
+	rows, err := r.db.QueryContext(ctx, base.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movies []*model.Movie
+	for rows.Next() {
+		movie, err := scanMovie(rows)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, movie)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return movies, nil
+}
+
+const movieColumns = "id, title, genre, release_date, distributor, budget, budget_usd, mpa_rating, box_office, enrichment_status, sources, version, created_at, updated_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanMovie reads a row selected with movieColumns, followed by any extra
+// columns which are scanned into extra.
+func scanMovie(row rowScanner, extra ...interface{}) (*model.Movie, error) {
 	var (
 		movie        model.Movie
 		distributor  sql.NullString
 		budget       sql.NullInt64
+		budgetUSD    sql.NullInt64
 		mpaRating    sql.NullString
 		boxOfficeRaw []byte
+		sourcesRaw   []byte
 	)
 
-	err := r.db.QueryRowContext(ctx, query, title).Scan(
+	dest := []interface{}{
 		&movie.ID,
 		&movie.Title,
 		&movie.Genre,
 		&movie.ReleaseDate,
 		&distributor,
 		&budget,
+		&budgetUSD,
 		&mpaRating,
 		&boxOfficeRaw,
+		&movie.EnrichmentStatus,
+		&sourcesRaw,
+		&movie.Version,
 		&movie.CreatedAt,
 		&movie.UpdatedAt,
-	)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrMovieNotFound
-		}
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
 		return nil, err
 	}
 
@@ -134,6 +413,10 @@ func (r *PostgresMovieRepository) GetByTitle(ctx context.Context, title string)
 		v := budget.Int64
 		movie.Budget = &v
 	}
+	if budgetUSD.Valid {
+		v := budgetUSD.Int64
+		movie.BudgetUSD = &v
+	}
 	if mpaRating.Valid {
 		movie.MpaRating = &mpaRating.String
 	}
@@ -144,132 +427,80 @@ func (r *PostgresMovieRepository) GetByTitle(ctx context.Context, title string)
 		}
 		movie.BoxOffice = boxOffice
 	}
+	if len(sourcesRaw) > 0 {
+		if err := json.Unmarshal(sourcesRaw, &movie.Sources); err != nil {
+			return nil, err
+		}
+	}
 
 	return &movie, nil
 }
 
-func (r *PostgresMovieRepository) List(ctx context.Context, params MovieListParams) ([]*model.Movie, error) {
-	base := strings.Builder{}
-	base.WriteString(`
-        SELECT id, title, genre, release_date, distributor, budget, mpa_rating, box_office, created_at, updated_at
-        FROM movies
-    `)
-
+// movieFilterClauses renders the filter as SQL predicates on the movies
+// columns, numbering placeholders from idx. Soft-deleted movies are always
+// excluded. It returns the next free placeholder index.
+func movieFilterClauses(filter MovieFilter, idx int) ([]string, []interface{}, int) {
 	var (
-		clauses []string
+		clauses = []string{"deleted_at IS NULL"}
 		args    []interface{}
-		idx     = 1
 	)
 
-	if params.Q != "" {
+	if filter.Q != "" {
 		clauses = append(clauses, fmt.Sprintf("title ILIKE '%%' || $%d || '%%'", idx))
-		args = append(args, params.Q)
+		args = append(args, filter.Q)
 		idx++
 	}
 
-	if params.Year != nil {
+	if filter.Year != nil {
 		clauses = append(clauses, fmt.Sprintf("EXTRACT(YEAR FROM release_date) = $%d", idx))
-		args = append(args, *params.Year)
+		args = append(args, *filter.Year)
 		idx++
 	}
 
-	if params.Genre != nil && *params.Genre != "" {
+	if filter.Genre != nil && *filter.Genre != "" {
 		clauses = append(clauses, fmt.Sprintf("LOWER(genre) = LOWER($%d)", idx))
-		args = append(args, *params.Genre)
+		args = append(args, *filter.Genre)
 		idx++
 	}
 
-	if params.Distributor != nil && *params.Distributor != "" {
+	if filter.Distributor != nil && *filter.Distributor != "" {
 		clauses = append(clauses, fmt.Sprintf("LOWER(distributor) = LOWER($%d)", idx))
-		args = append(args, *params.Distributor)
+		args = append(args, *filter.Distributor)
 		idx++
 	}
 
-	if params.BudgetLTE != nil {
-		clauses = append(clauses, fmt.Sprintf("budget IS NOT NULL AND budget <= $%d", idx))
-		args = append(args, *params.BudgetLTE)
+	if filter.BudgetLTE != nil {
+		clauses = append(clauses, fmt.Sprintf("budget_usd IS NOT NULL AND budget_usd <= $%d", idx))
+		args = append(args, *filter.BudgetLTE)
 		idx++
 	}
 
-	if params.MpaRating != nil && *params.MpaRating != "" {
-		clauses = append(clauses, fmt.Sprintf("LOWER(mpa_rating) = LOWER($%d)", idx))
-		args = append(args, *params.MpaRating)
+	if filter.RevenueGTE != nil {
+		clauses = append(clauses, fmt.Sprintf("worldwide_usd >= $%d", idx))
+		args = append(args, *filter.RevenueGTE)
 		idx++
 	}
 
-	if params.After != nil {
-		clauses = append(clauses, fmt.Sprintf("(created_at > $%d OR (created_at = $%d AND id > $%d))", idx, idx, idx+1))
-		args = append(args, params.After.CreatedAt, params.After.ID)
-		idx += 2
-	}
-
-	if len(clauses) > 0 {
-		base.WriteString("WHERE ")
-		base.WriteString(strings.Join(clauses, " AND "))
-		base.WriteString("\n")
+	if filter.MpaRating != nil && *filter.MpaRating != "" {
+		clauses = append(clauses, fmt.Sprintf("LOWER(mpa_rating) = LOWER($%d)", idx))
+		args = append(args, *filter.MpaRating)
+		idx++
 	}
 
-	base.WriteString("ORDER BY created_at ASC, id ASC\n")
-	base.WriteString(fmt.Sprintf("LIMIT $%d", idx))
-	args = append(args, params.Limit)
+	return clauses, args, idx
+}
 
-	rows, err := r.db.QueryContext(ctx, base.String(), args...)
+// requireAffected explains a conditional write that matched no row with
+// notFoundOrConflict.
+func requireAffected(ctx context.Context, db dbtx, res sql.Result, movieID string) error {
+	affected, err := res.RowsAffected()
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var movies []*model.Movie
-	for rows.Next() {
-		var (
-			movie        model.Movie
-			distributor  sql.NullString
-			budget       sql.NullInt64
-			mpaRating    sql.NullString
-			boxOfficeRaw []byte
-		)
-
-		if err := rows.Scan(
-			&movie.ID,
-			&movie.Title,
-			&movie.Genre,
-			&movie.ReleaseDate,
-			&distributor,
-			&budget,
-			&mpaRating,
-			&boxOfficeRaw,
-			&movie.CreatedAt,
-			&movie.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-
-		if distributor.Valid {
-			movie.Distributor = &distributor.String
-		}
-		if budget.Valid {
-			v := budget.Int64
-			movie.Budget = &v
-		}
-		if mpaRating.Valid {
-			movie.MpaRating = &mpaRating.String
-		}
-		if len(boxOfficeRaw) > 0 {
-			boxOffice, err := unmarshalBoxOffice(boxOfficeRaw)
-			if err != nil {
-				return nil, err
-			}
-			movie.BoxOffice = boxOffice
-		}
-
-		movies = append(movies, &movie)
+		return err
 	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
+	if affected == 0 {
+		return notFoundOrConflict(ctx, db, movieID)
 	}
-
-	return movies, nil
+	return nil
 }
 
 func marshalBoxOffice(boxOffice *model.BoxOffice) ([]byte, error) {
@@ -281,21 +512,43 @@ func marshalBoxOffice(boxOffice *model.BoxOffice) ([]byte, error) {
 		Revenue struct {
 			Worldwide        int64  `json:"worldwide"`
 			OpeningWeekendUS *int64 `json:"openingWeekendUSA"`
+			WorldwideUSD     *int64 `json:"worldwideUSD,omitempty"`
 		} `json:"revenue"`
-		Currency    string    `json:"currency"`
-		Source      string    `json:"source"`
-		LastUpdated time.Time `json:"lastUpdated"`
+		Currency        string    `json:"currency"`
+		Source          string    `json:"source"`
+		LastUpdated     time.Time `json:"lastUpdated"`
+		MatchConfidence *float64  `json:"matchConfidence,omitempty"`
 	}{
-		Currency:    boxOffice.Currency,
-		Source:      boxOffice.Source,
-		LastUpdated: boxOffice.LastUpdated,
+		Currency:        boxOffice.Currency,
+		Source:          boxOffice.Source,
+		LastUpdated:     boxOffice.LastUpdated,
+		MatchConfidence: boxOffice.MatchConfidence,
 	}
 	payload.Revenue.Worldwide = boxOffice.Revenue.Worldwide
 	payload.Revenue.OpeningWeekendUS = boxOffice.Revenue.OpeningWeekendUS
+	payload.Revenue.WorldwideUSD = boxOffice.Revenue.WorldwideUSD
 
 	return json.Marshal(payload)
 }
 
+// worldwideUSD extracts the normalised revenue stored alongside the box office
+// document so it can be filtered on.
+func worldwideUSD(boxOffice *model.BoxOffice) interface{} {
+	if boxOffice == nil {
+		return nil
+	}
+	return nullableInt(boxOffice.Revenue.WorldwideUSD)
+}
+
+// marshalSources stores a missing provenance map as an empty object, since
+// the column is NOT NULL.
+func marshalSources(sources map[string]string) ([]byte, error) {
+	if sources == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(sources)
+}
+
 func unmarshalBoxOffice(raw []byte) (*model.BoxOffice, error) {
 	if len(raw) == 0 {
 		return nil, nil
@@ -305,10 +558,12 @@ func unmarshalBoxOffice(raw []byte) (*model.BoxOffice, error) {
 		Revenue struct {
 			Worldwide        int64  `json:"worldwide"`
 			OpeningWeekendUS *int64 `json:"openingWeekendUSA"`
+			WorldwideUSD     *int64 `json:"worldwideUSD,omitempty"`
 		} `json:"revenue"`
-		Currency    string    `json:"currency"`
-		Source      string    `json:"source"`
-		LastUpdated time.Time `json:"lastUpdated"`
+		Currency        string    `json:"currency"`
+		Source          string    `json:"source"`
+		LastUpdated     time.Time `json:"lastUpdated"`
+		MatchConfidence *float64  `json:"matchConfidence,omitempty"`
 	}
 
 	if err := json.Unmarshal(raw, &payload); err != nil {
@@ -319,10 +574,12 @@ func unmarshalBoxOffice(raw []byte) (*model.BoxOffice, error) {
 		Revenue: model.BoxOfficeRevenue{
 			Worldwide:        payload.Revenue.Worldwide,
 			OpeningWeekendUS: payload.Revenue.OpeningWeekendUS,
+			WorldwideUSD:     payload.Revenue.WorldwideUSD,
 		},
-		Currency:    payload.Currency,
-		Source:      payload.Source,
-		LastUpdated: payload.LastUpdated,
+		Currency:        payload.Currency,
+		Source:          payload.Source,
+		LastUpdated:     payload.LastUpdated,
+		MatchConfidence: payload.MatchConfidence,
 	}, nil
 }
 
@@ -340,10 +597,16 @@ func nullableInt(value *int64) interface{} {
 	return *value
 }
 
+// isUniqueViolation recognises unique constraint errors from both Postgres
+// and SQLite.
 func isUniqueViolation(err error) bool {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
 		return pgErr.Code == "23505"
 	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
 	return false
 }
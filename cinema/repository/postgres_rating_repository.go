@@ -4,16 +4,22 @@ import (
 	"cinema/model"
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 )
 
 type PostgresRatingRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 func NewPostgresRatingRepository(db *sql.DB) *PostgresRatingRepository {
 	return &PostgresRatingRepository{db: db}
 }
 
+// Upsert writes the rating and adjusts movie_rating_stats in one transaction.
+// Writers for a movie serialise on its stats row, which keeps the previous
+// rating stable between reading it and applying the delta.
 func (r *PostgresRatingRepository) Upsert(ctx context.Context, rating *model.Rating) (bool, error) {
 	const query = `
         INSERT INTO ratings (movie_id, rater_id, rating, created_at, updated_at)
@@ -24,18 +30,136 @@ func (r *PostgresRatingRepository) Upsert(ctx context.Context, rating *model.Rat
     `
 
 	var created bool
-	if err := r.db.QueryRowContext(ctx, query, rating.MovieID, rating.RaterID, rating.Value).Scan(&created); err != nil {
+	err := inTx(ctx, r.db, func(tx dbtx) error {
+		if err := lockRatingStats(ctx, tx, rating.MovieID); err != nil {
+			return err
+		}
+
+		var previous sql.NullFloat64
+		err := tx.QueryRowContext(ctx, `SELECT rating FROM ratings WHERE movie_id = $1 AND rater_id = $2`, rating.MovieID, rating.RaterID).Scan(&previous)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if err := tx.QueryRowContext(ctx, query, rating.MovieID, rating.RaterID, rating.Value).Scan(&created); err != nil {
+			return err
+		}
+
+		delta := ratingStatsDelta{sum: rating.Value, added: bucketParam(rating.Value)}
+		if created {
+			delta.count = 1
+		} else if previous.Valid {
+			delta.sum -= previous.Float64
+			delta.removed = bucketParam(previous.Float64)
+		}
+		return applyRatingStatsDelta(ctx, tx, rating.MovieID, delta)
+	})
+	if err != nil {
 		return false, err
 	}
 	return created, nil
 }
 
-func (r *PostgresRatingRepository) AggregateByMovieID(ctx context.Context, movieID string) (float64, int, error) {
+func (r *PostgresRatingRepository) Get(ctx context.Context, movieID, raterID string) (*model.Rating, error) {
 	const query = `
-        SELECT COALESCE(AVG(rating), 0), COUNT(*)
+        SELECT movie_id, rater_id, rating, created_at, updated_at
+        FROM ratings
+        WHERE movie_id = $1 AND rater_id = $2
+    `
+
+	var rating model.Rating
+	err := r.db.QueryRowContext(ctx, query, movieID, raterID).Scan(
+		&rating.MovieID,
+		&rating.RaterID,
+		&rating.Value,
+		&rating.CreatedAt,
+		&rating.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRatingNotFound
+		}
+		return nil, err
+	}
+
+	return &rating, nil
+}
+
+func (r *PostgresRatingRepository) Delete(ctx context.Context, movieID, raterID string) error {
+	return inTx(ctx, r.db, func(tx dbtx) error {
+		if err := lockRatingStats(ctx, tx, movieID); err != nil {
+			return err
+		}
+
+		var removed float64
+		err := tx.QueryRowContext(ctx, `DELETE FROM ratings WHERE movie_id = $1 AND rater_id = $2 RETURNING rating`, movieID, raterID).Scan(&removed)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrRatingNotFound
+			}
+			return err
+		}
+
+		delta := ratingStatsDelta{sum: -removed, count: -1, removed: bucketParam(removed)}
+		return applyRatingStatsDelta(ctx, tx, movieID, delta)
+	})
+}
+
+// List pages through a movie's ratings in (created_at, rater_id) order, the
+// same keyset scheme used for movies.
+func (r *PostgresRatingRepository) List(ctx context.Context, params RatingListParams) ([]*model.Rating, error) {
+	query := `
+        SELECT movie_id, rater_id, rating, created_at, updated_at
         FROM ratings
         WHERE movie_id = $1
     `
+	args := []interface{}{params.MovieID}
+
+	if params.After != nil {
+		query += `AND (created_at > $2 OR (created_at = $2 AND rater_id > $3))
+        ORDER BY created_at ASC, rater_id ASC
+        LIMIT $4`
+		args = append(args, params.After.CreatedAt, params.After.RaterID, params.Limit)
+	} else {
+		query += `ORDER BY created_at ASC, rater_id ASC
+        LIMIT $2`
+		args = append(args, params.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ratings []*model.Rating
+	for rows.Next() {
+		var rating model.Rating
+		if err := rows.Scan(
+			&rating.MovieID,
+			&rating.RaterID,
+			&rating.Value,
+			&rating.CreatedAt,
+			&rating.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, &rating)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ratings, nil
+}
+
+func (r *PostgresRatingRepository) AggregateByMovieID(ctx context.Context, movieID string) (float64, int, error) {
+	const query = `
+        SELECT COALESCE(rating_sum / NULLIF(rating_count, 0), 0)::float8, rating_count
+        FROM movie_rating_stats
+        WHERE movie_id = $1
+    `
 
 	var (
 		average float64
@@ -43,8 +167,188 @@ func (r *PostgresRatingRepository) AggregateByMovieID(ctx context.Context, movie
 	)
 
 	if err := r.db.QueryRowContext(ctx, query, movieID).Scan(&average, &count); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, nil
+		}
 		return 0, 0, err
 	}
 
 	return average, count, nil
 }
+
+func (r *PostgresRatingRepository) HistogramByMovieID(ctx context.Context, movieID string) (model.RatingHistogram, error) {
+	const query = `
+        SELECT u.bucket, u.votes
+        FROM movie_rating_stats, unnest(histogram) WITH ORDINALITY AS u(votes, bucket)
+        WHERE movie_id = $1
+    `
+
+	var histogram model.RatingHistogram
+
+	rows, err := r.db.QueryContext(ctx, query, movieID)
+	if err != nil {
+		return histogram, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket, count int
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return histogram, err
+		}
+		if bucket >= 1 && bucket <= len(histogram) {
+			histogram[bucket-1] = count
+		}
+	}
+
+	return histogram, rows.Err()
+}
+
+// RebuildStats recomputes movie_rating_stats from the ratings table. Rating
+// writes are blocked for the duration so the result is exact. Locks are taken
+// in the order rating writers take them, stats before ratings, so that a
+// repair running alongside writers queues behind them instead of deadlocking.
+func (r *PostgresRatingRepository) RebuildStats(ctx context.Context) (int64, error) {
+	const query = `
+        INSERT INTO movie_rating_stats (movie_id, rating_sum, rating_count, histogram, updated_at)
+        SELECT movies.id,
+               COALESCE(SUM(ratings.rating), 0),
+               COUNT(ratings.rating),
+               ARRAY[
+                   COUNT(*) FILTER (WHERE ratings.rating = 0.5),
+                   COUNT(*) FILTER (WHERE ratings.rating = 1.0),
+                   COUNT(*) FILTER (WHERE ratings.rating = 1.5),
+                   COUNT(*) FILTER (WHERE ratings.rating = 2.0),
+                   COUNT(*) FILTER (WHERE ratings.rating = 2.5),
+                   COUNT(*) FILTER (WHERE ratings.rating = 3.0),
+                   COUNT(*) FILTER (WHERE ratings.rating = 3.5),
+                   COUNT(*) FILTER (WHERE ratings.rating = 4.0),
+                   COUNT(*) FILTER (WHERE ratings.rating = 4.5),
+                   COUNT(*) FILTER (WHERE ratings.rating = 5.0)
+               ]::INTEGER[],
+               NOW()
+        FROM movies
+        LEFT JOIN ratings ON ratings.movie_id = movies.id
+        GROUP BY movies.id
+        ON CONFLICT (movie_id) DO UPDATE
+        SET rating_sum = EXCLUDED.rating_sum,
+            rating_count = EXCLUDED.rating_count,
+            histogram = EXCLUDED.histogram,
+            updated_at = EXCLUDED.updated_at
+    `
+
+	var affected int64
+	err := inTx(ctx, r.db, func(tx dbtx) error {
+		if _, err := tx.ExecContext(ctx, `LOCK TABLE movie_rating_stats IN EXCLUSIVE MODE`); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `LOCK TABLE ratings IN SHARE MODE`); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		affected, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
+
+func (r *PostgresRatingRepository) TopRated(ctx context.Context, params TopRatedParams) ([]*model.RankedMovie, error) {
+	clauses, args, idx := movieFilterClauses(params.MovieFilter, 3)
+	args = append([]interface{}{params.PriorMean, params.MinVotes}, args...)
+
+	// Reads the materialised per-movie aggregates; the prior falls back to the
+	// global mean when not configured.
+	query := fmt.Sprintf(`
+        WITH prior AS (
+            SELECT COALESCE($1::float8, (SUM(rating_sum) / NULLIF(SUM(rating_count), 0))::float8, 0) AS mean
+            FROM movie_rating_stats
+        ),
+        stats AS (
+            SELECT movie_id, (rating_sum / rating_count)::float8 AS average, rating_count AS votes
+            FROM movie_rating_stats
+            WHERE rating_count > 0
+        )
+        SELECT %s, stats.average, stats.votes,
+               (stats.votes * stats.average + $2 * prior.mean) / (stats.votes + $2) AS score
+        FROM movies
+        JOIN stats ON stats.movie_id = movies.id
+        CROSS JOIN prior
+        WHERE %s
+        ORDER BY score DESC, stats.votes DESC, movies.id ASC
+        LIMIT $%d
+    `, movieColumns, strings.Join(clauses, " AND "), idx)
+	args = append(args, params.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranked []*model.RankedMovie
+	for rows.Next() {
+		entry := &model.RankedMovie{}
+		movie, err := scanMovie(rows, &entry.Average, &entry.Count, &entry.Score)
+		if err != nil {
+			return nil, err
+		}
+		entry.Movie = movie
+		ranked = append(ranked, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ranked, nil
+}
+
+type ratingStatsDelta struct {
+	sum     float64
+	count   int
+	added   interface{}
+	removed interface{}
+}
+
+// lockRatingStats creates the stats row if needed and holds its lock until
+// the transaction ends.
+func lockRatingStats(ctx context.Context, tx execer, movieID string) error {
+	const query = `
+        INSERT INTO movie_rating_stats (movie_id)
+        VALUES ($1)
+        ON CONFLICT (movie_id) DO UPDATE SET updated_at = movie_rating_stats.updated_at
+    `
+	_, err := tx.ExecContext(ctx, query, movieID)
+	return err
+}
+
+func applyRatingStatsDelta(ctx context.Context, tx execer, movieID string, delta ratingStatsDelta) error {
+	const query = `
+        UPDATE movie_rating_stats
+        SET rating_sum = rating_sum + $2,
+            rating_count = rating_count + $3,
+            histogram = ARRAY(
+                SELECT u.votes
+                       + CASE WHEN u.bucket = $4 THEN 1 ELSE 0 END
+                       - CASE WHEN u.bucket = $5 THEN 1 ELSE 0 END
+                FROM unnest(histogram) WITH ORDINALITY AS u(votes, bucket)
+                ORDER BY u.bucket
+            ),
+            updated_at = NOW()
+        WHERE movie_id = $1
+    `
+	_, err := tx.ExecContext(ctx, query, movieID, delta.sum, delta.count, delta.added, delta.removed)
+	return err
+}
+
+// bucketParam maps a rating onto its 1-based histogram position.
+func bucketParam(value float64) interface{} {
+	return int64(model.RatingBucket(value) + 1)
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+type ratingStatsRow struct {
+	sum       float64
+	count     int
+	histogram string
+}
+
+func readRatingStats(t *testing.T, sqlDB *sql.DB, movieID string) ratingStatsRow {
+	t.Helper()
+	var row ratingStatsRow
+	err := sqlDB.QueryRow(`
+        SELECT rating_sum::float8, rating_count, array_to_string(histogram, ',')
+        FROM movie_rating_stats
+        WHERE movie_id = $1
+    `, movieID).Scan(&row.sum, &row.count, &row.histogram)
+	if err != nil {
+		t.Fatalf("read stats: %v", err)
+	}
+	return row
+}
+
+func TestPostgresRatingStatsFollowWritesAndRebuild(t *testing.T) {
+	sqlDB := openTestPostgres(t)
+	truncateMovies(t, sqlDB)
+	ctx := context.Background()
+	movies, ratings := NewPostgresMovieRepository(sqlDB), NewPostgresRatingRepository(sqlDB)
+	movie := createMovie(t, movies, model.Movie{Title: "Heat"})
+
+	steps := []struct {
+		name  string
+		apply func()
+		want  ratingStatsRow
+	}{
+		{"insert", func() { upsertRating(t, ratings, movie.ID, "alice", 4) }, ratingStatsRow{4, 1, "0,0,0,0,0,0,0,1,0,0"}},
+		{"second insert", func() { upsertRating(t, ratings, movie.ID, "bob", 5) }, ratingStatsRow{9, 2, "0,0,0,0,0,0,0,1,0,1"}},
+		{"update", func() { upsertRating(t, ratings, movie.ID, "alice", 2) }, ratingStatsRow{7, 2, "0,0,0,1,0,0,0,0,0,1"}},
+		{"delete", func() {
+			if err := ratings.Delete(ctx, movie.ID, "bob"); err != nil {
+				t.Fatalf("Delete returned error: %v", err)
+			}
+		}, ratingStatsRow{2, 1, "0,0,0,1,0,0,0,0,0,0"}},
+	}
+	for _, step := range steps {
+		step.apply()
+		if got := readRatingStats(t, sqlDB, movie.ID); got != step.want {
+			t.Fatalf("after %s expected stats %+v, got %+v", step.name, step.want, got)
+		}
+	}
+
+	if _, err := sqlDB.Exec(`UPDATE movie_rating_stats SET rating_sum = 99, rating_count = 7, histogram = ARRAY[7,0,0,0,0,0,0,0,0,0] WHERE movie_id = $1`, movie.ID); err != nil {
+		t.Fatalf("corrupt stats: %v", err)
+	}
+	if _, err := ratings.RebuildStats(ctx); err != nil {
+		t.Fatalf("RebuildStats returned error: %v", err)
+	}
+	if got, want := readRatingStats(t, sqlDB, movie.ID), steps[len(steps)-1].want; got != want {
+		t.Fatalf("expected RebuildStats to restore %+v, got %+v", want, got)
+	}
+}
+
+// TestPostgresRebuildStatsRunsAlongsideWriters guards the lock order shared by
+// RebuildStats and rating writes: with opposite orders Postgres aborts one
+// side with a deadlock.
+func TestPostgresRebuildStatsRunsAlongsideWriters(t *testing.T) {
+	sqlDB := openTestPostgres(t)
+	truncateMovies(t, sqlDB)
+	ctx := context.Background()
+	movies, ratings := NewPostgresMovieRepository(sqlDB), NewPostgresRatingRepository(sqlDB)
+	movie := createMovie(t, movies, model.Movie{Title: "Heat"})
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, 40)
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			rating := &model.Rating{MovieID: movie.ID, RaterID: fmt.Sprintf("rater-%d", i), Value: 3}
+			if _, err := ratings.Upsert(ctx, rating); err != nil {
+				errs <- fmt.Errorf("Upsert: %w", err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			if _, err := ratings.RebuildStats(ctx); err != nil {
+				errs <- fmt.Errorf("RebuildStats: %w", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	if got := readRatingStats(t, sqlDB, movie.ID); got.count != 20 || got.sum != 60 {
+		t.Fatalf("expected 20 ratings summing to 60, got %+v", got)
+	}
+}
@@ -3,9 +3,39 @@ package repository
 import (
 	"cinema/model"
 	"context"
+	"errors"
+	"time"
 )
 
+var ErrRatingNotFound = errors.New("rating not found")
+
+type RatingCursor struct {
+	CreatedAt time.Time
+	RaterID   string
+}
+
+type RatingListParams struct {
+	MovieID string
+	Limit   int
+	After   *RatingCursor
+}
+
+// TopRatedParams ranks movies by the Bayesian average
+// (count*average + MinVotes*prior) / (count + MinVotes). A nil PriorMean uses
+// the mean of all ratings.
+type TopRatedParams struct {
+	MovieFilter
+	PriorMean *float64
+	MinVotes  int
+	Limit     int
+}
+
 type RatingRepository interface {
 	Upsert(ctx context.Context, rating *model.Rating) (bool, error)
+	Get(ctx context.Context, movieID, raterID string) (*model.Rating, error)
+	Delete(ctx context.Context, movieID, raterID string) error
+	List(ctx context.Context, params RatingListParams) ([]*model.Rating, error)
 	AggregateByMovieID(ctx context.Context, movieID string) (float64, int, error)
+	HistogramByMovieID(ctx context.Context, movieID string) (model.RatingHistogram, error)
+	TopRated(ctx context.Context, params TopRatedParams) ([]*model.RankedMovie, error)
 }
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SQLite stores timestamps as text in this layout. The fixed width keeps
+// string comparison in step with time order.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000Z"
+
+// sqliteDateLayout is used for release dates.
+const sqliteDateLayout = "2006-01-02"
+
+var sqliteClock struct {
+	sync.Mutex
+	last time.Time
+}
+
+// sqliteNow stands in for NOW(), which SQLite lacks at microsecond
+// precision. Like MemoryStore it never repeats a timestamp, so that every
+// write moves updated_at.
+func sqliteNow() time.Time {
+	sqliteClock.Lock()
+	defer sqliteClock.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if !now.After(sqliteClock.last) {
+		now = sqliteClock.last.Add(time.Microsecond)
+	}
+	sqliteClock.last = now
+	return now
+}
+
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+func nullableSQLiteTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return sqliteTime(*t)
+}
+
+// sqliteTimeScanner parses a timestamp or date column into dest, leaving it
+// nil for NULL when dest is a **time.Time.
+type sqliteTimeScanner struct {
+	dest interface{}
+}
+
+func (s sqliteTimeScanner) Scan(value interface{}) error {
+	if value == nil {
+		if dest, ok := s.dest.(**time.Time); ok {
+			*dest = nil
+			return nil
+		}
+		return fmt.Errorf("cannot scan NULL into %T", s.dest)
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into a timestamp", value)
+	}
+	layout := sqliteTimeLayout
+	if len(text) == len(sqliteDateLayout) {
+		layout = sqliteDateLayout
+	}
+	parsed, err := time.Parse(layout, text)
+	if err != nil {
+		return err
+	}
+
+	switch dest := s.dest.(type) {
+	case *time.Time:
+		*dest = parsed
+	case **time.Time:
+		*dest = &parsed
+	default:
+		return fmt.Errorf("cannot scan a timestamp into %T", s.dest)
+	}
+	return nil
+}
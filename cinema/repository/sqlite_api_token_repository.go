@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+type SQLiteAPITokenRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteAPITokenRepository(db *sql.DB) *SQLiteAPITokenRepository {
+	return &SQLiteAPITokenRepository{db: db}
+}
+
+func (r *SQLiteAPITokenRepository) Create(ctx context.Context, token *model.APIToken, hash []byte) error {
+	const query = `
+        INSERT INTO api_tokens (id, name, token_hash, scopes, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `
+
+	now := sqliteNow()
+	if _, err := r.db.ExecContext(ctx, query, token.ID, token.Name, hash, strings.Join(token.Scopes, " "), nullableSQLiteTime(token.ExpiresAt), sqliteTime(now)); err != nil {
+		return err
+	}
+	token.CreatedAt = now
+	return nil
+}
+
+func (r *SQLiteAPITokenRepository) GetByHash(ctx context.Context, hash []byte) (*model.APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens WHERE token_hash = $1`
+
+	token, err := scanSQLiteAPIToken(r.db.QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *SQLiteAPITokenRepository) List(ctx context.Context) ([]*model.APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens ORDER BY created_at ASC, id ASC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*model.APIToken
+	for rows.Next() {
+		token, err := scanSQLiteAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// Revoke is idempotent: revoking an already revoked token keeps the original
+// revocation time.
+func (r *SQLiteAPITokenRepository) Revoke(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE api_tokens SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1`, id, sqliteTime(sqliteNow()))
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+func scanSQLiteAPIToken(row rowScanner) (*model.APIToken, error) {
+	var (
+		token  model.APIToken
+		scopes string
+	)
+
+	if err := row.Scan(
+		&token.ID,
+		&token.Name,
+		&scopes,
+		sqliteTimeScanner{&token.ExpiresAt},
+		sqliteTimeScanner{&token.RevokedAt},
+		sqliteTimeScanner{&token.CreatedAt},
+	); err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Fields(scopes)
+	return &token, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SQLiteBoxOfficeSnapshotRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteBoxOfficeSnapshotRepository(db *sql.DB) *SQLiteBoxOfficeSnapshotRepository {
+	return &SQLiteBoxOfficeSnapshotRepository{db: db}
+}
+
+func (r *SQLiteBoxOfficeSnapshotRepository) ListSnapshots(ctx context.Context, movieID string, from, to *time.Time) ([]model.BoxOfficeSnapshot, error) {
+	clauses := []string{"movie_id = $1"}
+	args := []interface{}{movieID}
+	if from != nil {
+		args = append(args, sqliteTime(*from))
+		clauses = append(clauses, "recorded_at >= $"+strconv.Itoa(len(args)))
+	}
+	if to != nil {
+		args = append(args, sqliteTime(*to))
+		clauses = append(clauses, "recorded_at < $"+strconv.Itoa(len(args)))
+	}
+
+	query := `
+        SELECT worldwide, opening_weekend_usa, currency, source, provider_updated_at, recorded_at
+        FROM box_office_snapshots
+        WHERE ` + strings.Join(clauses, " AND ") + `
+        ORDER BY recorded_at, id
+    `
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := make([]model.BoxOfficeSnapshot, 0)
+	for rows.Next() {
+		var (
+			snapshot          model.BoxOfficeSnapshot
+			openingWeekend    sql.NullInt64
+			providerUpdatedAt *time.Time
+		)
+		if err := rows.Scan(
+			&snapshot.Revenue.Worldwide,
+			&openingWeekend,
+			&snapshot.Currency,
+			&snapshot.Source,
+			sqliteTimeScanner{&providerUpdatedAt},
+			sqliteTimeScanner{&snapshot.RecordedAt},
+		); err != nil {
+			return nil, err
+		}
+		if openingWeekend.Valid {
+			value := openingWeekend.Int64
+			snapshot.Revenue.OpeningWeekendUS = &value
+		}
+		if providerUpdatedAt != nil {
+			snapshot.LastUpdated = *providerUpdatedAt
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// appendSQLiteSnapshot is appendSnapshot for SQLite, which has no NOW() to
+// stamp the row with, so the caller passes the time of the movie write.
+func appendSQLiteSnapshot(ctx context.Context, db execer, movieID string, boxOffice *model.BoxOffice, recordedAt time.Time) error {
+	const query = `
+        INSERT INTO box_office_snapshots (movie_id, worldwide, opening_weekend_usa, currency, source, provider_updated_at, recorded_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `
+
+	var providerUpdatedAt interface{}
+	if !boxOffice.LastUpdated.IsZero() {
+		providerUpdatedAt = sqliteTime(boxOffice.LastUpdated)
+	}
+
+	_, err := db.ExecContext(
+		ctx,
+		query,
+		movieID,
+		boxOffice.Revenue.Worldwide,
+		nullableInt(boxOffice.Revenue.OpeningWeekendUS),
+		boxOffice.Currency,
+		boxOffice.Source,
+		providerUpdatedAt,
+		sqliteTime(recordedAt),
+	)
+	return err
+}
@@ -0,0 +1,477 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SQLiteMovieRepository stores movies in SQLite with the semantics of
+// PostgresMovieRepository. Movies created with EnrichmentPending are not
+// queued, since the enrichment worker needs Postgres, and conflicts with the
+// provider are not recorded.
+type SQLiteMovieRepository struct {
+	db dbtx
+}
+
+func NewSQLiteMovieRepository(db *sql.DB) *SQLiteMovieRepository {
+	return &SQLiteMovieRepository{db: db}
+}
+
+// Create inserts the movie and replaces it with the stored row; box office
+// data looked up on creation starts the movie's history.
+func (r *SQLiteMovieRepository) Create(ctx context.Context, movie *model.Movie) error {
+	query := `
+        INSERT INTO movies (id, title, title_key, genre, release_date, distributor, budget, budget_usd, mpa_rating, box_office, worldwide_usd, enrichment_status, sources, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $14)
+        RETURNING ` + movieColumns
+
+	boxOfficeJSON, err := marshalBoxOffice(movie.BoxOffice)
+	if err != nil {
+		return err
+	}
+	sourcesJSON, err := marshalSources(movie.Sources)
+	if err != nil {
+		return err
+	}
+	if movie.EnrichmentStatus == "" {
+		movie.EnrichmentStatus = model.EnrichmentComplete
+	}
+
+	var stored *model.Movie
+	err = inTx(ctx, r.db, func(tx dbtx) error {
+		now := sqliteNow()
+		var err error
+		stored, err = scanSQLiteMovie(tx.QueryRowContext(
+			ctx,
+			query,
+			movie.ID,
+			movie.Title,
+			titleKey(movie.Title),
+			movie.Genre,
+			movie.ReleaseDate.Format(sqliteDateLayout),
+			nullableString(movie.Distributor),
+			nullableInt(movie.Budget),
+			nullableInt(movie.BudgetUSD),
+			nullableString(movie.MpaRating),
+			nullableJSON(boxOfficeJSON),
+			worldwideUSD(movie.BoxOffice),
+			movie.EnrichmentStatus,
+			string(sourcesJSON),
+			sqliteTime(now),
+		))
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrMovieAlreadyExists
+			}
+			return err
+		}
+
+		if movie.EnrichmentStatus == model.EnrichmentComplete && movie.BoxOffice != nil {
+			return appendSQLiteSnapshot(ctx, tx, movie.ID, movie.BoxOffice, now)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	*movie = *stored
+	return nil
+}
+
+func (r *SQLiteMovieRepository) Update(ctx context.Context, movie *model.Movie, expectedVersion int64) error {
+	const query = `
+        UPDATE movies
+        SET title = $2,
+            title_key = $3,
+            genre = $4,
+            release_date = $5,
+            distributor = $6,
+            budget = $7,
+            budget_usd = $8,
+            mpa_rating = $9,
+            sources = $10,
+            version = version + 1,
+            updated_at = $11
+        WHERE id = $1 AND version = $12 AND deleted_at IS NULL
+    `
+
+	sourcesJSON, err := marshalSources(movie.Sources)
+	if err != nil {
+		return err
+	}
+
+	now := sqliteNow()
+	res, err := r.db.ExecContext(
+		ctx,
+		query,
+		movie.ID,
+		movie.Title,
+		titleKey(movie.Title),
+		movie.Genre,
+		movie.ReleaseDate.Format(sqliteDateLayout),
+		nullableString(movie.Distributor),
+		nullableInt(movie.Budget),
+		nullableInt(movie.BudgetUSD),
+		nullableString(movie.MpaRating),
+		string(sourcesJSON),
+		sqliteTime(now),
+		expectedVersion,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrMovieAlreadyExists
+		}
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFoundOrConflict(ctx, r.db, movie.ID)
+	}
+
+	movie.Version = expectedVersion + 1
+	movie.UpdatedAt = now
+	return nil
+}
+
+// UpdateBoxOffice also appends the box office data to the movie's history
+// when the lookup behind it succeeded.
+func (r *SQLiteMovieRepository) UpdateBoxOffice(ctx context.Context, movie *model.Movie, expectedVersion int64) error {
+	const query = `
+        UPDATE movies
+        SET distributor = $2,
+            budget = $3,
+            budget_usd = $4,
+            mpa_rating = $5,
+            box_office = $6,
+            worldwide_usd = $7,
+            enrichment_status = $8,
+            sources = $9,
+            version = version + 1,
+            updated_at = $10
+        WHERE id = $1 AND version = $11 AND deleted_at IS NULL
+    `
+
+	boxOfficeJSON, err := marshalBoxOffice(movie.BoxOffice)
+	if err != nil {
+		return err
+	}
+	sourcesJSON, err := marshalSources(movie.Sources)
+	if err != nil {
+		return err
+	}
+
+	now := sqliteNow()
+	err = inTx(ctx, r.db, func(tx dbtx) error {
+		res, err := tx.ExecContext(
+			ctx,
+			query,
+			movie.ID,
+			nullableString(movie.Distributor),
+			nullableInt(movie.Budget),
+			nullableInt(movie.BudgetUSD),
+			nullableString(movie.MpaRating),
+			nullableJSON(boxOfficeJSON),
+			worldwideUSD(movie.BoxOffice),
+			movie.EnrichmentStatus,
+			string(sourcesJSON),
+			sqliteTime(now),
+			expectedVersion,
+		)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return notFoundOrConflict(ctx, tx, movie.ID)
+		}
+
+		if movie.EnrichmentStatus == model.EnrichmentComplete && movie.BoxOffice != nil {
+			return appendSQLiteSnapshot(ctx, tx, movie.ID, movie.BoxOffice, now)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	movie.Version = expectedVersion + 1
+	movie.UpdatedAt = now
+	return nil
+}
+
+func (r *SQLiteMovieRepository) GetByTitle(ctx context.Context, title string) (*model.Movie, error) {
+	query := `
+        SELECT ` + movieColumns + `
+        FROM movies
+        WHERE title_key = $1 AND deleted_at IS NULL
+    `
+
+	movie, err := scanSQLiteMovie(r.db.QueryRowContext(ctx, query, titleKey(title)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMovieNotFound
+		}
+		return nil, err
+	}
+
+	return movie, nil
+}
+
+func (r *SQLiteMovieRepository) GetByTitleIncludingDeleted(ctx context.Context, title string) (*model.Movie, error) {
+	query := `
+        SELECT ` + movieColumns + `
+        FROM movies
+        WHERE title_key = $1
+        ORDER BY deleted_at IS NOT NULL, deleted_at DESC
+        LIMIT 1
+    `
+
+	movie, err := scanSQLiteMovie(r.db.QueryRowContext(ctx, query, titleKey(title)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMovieNotFound
+		}
+		return nil, err
+	}
+
+	return movie, nil
+}
+
+// SoftDelete hides a movie from reads and frees its title for reuse. Ratings
+// are kept so that a restore brings them back.
+func (r *SQLiteMovieRepository) SoftDelete(ctx context.Context, movieID string, expectedVersion int64) error {
+	const query = `
+        UPDATE movies
+        SET deleted_at = $2,
+            version = version + 1,
+            updated_at = $2
+        WHERE id = $1 AND version = $3 AND deleted_at IS NULL
+    `
+
+	res, err := r.db.ExecContext(ctx, query, movieID, sqliteTime(sqliteNow()), expectedVersion)
+	if err != nil {
+		return err
+	}
+	return requireAffected(ctx, r.db, res, movieID)
+}
+
+// Delete permanently removes a movie; its ratings and history go with it via
+// ON DELETE CASCADE.
+func (r *SQLiteMovieRepository) Delete(ctx context.Context, movieID string, expectedVersion int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM movies WHERE id = $1 AND version = $2`, movieID, expectedVersion)
+	if err != nil {
+		return err
+	}
+	return requireAffected(ctx, r.db, res, movieID)
+}
+
+// Restore brings back the most recently soft-deleted movie with the given
+// title. It fails with ErrMovieAlreadyExists if the title has been reused.
+func (r *SQLiteMovieRepository) Restore(ctx context.Context, title string) (*model.Movie, error) {
+	query := `
+        UPDATE movies
+        SET deleted_at = NULL,
+            version = version + 1,
+            updated_at = $2
+        WHERE id = (
+            SELECT id
+            FROM movies
+            WHERE title_key = $1 AND deleted_at IS NOT NULL
+            ORDER BY deleted_at DESC
+            LIMIT 1
+        )
+        RETURNING ` + movieColumns
+
+	movie, err := scanSQLiteMovie(r.db.QueryRowContext(ctx, query, titleKey(title), sqliteTime(sqliteNow())))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrMovieNotFound
+		case isUniqueViolation(err):
+			return nil, ErrMovieAlreadyExists
+		}
+		return nil, err
+	}
+
+	return movie, nil
+}
+
+func (r *SQLiteMovieRepository) List(ctx context.Context, params MovieListParams) ([]*model.Movie, error) {
+	clauses, args, idx := sqliteMovieFilterClauses(params.MovieFilter, 1)
+
+	if params.After != nil {
+		clauses = append(clauses, fmt.Sprintf("(created_at > $%d OR (created_at = $%d AND id > $%d))", idx, idx, idx+1))
+		args = append(args, sqliteTime(params.After.CreatedAt), params.After.ID)
+		idx += 2
+	}
+
+	query := fmt.Sprintf(`
+        SELECT %s
+        FROM movies
+        WHERE %s
+        ORDER BY created_at ASC, id ASC
+        LIMIT $%d
+    `, movieColumns, strings.Join(clauses, " AND "), idx)
+	args = append(args, params.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movies []*model.Movie
+	for rows.Next() {
+		movie, err := scanSQLiteMovie(rows)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, movie)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return movies, nil
+}
+
+// scanSQLiteMovie is scanMovie for SQLite, whose timestamps and JSON columns
+// are stored as text.
+func scanSQLiteMovie(row rowScanner, extra ...interface{}) (*model.Movie, error) {
+	var (
+		movie        model.Movie
+		distributor  sql.NullString
+		budget       sql.NullInt64
+		budgetUSD    sql.NullInt64
+		mpaRating    sql.NullString
+		boxOfficeRaw sql.NullString
+		sourcesRaw   string
+	)
+
+	dest := []interface{}{
+		&movie.ID,
+		&movie.Title,
+		&movie.Genre,
+		sqliteTimeScanner{&movie.ReleaseDate},
+		&distributor,
+		&budget,
+		&budgetUSD,
+		&mpaRating,
+		&boxOfficeRaw,
+		&movie.EnrichmentStatus,
+		&sourcesRaw,
+		&movie.Version,
+		sqliteTimeScanner{&movie.CreatedAt},
+		sqliteTimeScanner{&movie.UpdatedAt},
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if distributor.Valid {
+		movie.Distributor = &distributor.String
+	}
+	if budget.Valid {
+		v := budget.Int64
+		movie.Budget = &v
+	}
+	if budgetUSD.Valid {
+		v := budgetUSD.Int64
+		movie.BudgetUSD = &v
+	}
+	if mpaRating.Valid {
+		movie.MpaRating = &mpaRating.String
+	}
+	if boxOfficeRaw.Valid {
+		boxOffice, err := unmarshalBoxOffice([]byte(boxOfficeRaw.String))
+		if err != nil {
+			return nil, err
+		}
+		movie.BoxOffice = boxOffice
+	}
+	if err := json.Unmarshal([]byte(sourcesRaw), &movie.Sources); err != nil {
+		return nil, err
+	}
+
+	return &movie, nil
+}
+
+// sqliteMovieFilterClauses is movieFilterClauses for SQLite. The search and
+// title comparisons go through title_key, as SQLite's LIKE and LOWER only
+// fold ASCII; backslash escapes wildcards as in Postgres.
+func sqliteMovieFilterClauses(filter MovieFilter, idx int) ([]string, []interface{}, int) {
+	var (
+		clauses = []string{"deleted_at IS NULL"}
+		args    []interface{}
+	)
+
+	if filter.Q != "" {
+		clauses = append(clauses, fmt.Sprintf(`title_key LIKE '%%' || $%d || '%%' ESCAPE '\'`, idx))
+		args = append(args, titleKey(filter.Q))
+		idx++
+	}
+
+	if filter.Year != nil {
+		clauses = append(clauses, fmt.Sprintf("CAST(substr(release_date, 1, 4) AS INTEGER) = $%d", idx))
+		args = append(args, *filter.Year)
+		idx++
+	}
+
+	if filter.Genre != nil && *filter.Genre != "" {
+		clauses = append(clauses, fmt.Sprintf("LOWER(genre) = LOWER($%d)", idx))
+		args = append(args, *filter.Genre)
+		idx++
+	}
+
+	if filter.Distributor != nil && *filter.Distributor != "" {
+		clauses = append(clauses, fmt.Sprintf("LOWER(distributor) = LOWER($%d)", idx))
+		args = append(args, *filter.Distributor)
+		idx++
+	}
+
+	if filter.BudgetLTE != nil {
+		clauses = append(clauses, fmt.Sprintf("budget_usd IS NOT NULL AND budget_usd <= $%d", idx))
+		args = append(args, *filter.BudgetLTE)
+		idx++
+	}
+
+	if filter.RevenueGTE != nil {
+		clauses = append(clauses, fmt.Sprintf("worldwide_usd >= $%d", idx))
+		args = append(args, *filter.RevenueGTE)
+		idx++
+	}
+
+	if filter.MpaRating != nil && *filter.MpaRating != "" {
+		clauses = append(clauses, fmt.Sprintf("LOWER(mpa_rating) = LOWER($%d)", idx))
+		args = append(args, *filter.MpaRating)
+		idx++
+	}
+
+	return clauses, args, idx
+}
+
+// titleKey folds a title the way Postgres' LOWER does.
+func titleKey(title string) string {
+	return strings.ToLower(title)
+}
+
+// nullableJSON stores a marshalled document as text, or NULL when absent.
+func nullableJSON(raw []byte) interface{} {
+	if raw == nil {
+		return nil
+	}
+	return string(raw)
+}
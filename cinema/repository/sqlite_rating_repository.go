@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"cinema/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SQLiteRatingRepository stores ratings in SQLite. There is no
+// movie_rating_stats table: aggregates are computed from ratings on read,
+// which is cheap at the sizes SQLite deployments are meant for.
+type SQLiteRatingRepository struct {
+	db dbtx
+}
+
+func NewSQLiteRatingRepository(db *sql.DB) *SQLiteRatingRepository {
+	return &SQLiteRatingRepository{db: db}
+}
+
+// Upsert reports creation by comparing the row's timestamps: an update moves
+// updated_at past created_at, since sqliteNow never repeats.
+func (r *SQLiteRatingRepository) Upsert(ctx context.Context, rating *model.Rating) (bool, error) {
+	const query = `
+        INSERT INTO ratings (movie_id, rater_id, rating, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $4)
+        ON CONFLICT (movie_id, rater_id)
+        DO UPDATE SET rating = excluded.rating, updated_at = excluded.updated_at
+        RETURNING created_at = updated_at
+    `
+
+	var created bool
+	err := r.db.QueryRowContext(ctx, query, rating.MovieID, rating.RaterID, rating.Value, sqliteTime(sqliteNow())).Scan(&created)
+	if err != nil {
+		return false, err
+	}
+	return created, nil
+}
+
+func (r *SQLiteRatingRepository) Get(ctx context.Context, movieID, raterID string) (*model.Rating, error) {
+	const query = `
+        SELECT movie_id, rater_id, rating, created_at, updated_at
+        FROM ratings
+        WHERE movie_id = $1 AND rater_id = $2
+    `
+
+	rating, err := scanSQLiteRating(r.db.QueryRowContext(ctx, query, movieID, raterID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRatingNotFound
+		}
+		return nil, err
+	}
+
+	return rating, nil
+}
+
+func (r *SQLiteRatingRepository) Delete(ctx context.Context, movieID, raterID string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM ratings WHERE movie_id = $1 AND rater_id = $2`, movieID, raterID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrRatingNotFound
+	}
+	return nil
+}
+
+// List pages through a movie's ratings in (created_at, rater_id) order, the
+// same keyset scheme used for movies.
+func (r *SQLiteRatingRepository) List(ctx context.Context, params RatingListParams) ([]*model.Rating, error) {
+	query := `
+        SELECT movie_id, rater_id, rating, created_at, updated_at
+        FROM ratings
+        WHERE movie_id = $1
+    `
+	args := []interface{}{params.MovieID}
+
+	if params.After != nil {
+		query += `AND (created_at > $2 OR (created_at = $2 AND rater_id > $3))
+        ORDER BY created_at ASC, rater_id ASC
+        LIMIT $4`
+		args = append(args, sqliteTime(params.After.CreatedAt), params.After.RaterID, params.Limit)
+	} else {
+		query += `ORDER BY created_at ASC, rater_id ASC
+        LIMIT $2`
+		args = append(args, params.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ratings []*model.Rating
+	for rows.Next() {
+		rating, err := scanSQLiteRating(rows)
+		if err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, rating)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ratings, nil
+}
+
+func (r *SQLiteRatingRepository) AggregateByMovieID(ctx context.Context, movieID string) (float64, int, error) {
+	const query = `
+        SELECT COALESCE(AVG(rating), 0), COUNT(*)
+        FROM ratings
+        WHERE movie_id = $1
+    `
+
+	var (
+		average float64
+		count   int
+	)
+
+	if err := r.db.QueryRowContext(ctx, query, movieID).Scan(&average, &count); err != nil {
+		return 0, 0, err
+	}
+
+	return average, count, nil
+}
+
+func (r *SQLiteRatingRepository) HistogramByMovieID(ctx context.Context, movieID string) (model.RatingHistogram, error) {
+	const query = `
+        SELECT rating, COUNT(*)
+        FROM ratings
+        WHERE movie_id = $1
+        GROUP BY rating
+    `
+
+	var histogram model.RatingHistogram
+
+	rows, err := r.db.QueryContext(ctx, query, movieID)
+	if err != nil {
+		return histogram, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			value float64
+			count int
+		)
+		if err := rows.Scan(&value, &count); err != nil {
+			return histogram, err
+		}
+		if bucket := model.RatingBucket(value); bucket >= 0 && bucket < len(histogram) {
+			histogram[bucket] = count
+		}
+	}
+
+	return histogram, rows.Err()
+}
+
+func (r *SQLiteRatingRepository) TopRated(ctx context.Context, params TopRatedParams) ([]*model.RankedMovie, error) {
+	clauses, args, idx := sqliteMovieFilterClauses(params.MovieFilter, 3)
+	args = append([]interface{}{params.PriorMean, params.MinVotes}, args...)
+
+	// Ratings of soft-deleted movies count towards the global mean, as they do
+	// in movie_rating_stats on Postgres.
+	query := fmt.Sprintf(`
+        WITH prior AS (
+            SELECT COALESCE($1, AVG(rating), 0) AS mean
+            FROM ratings
+        ),
+        stats AS (
+            SELECT movie_id, AVG(rating) AS average, COUNT(*) AS votes
+            FROM ratings
+            GROUP BY movie_id
+        )
+        SELECT %s, stats.average, stats.votes,
+               (stats.votes * stats.average + $2 * prior.mean) / (stats.votes + $2) AS score
+        FROM movies
+        JOIN stats ON stats.movie_id = movies.id
+        CROSS JOIN prior
+        WHERE %s
+        ORDER BY score DESC, stats.votes DESC, movies.id ASC
+        LIMIT $%d
+    `, movieColumns, strings.Join(clauses, " AND "), idx)
+	args = append(args, params.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranked []*model.RankedMovie
+	for rows.Next() {
+		entry := &model.RankedMovie{}
+		movie, err := scanSQLiteMovie(rows, &entry.Average, &entry.Count, &entry.Score)
+		if err != nil {
+			return nil, err
+		}
+		entry.Movie = movie
+		ranked = append(ranked, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ranked, nil
+}
+
+func scanSQLiteRating(row rowScanner) (*model.Rating, error) {
+	var rating model.Rating
+	if err := row.Scan(
+		&rating.MovieID,
+		&rating.RaterID,
+		&rating.Value,
+		sqliteTimeScanner{&rating.CreatedAt},
+		sqliteTimeScanner{&rating.UpdatedAt},
+	); err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Repositories are the repositories available inside a unit of work.
+type Repositories struct {
+	Movies  MovieRepository
+	Ratings RatingRepository
+}
+
+// UnitOfWork composes repository operations atomically. WithTx runs fn with
+// repositories bound to a single transaction, which commits when fn returns
+// nil and rolls back when it returns an error or panics. Movies read through
+// repos.Movies stay locked until the transaction ends, so a write that
+// depends on a lookup cannot race with a concurrent rename or delete; fn
+// should therefore not wait on anything slow, such as a box office lookup.
+type UnitOfWork interface {
+	WithTx(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+// dbtx is what the SQL repositories need from *sql.DB and *sql.Tx, so that
+// the same repository code runs standalone or inside a unit of work.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// inTx runs fn in a transaction of its own, or in the enclosing one when db
+// is already bound to a unit of work.
+func inTx(ctx context.Context, db dbtx, fn func(tx dbtx) error) error {
+	beginner, ok := db.(txBeginner)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type PostgresUnitOfWork struct {
+	db *sql.DB
+}
+
+func NewPostgresUnitOfWork(db *sql.DB) *PostgresUnitOfWork {
+	return &PostgresUnitOfWork{db: db}
+}
+
+// WithTx locks the movies read in fn with SELECT ... FOR UPDATE.
+func (u *PostgresUnitOfWork) WithTx(ctx context.Context, fn func(repos Repositories) error) error {
+	return inTx(ctx, u.db, func(tx dbtx) error {
+		return fn(Repositories{
+			Movies:  &PostgresMovieRepository{db: tx, lockReads: true},
+			Ratings: &PostgresRatingRepository{db: tx},
+		})
+	})
+}
+
+type SQLiteUnitOfWork struct {
+	db *sql.DB
+}
+
+func NewSQLiteUnitOfWork(db *sql.DB) *SQLiteUnitOfWork {
+	return &SQLiteUnitOfWork{db: db}
+}
+
+// WithTx needs no row locks: the transaction holds the only connection, so
+// nothing else reaches the database until it ends.
+func (u *SQLiteUnitOfWork) WithTx(ctx context.Context, fn func(repos Repositories) error) error {
+	return inTx(ctx, u.db, func(tx dbtx) error {
+		return fn(Repositories{
+			Movies:  &SQLiteMovieRepository{db: tx},
+			Ratings: &SQLiteRatingRepository{db: tx},
+		})
+	})
+}
+
+type MemoryUnitOfWork struct {
+	store *MemoryStore
+}
+
+func NewMemoryUnitOfWork(store *MemoryStore) *MemoryUnitOfWork {
+	return &MemoryUnitOfWork{store: store}
+}
+
+// WithTx holds the store's write lock for the duration of fn, which writes
+// to the store directly. The rows it touches are logged first and restored
+// if fn fails or panics.
+func (u *MemoryUnitOfWork) WithTx(ctx context.Context, fn func(repos Repositories) error) error {
+	s := u.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	work := s.view()
+	committed := false
+	defer func() {
+		if !committed {
+			work.rollback()
+		}
+	}()
+
+	if err := fn(Repositories{
+		Movies:  NewMemoryMovieRepository(work),
+		Ratings: NewMemoryRatingRepository(work),
+	}); err != nil {
+		return err
+	}
+
+	committed = true
+	s.last = work.last
+	return nil
+}
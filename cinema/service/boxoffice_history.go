@@ -0,0 +1,109 @@
+package service
+
+import (
+	"cinema/model"
+	"cinema/repository"
+	"context"
+	"time"
+)
+
+// HistoryInterval selects how box office snapshots are grouped for charting.
+type HistoryInterval string
+
+const (
+	HistoryRaw    HistoryInterval = "raw"
+	HistoryDaily  HistoryInterval = "daily"
+	HistoryWeekly HistoryInterval = "weekly"
+)
+
+// BoxOfficeHistoryParams filters a movie's box office history. From and To
+// are calendar dates in UTC and both are inclusive.
+type BoxOfficeHistoryParams struct {
+	From     *time.Time
+	To       *time.Time
+	Interval HistoryInterval
+}
+
+// BoxOfficeHistoryPoint is one snapshot, or the last snapshot of a bucket.
+// For buckets At is the start of the day or ISO week (Monday, UTC) and
+// Snapshots counts the lookups that fell into it.
+type BoxOfficeHistoryPoint struct {
+	At time.Time
+	model.BoxOffice
+	Snapshots int
+}
+
+type BoxOfficeHistoryService struct {
+	movieRepo    repository.MovieRepository
+	snapshotRepo repository.BoxOfficeSnapshotRepository
+}
+
+func NewBoxOfficeHistoryService(movieRepo repository.MovieRepository, snapshotRepo repository.BoxOfficeSnapshotRepository) *BoxOfficeHistoryService {
+	return &BoxOfficeHistoryService{movieRepo: movieRepo, snapshotRepo: snapshotRepo}
+}
+
+func (s *BoxOfficeHistoryService) History(ctx context.Context, title string, params BoxOfficeHistoryParams) (*model.Movie, []BoxOfficeHistoryPoint, error) {
+	if params.Interval == "" {
+		params.Interval = HistoryRaw
+	}
+	if params.Interval != HistoryRaw && params.Interval != HistoryDaily && params.Interval != HistoryWeekly {
+		return nil, nil, ErrInvalidInput
+	}
+	if params.From != nil && params.To != nil && params.From.After(*params.To) {
+		return nil, nil, ErrInvalidInput
+	}
+
+	movie, err := s.movieRepo.GetByTitle(ctx, title)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var from, to *time.Time
+	if params.From != nil {
+		start := truncateDay(*params.From)
+		from = &start
+	}
+	if params.To != nil {
+		end := truncateDay(*params.To).AddDate(0, 0, 1)
+		to = &end
+	}
+
+	snapshots, err := s.snapshotRepo.ListSnapshots(ctx, movie.ID, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return movie, bucketSnapshots(snapshots, params.Interval), nil
+}
+
+// bucketSnapshots keeps the last snapshot of every bucket. Revenue figures are
+// cumulative, so the latest one is the bucket's closing value.
+func bucketSnapshots(snapshots []model.BoxOfficeSnapshot, interval HistoryInterval) []BoxOfficeHistoryPoint {
+	points := make([]BoxOfficeHistoryPoint, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		var start time.Time
+		switch interval {
+		case HistoryDaily:
+			start = truncateDay(snapshot.RecordedAt)
+		case HistoryWeekly:
+			day := truncateDay(snapshot.RecordedAt)
+			start = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		default:
+			points = append(points, BoxOfficeHistoryPoint{At: snapshot.RecordedAt, BoxOffice: snapshot.BoxOffice, Snapshots: 1})
+			continue
+		}
+
+		if n := len(points); n > 0 && points[n-1].At.Equal(start) {
+			points[n-1].BoxOffice = snapshot.BoxOffice
+			points[n-1].Snapshots++
+			continue
+		}
+		points = append(points, BoxOfficeHistoryPoint{At: start, BoxOffice: snapshot.BoxOffice, Snapshots: 1})
+	}
+	return points
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
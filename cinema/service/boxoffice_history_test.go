@@ -0,0 +1,68 @@
+package service
+
+import (
+	"cinema/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubSnapshotRepository struct {
+	snapshots []model.BoxOfficeSnapshot
+	from, to  *time.Time
+}
+
+func (r *stubSnapshotRepository) ListSnapshots(ctx context.Context, movieID string, from, to *time.Time) ([]model.BoxOfficeSnapshot, error) {
+	r.from, r.to = from, to
+	return r.snapshots, nil
+}
+
+func snapshotAt(recordedAt string, worldwide int64) model.BoxOfficeSnapshot {
+	at, _ := time.Parse(time.RFC3339, recordedAt)
+	return model.BoxOfficeSnapshot{BoxOffice: model.BoxOffice{Revenue: model.BoxOfficeRevenue{Worldwide: worldwide}, Currency: "USD"}, RecordedAt: at}
+}
+
+func TestBoxOfficeHistory_BucketsKeepLastSnapshot(t *testing.T) {
+	movies := newMemoryMovieRepository()
+	seedMovie(t, movies, &model.Movie{ID: "m_1", Title: "Inception"})
+	snapshots := &stubSnapshotRepository{snapshots: []model.BoxOfficeSnapshot{
+		snapshotAt("2024-05-05T10:00:00Z", 100), // Sunday
+		snapshotAt("2024-05-06T09:00:00Z", 150), // Monday
+		snapshotAt("2024-05-06T21:00:00Z", 180),
+		snapshotAt("2024-05-08T12:00:00Z", 200),
+	}}
+	service := NewBoxOfficeHistoryService(movies, snapshots)
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)
+	_, daily, err := service.History(context.Background(), "Inception", BoxOfficeHistoryParams{From: &from, To: &to, Interval: HistoryDaily})
+	if err != nil {
+		t.Fatalf("History returned error: %v", err)
+	}
+	if !snapshots.to.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the to date to be inclusive, got upper bound %v", snapshots.to)
+	}
+	if len(daily) != 3 || daily[1].Revenue.Worldwide != 180 || daily[1].Snapshots != 2 {
+		t.Fatalf("unexpected daily buckets %+v", daily)
+	}
+
+	_, weekly, err := service.History(context.Background(), "Inception", BoxOfficeHistoryParams{Interval: HistoryWeekly})
+	if err != nil {
+		t.Fatalf("History returned error: %v", err)
+	}
+	if len(weekly) != 2 {
+		t.Fatalf("expected 2 weekly buckets, got %+v", weekly)
+	}
+	if !weekly[0].At.Equal(time.Date(2024, 4, 29, 0, 0, 0, 0, time.UTC)) || weekly[0].Revenue.Worldwide != 100 {
+		t.Fatalf("unexpected first week %+v", weekly[0])
+	}
+	if !weekly[1].At.Equal(time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)) || weekly[1].Revenue.Worldwide != 200 || weekly[1].Snapshots != 3 {
+		t.Fatalf("unexpected second week %+v", weekly[1])
+	}
+
+	_, _, err = service.History(context.Background(), "Inception", BoxOfficeHistoryParams{From: &to, To: &from})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput for an inverted range, got %v", err)
+	}
+}
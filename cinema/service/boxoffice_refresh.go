@@ -0,0 +1,175 @@
+package service
+
+import (
+	"cinema/boxoffice"
+	"cinema/model"
+	"cinema/repository"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+type BoxOfficeRefreshOptions struct {
+	// MaxAge is how old a box office check may be before it is refreshed.
+	// Defaults to 7 days.
+	MaxAge time.Duration
+	// Interval is the pause between runs when scheduled. Defaults to 1h.
+	Interval time.Duration
+	// BatchSize is the number of movies loaded, and checkpointed, at a time.
+	// Defaults to 50.
+	BatchSize int
+	// Concurrency caps simultaneous lookups. Defaults to 2.
+	Concurrency int
+	// RatePerSecond caps lookups per second across all workers. Defaults to 2.
+	RatePerSecond float64
+}
+
+// BoxOfficeRefresher re-fetches box office data that is missing or older than
+// MaxAge. Progress is checkpointed after every batch so an interrupted run
+// resumes where it stopped.
+type BoxOfficeRefresher struct {
+	repo   repository.BoxOfficeRefreshRepository
+	client boxoffice.Client
+	opts   BoxOfficeRefreshOptions
+	now    func() time.Time
+}
+
+func NewBoxOfficeRefresher(repo repository.BoxOfficeRefreshRepository, client boxoffice.Client, opts BoxOfficeRefreshOptions) *BoxOfficeRefresher {
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = 7 * 24 * time.Hour
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Hour
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 2
+	}
+	if opts.RatePerSecond <= 0 {
+		opts.RatePerSecond = 2
+	}
+
+	return &BoxOfficeRefresher{repo: repo, client: client, opts: opts, now: time.Now}
+}
+
+// Run refreshes stale records every Interval until ctx is cancelled.
+func (r *BoxOfficeRefresher) Run(ctx context.Context) {
+	for {
+		run, err := r.RunOnce(ctx)
+		switch {
+		case errors.Is(err, repository.ErrRefreshRunInProgress):
+			// Another instance is on it.
+		case errors.Is(err, repository.ErrRefreshRunLost):
+			log.Printf("box office refresh run %d stopped: another instance took it over", run.ID)
+		case err != nil && ctx.Err() == nil:
+			log.Printf("box office refresh failed: %v", err)
+		case err == nil:
+			log.Printf("box office refresh run %d finished: %d refreshed, %d not found, %d failed", run.ID, run.Refreshed, run.NotFound, run.Failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.opts.Interval):
+		}
+	}
+}
+
+// RunOnce claims a refresh run, resuming an interrupted one if there is one,
+// and works through it to the end.
+func (r *BoxOfficeRefresher) RunOnce(ctx context.Context) (*model.BoxOfficeRefreshRun, error) {
+	// The lease must outlive one batch at the configured rate.
+	lease := time.Duration(float64(r.opts.BatchSize)/r.opts.RatePerSecond*float64(time.Second)) + 5*time.Minute
+
+	run, err := r.repo.ClaimRun(ctx, r.now().Add(-r.opts.MaxAge), lease)
+	if err != nil {
+		return nil, err
+	}
+
+	tick := time.NewTicker(time.Duration(float64(time.Second) / r.opts.RatePerSecond))
+	defer tick.Stop()
+
+	for {
+		movies, err := r.repo.ListStale(ctx, run, r.opts.BatchSize)
+		if err != nil {
+			return run, err
+		}
+		if len(movies) == 0 {
+			return run, r.repo.FinishRun(ctx, run)
+		}
+
+		if err := r.refreshBatch(ctx, run, movies, tick.C); err != nil {
+			return run, err
+		}
+
+		last := movies[len(movies)-1]
+		run.CursorCreatedAt = &last.CreatedAt
+		run.CursorID = last.ID
+		if err := r.repo.SaveProgress(ctx, run, lease); err != nil {
+			return run, err
+		}
+	}
+}
+
+// refreshBatch looks up every movie in the batch with at most Concurrency
+// lookups in flight, each waiting for a tick of the rate limiter.
+func (r *BoxOfficeRefresher) refreshBatch(ctx context.Context, run *model.BoxOfficeRefreshRun, movies []*model.Movie, tick <-chan time.Time) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, r.opts.Concurrency)
+	)
+
+	for _, movie := range movies {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		case <-tick:
+		}
+		sem <- struct{}{}
+
+		wg.Add(1)
+		go func(movie *model.Movie) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			record, err := r.client.Fetch(boxoffice.WithReleaseYear(ctx, movie.ReleaseDate.Year()), movie.Title)
+			enrichment := enrichmentFromLookup(record, err)
+			if enrichment.Status == model.EnrichmentFailed {
+				// Left unchecked so the next run tries again.
+				log.Printf("box office refresh of %q failed: %v", movie.Title, err)
+			} else {
+				err = r.repo.ApplyEnrichment(ctx, movie.ID, movie.Version, enrichment)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case enrichment.Status == model.EnrichmentFailed:
+				run.Failed++
+			case errors.Is(err, repository.ErrMovieConflict):
+				// Edited during the lookup; left unchecked so the next run
+				// tries again.
+				log.Printf("box office refresh of %q skipped: the movie changed during the lookup", movie.Title)
+				run.Failed++
+			case err != nil:
+				errs = append(errs, err)
+			case enrichment.Status == model.EnrichmentNotFound:
+				run.NotFound++
+			default:
+				run.Refreshed++
+			}
+		}(movie)
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"cinema/boxoffice"
+	"cinema/model"
+	"cinema/repository"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type stubRefreshRepository struct {
+	mu       sync.Mutex
+	movies   []*model.Movie
+	run      *model.BoxOfficeRefreshRun
+	applied  map[string]string
+	edited   map[string]bool
+	saves    int
+	finished bool
+}
+
+func (r *stubRefreshRepository) ClaimRun(ctx context.Context, staleBefore time.Time, lease time.Duration) (*model.BoxOfficeRefreshRun, error) {
+	if r.run == nil {
+		r.run = &model.BoxOfficeRefreshRun{ID: 1, StaleBefore: staleBefore}
+	}
+	copied := *r.run
+	return &copied, nil
+}
+
+func (r *stubRefreshRepository) ListStale(ctx context.Context, run *model.BoxOfficeRefreshRun, limit int) ([]*model.Movie, error) {
+	var stale []*model.Movie
+	for _, movie := range r.movies {
+		if run.CursorCreatedAt != nil && !movie.CreatedAt.After(*run.CursorCreatedAt) {
+			continue
+		}
+		if len(stale) == limit {
+			break
+		}
+		stale = append(stale, movie)
+	}
+	return stale, nil
+}
+
+func (r *stubRefreshRepository) ApplyEnrichment(ctx context.Context, movieID string, expectedVersion int64, enrichment model.Enrichment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.edited[movieID] {
+		return repository.ErrMovieConflict
+	}
+	r.applied[movieID] = enrichment.Status
+	return nil
+}
+
+func (r *stubRefreshRepository) SaveProgress(ctx context.Context, run *model.BoxOfficeRefreshRun, lease time.Duration) error {
+	r.saves++
+	copied := *run
+	r.run = &copied
+	return nil
+}
+
+func (r *stubRefreshRepository) FinishRun(ctx context.Context, run *model.BoxOfficeRefreshRun) error {
+	r.finished = true
+	return nil
+}
+
+type titleBoxOfficeClient map[string]error
+
+func (c titleBoxOfficeClient) Fetch(ctx context.Context, title string) (*boxoffice.Record, error) {
+	if err, ok := c[title]; ok {
+		return nil, err
+	}
+	return &boxoffice.Record{Revenue: boxoffice.Revenue{Worldwide: 1}}, nil
+}
+
+func TestBoxOfficeRefresher_ResumesFromCursorAndCountsOutcomes(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	repo := &stubRefreshRepository{applied: map[string]string{}, edited: map[string]bool{"Edited": true}}
+	for i, title := range []string{"Already Done", "Heat", "Unknown", "Flaky", "Edited", "Ronin"} {
+		repo.movies = append(repo.movies, &model.Movie{ID: title, Title: title, CreatedAt: base.Add(time.Duration(i) * time.Minute)})
+	}
+
+	// An interrupted run already handled the first movie.
+	repo.run = &model.BoxOfficeRefreshRun{ID: 7, CursorCreatedAt: &repo.movies[0].CreatedAt, CursorID: "Already Done", Refreshed: 1}
+
+	client := titleBoxOfficeClient{"Unknown": boxoffice.ErrNotFound, "Flaky": errors.New("timeout")}
+	refresher := NewBoxOfficeRefresher(repo, client, BoxOfficeRefreshOptions{BatchSize: 2, Concurrency: 2, RatePerSecond: 1000})
+
+	run, err := refresher.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce returned error: %v", err)
+	}
+
+	if _, ok := repo.applied["Already Done"]; ok {
+		t.Fatal("expected the resumed run to skip movies before the cursor")
+	}
+	if _, ok := repo.applied["Flaky"]; ok {
+		t.Fatal("expected failed lookups to be left unchecked")
+	}
+	if _, ok := repo.applied["Edited"]; ok {
+		t.Fatal("expected movies edited during the lookup to be left unchecked")
+	}
+	if repo.applied["Heat"] != model.EnrichmentComplete || repo.applied["Unknown"] != model.EnrichmentNotFound {
+		t.Fatalf("unexpected applied statuses %v", repo.applied)
+	}
+	if run.ID != 7 || run.Refreshed != 3 || run.NotFound != 1 || run.Failed != 2 {
+		t.Fatalf("unexpected run counters %+v", run)
+	}
+	if repo.saves != 3 || !repo.finished {
+		t.Fatalf("expected a checkpoint per batch and a finished run, got %d saves (finished=%v)", repo.saves, repo.finished)
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"cinema/model"
+	"cinema/repository"
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Resolutions accepted by DataConflictService.Resolve.
+const (
+	AcceptOurs   = "ours"
+	AcceptTheirs = "theirs"
+)
+
+// DataConflictService lets admins review and settle disagreements between
+// stored movies and the box office provider.
+type DataConflictService struct {
+	movieRepo    repository.MovieRepository
+	conflictRepo repository.DataConflictRepository
+}
+
+type ListConflictsParams struct {
+	// Status defaults to model.ConflictOpen.
+	Status     string
+	MovieTitle string
+	Limit      int
+	Cursor     string
+}
+
+func NewDataConflictService(movieRepo repository.MovieRepository, conflictRepo repository.DataConflictRepository) *DataConflictService {
+	return &DataConflictService{movieRepo: movieRepo, conflictRepo: conflictRepo}
+}
+
+func (s *DataConflictService) List(ctx context.Context, params ListConflictsParams) ([]*model.DataConflict, *string, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	listParams := repository.DataConflictListParams{
+		Status: params.Status,
+		Limit:  limit + 1,
+	}
+	switch listParams.Status {
+	case "":
+		listParams.Status = model.ConflictOpen
+	case model.ConflictOpen, model.ConflictAcceptedOurs, model.ConflictAcceptedTheirs, model.ConflictStale:
+	default:
+		return nil, nil, ErrInvalidInput
+	}
+
+	if title := strings.TrimSpace(params.MovieTitle); title != "" {
+		movie, err := s.movieRepo.GetByTitle(ctx, title)
+		if err != nil {
+			return nil, nil, err
+		}
+		listParams.MovieID = movie.ID
+	}
+
+	if params.Cursor != "" {
+		createdAt, rawID, err := decodeKeysetCursor(params.Cursor)
+		if err != nil {
+			return nil, nil, ErrInvalidInput
+		}
+		id, err := strconv.ParseInt(rawID, 10, 64)
+		if err != nil {
+			return nil, nil, ErrInvalidInput
+		}
+		listParams.After = &repository.DataConflictCursor{CreatedAt: createdAt, ID: id}
+	}
+
+	conflicts, err := s.conflictRepo.List(ctx, listParams)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var nextCursor *string
+	if len(conflicts) > limit {
+		conflicts = conflicts[:limit]
+		last := conflicts[len(conflicts)-1]
+		encoded, err := encodeKeysetCursor(last.CreatedAt, strconv.FormatInt(last.ID, 10))
+		if err != nil {
+			return nil, nil, err
+		}
+		nextCursor = &encoded
+	}
+
+	return conflicts, nextCursor, nil
+}
+
+// Resolve settles an open conflict by keeping our value (AcceptOurs) or by
+// writing the provider's value to the movie (AcceptTheirs).
+func (s *DataConflictService) Resolve(ctx context.Context, id int64, resolution string) (*model.DataConflict, error) {
+	var status string
+	switch resolution {
+	case AcceptOurs:
+		status = model.ConflictAcceptedOurs
+	case AcceptTheirs:
+		status = model.ConflictAcceptedTheirs
+	default:
+		return nil, ErrInvalidInput
+	}
+
+	return s.conflictRepo.Resolve(ctx, id, status)
+}
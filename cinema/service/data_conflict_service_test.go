@@ -0,0 +1,77 @@
+package service
+
+import (
+	"cinema/model"
+	"cinema/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubDataConflictRepository struct {
+	conflicts []*model.DataConflict
+	listed    repository.DataConflictListParams
+	resolved  string
+}
+
+func (r *stubDataConflictRepository) List(ctx context.Context, params repository.DataConflictListParams) ([]*model.DataConflict, error) {
+	r.listed = params
+	var result []*model.DataConflict
+	for _, conflict := range r.conflicts {
+		if params.After != nil && conflict.ID <= params.After.ID {
+			continue
+		}
+		if len(result) == params.Limit {
+			break
+		}
+		result = append(result, conflict)
+	}
+	return result, nil
+}
+
+func (r *stubDataConflictRepository) Resolve(ctx context.Context, id int64, status string) (*model.DataConflict, error) {
+	r.resolved = status
+	return &model.DataConflict{ID: id, Status: status}, nil
+}
+
+func TestDataConflictService_ListPaginatesOpenConflicts(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	conflicts := &stubDataConflictRepository{}
+	for id := int64(1); id <= 3; id++ {
+		conflicts.conflicts = append(conflicts.conflicts, &model.DataConflict{ID: id, Field: model.ConflictBudget, CreatedAt: created.Add(time.Duration(id) * time.Minute)})
+	}
+	svc := NewDataConflictService(newMemoryMovieRepository(), conflicts)
+
+	page, cursor, err := svc.List(context.Background(), ListConflictsParams{Limit: 2})
+	if err != nil || len(page) != 2 || cursor == nil {
+		t.Fatalf("expected a first page of 2 with a cursor, got %d, %v, %v", len(page), cursor, err)
+	}
+	if conflicts.listed.Status != model.ConflictOpen {
+		t.Fatalf("expected open conflicts by default, got %q", conflicts.listed.Status)
+	}
+
+	page, cursor, err = svc.List(context.Background(), ListConflictsParams{Limit: 2, Cursor: *cursor})
+	if err != nil || len(page) != 1 || page[0].ID != 3 || cursor != nil {
+		t.Fatalf("expected the last conflict without a cursor, got %+v, %v, %v", page, cursor, err)
+	}
+
+	if _, _, err := svc.List(context.Background(), ListConflictsParams{Status: "closed"}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput for an unknown status, got %v", err)
+	}
+	if _, _, err := svc.List(context.Background(), ListConflictsParams{MovieTitle: "Missing"}); !errors.Is(err, repository.ErrMovieNotFound) {
+		t.Fatalf("expected ErrMovieNotFound for an unknown movie, got %v", err)
+	}
+}
+
+func TestDataConflictService_ResolveMapsResolutions(t *testing.T) {
+	conflicts := &stubDataConflictRepository{}
+	svc := NewDataConflictService(newMemoryMovieRepository(), conflicts)
+
+	if _, err := svc.Resolve(context.Background(), 1, AcceptTheirs); err != nil || conflicts.resolved != model.ConflictAcceptedTheirs {
+		t.Fatalf("expected accepted_theirs, got %q, %v", conflicts.resolved, err)
+	}
+	if _, err := svc.Resolve(context.Background(), 1, "mine"); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+}
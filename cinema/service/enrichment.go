@@ -0,0 +1,268 @@
+package service
+
+import (
+	"cinema/boxoffice"
+	"cinema/model"
+	"cinema/repository"
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type EnrichmentWorkerOptions struct {
+	// Concurrency is the number of jobs processed in parallel. Defaults to 4.
+	Concurrency int
+	// PollInterval is how long an idle worker waits before checking for new
+	// jobs. Defaults to 1s.
+	PollInterval time.Duration
+	// MaxAttempts bounds lookups per job before the movie is marked failed.
+	// Defaults to 8.
+	MaxAttempts int
+	// BaseBackoff and MaxBackoff bound the jittered exponential delay between
+	// attempts. They default to 30s and 1h.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// EnrichmentWorker drains the enrichment job queue written by CreateMovie in
+// EnrichAsync mode. Any number of instances may run against the same
+// database.
+type EnrichmentWorker struct {
+	jobs   repository.EnrichmentJobRepository
+	client boxoffice.Client
+	opts   EnrichmentWorkerOptions
+	now    func() time.Time
+}
+
+func NewEnrichmentWorker(jobs repository.EnrichmentJobRepository, client boxoffice.Client, opts EnrichmentWorkerOptions) *EnrichmentWorker {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = 30 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+
+	return &EnrichmentWorker{jobs: jobs, client: client, opts: opts, now: time.Now}
+}
+
+// Run processes jobs until ctx is cancelled. Jobs already claimed are
+// finished first, so Run returning means the worker has drained.
+func (w *EnrichmentWorker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < w.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *EnrichmentWorker) loop(ctx context.Context) {
+	for ctx.Err() == nil {
+		// A claimed job runs to completion even if ctx is cancelled meanwhile;
+		// the repository bounds how long it may take.
+		processed, err := w.jobs.ProcessNext(context.WithoutCancel(ctx), w.handle)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("enrichment job failed: %v", err)
+		}
+		if processed && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.opts.PollInterval):
+		}
+	}
+}
+
+func (w *EnrichmentWorker) handle(ctx context.Context, job model.EnrichmentJob) repository.EnrichmentOutcome {
+	record, err := w.client.Fetch(boxoffice.WithReleaseYear(ctx, job.ReleaseDate.Year()), job.Title)
+	enrichment := enrichmentFromLookup(record, err)
+	if enrichment.Status != model.EnrichmentFailed || job.Attempts+1 >= w.opts.MaxAttempts {
+		if enrichment.Status == model.EnrichmentFailed {
+			log.Printf("giving up enriching movie %s after %d attempts: %v", job.MovieID, job.Attempts+1, err)
+		}
+		return repository.EnrichmentOutcome{Enrichment: enrichment}
+	}
+
+	retryAt := w.now().Add(w.backoff(job.Attempts))
+	outcome := repository.EnrichmentOutcome{RetryAt: &retryAt, Error: "empty box office response"}
+	if err != nil {
+		outcome.Error = err.Error()
+	}
+	return outcome
+}
+
+func (w *EnrichmentWorker) backoff(attempts int) time.Duration {
+	ceiling := w.opts.BaseBackoff << attempts
+	if ceiling <= 0 || ceiling > w.opts.MaxBackoff {
+		ceiling = w.opts.MaxBackoff
+	}
+	// Equal jitter: at least half the ceiling so retries still back off.
+	return ceiling/2 + rand.N(ceiling/2+1)
+}
+
+// enrichmentFromLookup converts a provider answer into the enrichment to
+// apply to a movie.
+func enrichmentFromLookup(record *boxoffice.Record, err error) model.Enrichment {
+	switch {
+	case err == nil && record != nil:
+		enrichment := model.Enrichment{
+			Status:      model.EnrichmentComplete,
+			Distributor: record.Distributor,
+			Budget:      record.Budget,
+			BudgetUSD:   record.BudgetUSD,
+			MpaRating:   record.MpaRating,
+			BoxOffice: &model.BoxOffice{
+				Revenue: model.BoxOfficeRevenue{
+					Worldwide:        record.Revenue.Worldwide,
+					OpeningWeekendUS: record.Revenue.OpeningWeekendUS,
+					WorldwideUSD:     record.Revenue.WorldwideUSD,
+				},
+				Currency:        record.Currency,
+				Source:          record.Source,
+				LastUpdated:     record.LastUpdated,
+				MatchConfidence: record.MatchConfidence,
+			},
+			Sources: record.Sources,
+		}
+		if releaseDate, err := time.Parse("2006-01-02", record.ReleaseDate); err == nil {
+			enrichment.ReleaseDate = &releaseDate
+		}
+		return enrichment
+	case errors.Is(err, boxoffice.ErrNotFound):
+		return model.Enrichment{Status: model.EnrichmentNotFound}
+	default:
+		return model.Enrichment{Status: model.EnrichmentFailed}
+	}
+}
+
+// mergeEnrichment applies enrichment to an in-memory movie. Unless force is
+// set it follows the fill-missing rules the repository uses; with force,
+// every attribute the provider knows overwrites the movie's.
+// The provenance of every attribute taken from the provider is recorded, and
+// so is every attribute on which the movie still disagrees with it.
+func mergeEnrichment(movie *model.Movie, enrichment model.Enrichment, force bool) {
+	sources := make(map[string]string, len(movie.Sources))
+	for field, provider := range movie.Sources {
+		sources[field] = provider
+	}
+	take := func(field string) {
+		if provider, ok := enrichment.Sources[field]; ok {
+			sources[field] = provider
+		} else {
+			delete(sources, field)
+		}
+	}
+
+	movie.EnrichmentStatus = enrichment.Status
+	if enrichment.Distributor != nil && (force || movie.Distributor == nil) {
+		movie.Distributor = enrichment.Distributor
+		take(boxoffice.FieldDistributor)
+	}
+	if enrichment.Budget != nil && (force || movie.Budget == nil) {
+		movie.Budget = enrichment.Budget
+		movie.BudgetUSD = enrichment.BudgetUSD
+		take(boxoffice.FieldBudget)
+	} else if movie.BudgetUSD == nil && movie.Budget != nil && enrichment.Budget != nil && *movie.Budget == *enrichment.Budget {
+		// A provider budget the USD backfill could not convert.
+		movie.BudgetUSD = enrichment.BudgetUSD
+	}
+	if enrichment.MpaRating != nil && (force || movie.MpaRating == nil) {
+		movie.MpaRating = enrichment.MpaRating
+		take(boxoffice.FieldMpaRating)
+	}
+	if enrichment.BoxOffice != nil {
+		movie.BoxOffice = enrichment.BoxOffice
+		take(boxoffice.FieldRevenue)
+	}
+	movie.Sources = sources
+	movie.Conflicts = enrichment.ConflictsWith(movie)
+}
+
+// FieldChange is one attribute changed by a box office refresh, named as in
+// the API representation of a movie. Old and New are nil when unset.
+type FieldChange struct {
+	Field string
+	Old   interface{}
+	New   interface{}
+}
+
+func diffEnrichedFields(before, after *model.Movie) []FieldChange {
+	var changes []FieldChange
+	add := func(field string, old, new interface{}) {
+		if !reflect.DeepEqual(old, new) {
+			changes = append(changes, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	add("distributor", optional(before.Distributor), optional(after.Distributor))
+	add("budget", optional(before.Budget), optional(after.Budget))
+	add("mpaRating", optional(before.MpaRating), optional(after.MpaRating))
+
+	oldBoxOffice, newBoxOffice := boxOfficeFields(before.BoxOffice), boxOfficeFields(after.BoxOffice)
+	for i, field := range boxOfficeFieldNames {
+		add(field, oldBoxOffice[i], newBoxOffice[i])
+	}
+
+	add("enrichmentStatus", before.EnrichmentStatus, after.EnrichmentStatus)
+	return changes
+}
+
+var boxOfficeFieldNames = [...]string{
+	"boxOffice.revenue.worldwide",
+	"boxOffice.revenue.openingWeekendUSA",
+	"boxOffice.currency",
+	"boxOffice.source",
+	"boxOffice.lastUpdated",
+	"boxOffice.matchConfidence",
+}
+
+func boxOfficeFields(boxOffice *model.BoxOffice) [len(boxOfficeFieldNames)]interface{} {
+	var fields [len(boxOfficeFieldNames)]interface{}
+	if boxOffice == nil {
+		return fields
+	}
+
+	fields[0] = boxOffice.Revenue.Worldwide
+	fields[1] = optional(boxOffice.Revenue.OpeningWeekendUS)
+	fields[2] = nonEmpty(boxOffice.Currency)
+	fields[3] = nonEmpty(boxOffice.Source)
+	if !boxOffice.LastUpdated.IsZero() {
+		fields[4] = boxOffice.LastUpdated.UTC().Format(time.RFC3339)
+	}
+	fields[5] = optional(boxOffice.MatchConfidence)
+	return fields
+}
+
+// nonEmpty reports an unset string as absent so that it does not show up as a
+// change from nil.
+func nonEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func optional[T any](value *T) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"cinema/boxoffice"
+	"cinema/model"
+	"cinema/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type recordingBoxOfficeClient struct {
+	calls  int
+	record *boxoffice.Record
+	err    error
+}
+
+func (c *recordingBoxOfficeClient) Fetch(ctx context.Context, title string) (*boxoffice.Record, error) {
+	c.calls++
+	return c.record, c.err
+}
+
+func TestCreateMovie_AsyncDefersLookup(t *testing.T) {
+	repo := newMemoryMovieRepository()
+	client := &recordingBoxOfficeClient{err: boxoffice.ErrNotFound}
+	svc := NewMovieService(repo, stubUnitOfWork{repository.Repositories{Movies: repo}}, client, EnrichAsync)
+
+	movie, err := svc.CreateMovie(context.Background(), CreateMovieParams{Title: "Heat", Genre: "Crime", ReleaseDate: "1995-12-15"})
+	if err != nil {
+		t.Fatalf("CreateMovie returned error: %v", err)
+	}
+	if movie.EnrichmentStatus != model.EnrichmentPending || client.calls != 0 {
+		t.Fatalf("expected pending movie without lookup, got %q after %d calls", movie.EnrichmentStatus, client.calls)
+	}
+}
+
+func TestCreateMovie_SyncFillsMissingAttributes(t *testing.T) {
+	repo := newMemoryMovieRepository()
+	budget := int64(60000000)
+	distributor := "Warner Bros."
+	client := &recordingBoxOfficeClient{record: &boxoffice.Record{
+		Distributor: &distributor,
+		Budget:      &budget,
+		Revenue:     boxoffice.Revenue{Worldwide: 187},
+		Sources:     map[string]string{boxoffice.FieldDistributor: "a", boxoffice.FieldBudget: "b", boxoffice.FieldRevenue: "a"},
+	}}
+	svc := NewMovieService(repo, stubUnitOfWork{repository.Repositories{Movies: repo}}, client, EnrichSync)
+
+	ownDistributor := "Regency"
+	movie, err := svc.CreateMovie(context.Background(), CreateMovieParams{Title: "Heat", Genre: "Crime", ReleaseDate: "1995-12-15", Distributor: &ownDistributor})
+	if err != nil {
+		t.Fatalf("CreateMovie returned error: %v", err)
+	}
+	if movie.EnrichmentStatus != model.EnrichmentComplete || *movie.Distributor != "Regency" || *movie.Budget != budget || movie.BoxOffice == nil {
+		t.Fatalf("unexpected movie %+v", movie)
+	}
+	if _, ok := movie.Sources[boxoffice.FieldDistributor]; ok || movie.Sources[boxoffice.FieldBudget] != "b" || movie.Sources[boxoffice.FieldRevenue] != "a" {
+		t.Fatalf("expected provenance only for provider-supplied fields, got %v", movie.Sources)
+	}
+}
+
+func TestCreateMovie_SyncRecordsConflicts(t *testing.T) {
+	repo := newMemoryMovieRepository()
+	budget, budgetUSD := int64(55000000), int64(60000000)
+	distributor, rating := "warner  bros.", "R"
+	client := &recordingBoxOfficeClient{record: &boxoffice.Record{
+		ReleaseDate: "1995-12-08",
+		Distributor: &distributor,
+		Budget:      &budget,
+		BudgetUSD:   &budgetUSD,
+		MpaRating:   &rating,
+		Sources:     map[string]string{boxoffice.FieldReleaseDate: "a", boxoffice.FieldBudget: "b"},
+	}}
+	svc := NewMovieService(repo, stubUnitOfWork{repository.Repositories{Movies: repo}}, client, EnrichSync)
+
+	ownDistributor, ownBudget, ownRating := "Warner Bros.", int64(50000000), "R"
+	movie, err := svc.CreateMovie(context.Background(), CreateMovieParams{
+		Title: "Heat", Genre: "Crime", ReleaseDate: "1995-12-15",
+		Distributor: &ownDistributor, Budget: &ownBudget, MpaRating: &ownRating,
+	})
+	if err != nil {
+		t.Fatalf("CreateMovie returned error: %v", err)
+	}
+	if movie.ReleaseDate.Format("2006-01-02") != "1995-12-15" || *movie.Budget != ownBudget {
+		t.Fatalf("expected our values to be kept, got %+v", movie)
+	}
+
+	want := map[string]model.DataConflict{
+		model.ConflictReleaseDate: {Ours: "1995-12-15", Theirs: "1995-12-08", Source: "a"},
+		model.ConflictBudget:      {Ours: "50000000", Theirs: "60000000", Source: "b"},
+	}
+	if len(movie.Conflicts) != len(want) {
+		t.Fatalf("expected %d conflicts, got %+v", len(want), movie.Conflicts)
+	}
+	for _, conflict := range movie.Conflicts {
+		expected := want[conflict.Field]
+		if conflict.MovieID != movie.ID || conflict.Ours != expected.Ours || conflict.Theirs != expected.Theirs || conflict.Source != expected.Source {
+			t.Fatalf("unexpected %s conflict %+v", conflict.Field, conflict)
+		}
+	}
+}
+
+func TestRefreshBoxOffice_ForceLeavesOnlyReleaseDateConflict(t *testing.T) {
+	repo := newMemoryMovieRepository()
+	ownDistributor := "Regency"
+	seedMovie(t, repo, &model.Movie{ID: "m_1", Title: "Heat", Genre: "Crime", ReleaseDate: time.Date(1995, 12, 15, 0, 0, 0, 0, time.UTC), Distributor: &ownDistributor})
+	distributor := "Warner Bros."
+	client := &recordingBoxOfficeClient{record: &boxoffice.Record{ReleaseDate: "1995-12-08", Distributor: &distributor}}
+	svc := NewMovieService(repo, stubUnitOfWork{repository.Repositories{Movies: repo}}, client, EnrichSync)
+
+	movie, _, err := svc.RefreshBoxOffice(context.Background(), "Heat", RefreshForce, VersionMatch{Any: true})
+	if err != nil {
+		t.Fatalf("RefreshBoxOffice returned error: %v", err)
+	}
+	if *movie.Distributor != distributor || len(movie.Conflicts) != 1 || movie.Conflicts[0].Field != model.ConflictReleaseDate {
+		t.Fatalf("expected the forced distributor and a release date conflict, got %+v", movie)
+	}
+}
+
+func TestRefreshBoxOffice_ConvertsBudgetLeftUnconverted(t *testing.T) {
+	repo := newMemoryMovieRepository()
+	budget := int64(900000000)
+	seedMovie(t, repo, &model.Movie{ID: "m_1", Title: "Heat", Genre: "Crime", ReleaseDate: time.Date(1995, 12, 15, 0, 0, 0, 0, time.UTC), Budget: &budget})
+	budgetUSD := int64(6000000)
+	client := &recordingBoxOfficeClient{record: &boxoffice.Record{Budget: &budget, BudgetUSD: &budgetUSD, Currency: "JPY"}}
+	svc := NewMovieService(repo, stubUnitOfWork{repository.Repositories{Movies: repo}}, client, EnrichSync)
+
+	movie, _, err := svc.RefreshBoxOffice(context.Background(), "Heat", RefreshFillMissing, VersionMatch{Any: true})
+	if err != nil {
+		t.Fatalf("RefreshBoxOffice returned error: %v", err)
+	}
+	if movie.BudgetUSD == nil || *movie.BudgetUSD != budgetUSD || *movie.Budget != budget {
+		t.Fatalf("expected the budget to be converted, got %+v", movie)
+	}
+}
+
+func TestEnrichmentWorker_RetriesThenGivesUp(t *testing.T) {
+	client := &recordingBoxOfficeClient{err: errors.New("upstream unavailable")}
+	worker := NewEnrichmentWorker(nil, client, EnrichmentWorkerOptions{MaxAttempts: 3, BaseBackoff: time.Minute, MaxBackoff: time.Hour})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	worker.now = func() time.Time { return now }
+
+	outcome := worker.handle(context.Background(), model.EnrichmentJob{Title: "Heat", Attempts: 1})
+	if outcome.RetryAt == nil || outcome.Error != "upstream unavailable" {
+		t.Fatalf("expected a retry, got %+v", outcome)
+	}
+	if delay := outcome.RetryAt.Sub(now); delay < time.Minute || delay > 2*time.Minute {
+		t.Fatalf("expected backoff between 1m and 2m, got %s", delay)
+	}
+
+	outcome = worker.handle(context.Background(), model.EnrichmentJob{Title: "Heat", Attempts: 2})
+	if outcome.RetryAt != nil || outcome.Enrichment.Status != model.EnrichmentFailed {
+		t.Fatalf("expected the job to be marked failed, got %+v", outcome)
+	}
+
+	client.err = boxoffice.ErrNotFound
+	outcome = worker.handle(context.Background(), model.EnrichmentJob{Title: "Heat"})
+	if outcome.RetryAt != nil || outcome.Enrichment.Status != model.EnrichmentNotFound {
+		t.Fatalf("expected not_found without retry, got %+v", outcome)
+	}
+}
@@ -10,17 +10,75 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"reflect"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-var ErrInvalidInput = errors.New("invalid input")
+var (
+	ErrInvalidInput         = errors.New("invalid input")
+	ErrBoxOfficeUnavailable = errors.New("box office provider unavailable")
+	ErrPreconditionFailed   = errors.New("movie version does not match")
+)
+
+// EnrichmentMode selects whether CreateMovie looks up box office data inline
+// or defers it to the EnrichmentWorker.
+type EnrichmentMode string
+
+const (
+	EnrichSync  EnrichmentMode = "sync"
+	EnrichAsync EnrichmentMode = "async"
+)
+
+// RefreshMode selects how RefreshBoxOffice merges provider data.
+type RefreshMode string
+
+const (
+	RefreshFillMissing RefreshMode = "fill-missing"
+	RefreshForce       RefreshMode = "force"
+)
+
+// VersionMatch is the condition a client puts on a write to a movie, taken
+// from an If-Match header: either any version, or one of Versions.
+type VersionMatch struct {
+	Any      bool
+	Versions []int64
+}
+
+func (m VersionMatch) matches(version int64) bool {
+	if m.Any {
+		return true
+	}
+	for _, v := range m.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// lost reports a write that lost a race with another as a failed
+// precondition, since the version the client named is no longer current.
+func (m VersionMatch) lost(err error) error {
+	if !m.Any && errors.Is(err, repository.ErrMovieConflict) {
+		return ErrPreconditionFailed
+	}
+	return err
+}
+
+// defaultLookupBudget bounds the box office lookup CreateMovie makes in
+// EnrichSync mode, leaving the server time to respond.
+const defaultLookupBudget = 8 * time.Second
 
 type MovieService struct {
 	repo            repository.MovieRepository
+	tx              repository.UnitOfWork
 	boxOfficeClient boxoffice.Client
+	enrichment      EnrichmentMode
+	lookupBudget    time.Duration
+	deferSlow       bool
 }
 
 type CreateMovieParams struct {
@@ -32,103 +90,279 @@ type CreateMovieParams struct {
 	MpaRating   *string
 }
 
+// MoviePatch describes a JSON Merge Patch (RFC 7396) against a movie. Nil
+// fields are left untouched; the Clear flags null out optional attributes.
+type MoviePatch struct {
+	Title            *string
+	Genre            *string
+	ReleaseDate      *string
+	Distributor      *string
+	Budget           *int64
+	MpaRating        *string
+	ClearDistributor bool
+	ClearBudget      bool
+	ClearMpaRating   bool
+}
+
 type ListMoviesParams struct {
 	Q           string
 	Year        *int
 	Genre       *string
 	Distributor *string
 	BudgetLTE   *int64
+	RevenueGTE  *int64
 	MpaRating   *string
 	Limit       int
 	Cursor      string
 }
 
-func NewMovieService(repo repository.MovieRepository, client boxoffice.Client) *MovieService {
+func NewMovieService(repo repository.MovieRepository, tx repository.UnitOfWork, client boxoffice.Client, enrichment EnrichmentMode) *MovieService {
 	return &MovieService{
 		repo:            repo,
+		tx:              tx,
 		boxOfficeClient: client,
+		enrichment:      enrichment,
+		lookupBudget:    defaultLookupBudget,
 	}
 }
 
+// SetLookupBudget bounds the box office lookup CreateMovie makes in
+// EnrichSync mode. With deferSlow, a movie whose lookup runs out of time is
+// stored as EnrichmentPending for the EnrichmentWorker to finish, which needs
+// a repository that queues enrichment jobs; otherwise it is stored as failed.
+func (s *MovieService) SetLookupBudget(budget time.Duration, deferSlow bool) {
+	s.lookupBudget = budget
+	s.deferSlow = deferSlow
+}
+
+// CreateMovie stores a new movie and returns it as stored. In EnrichSync mode
+// the box office lookup happens first so the movie is inserted complete in a
+// single statement; in EnrichAsync mode, or when the lookup exceeds the budget
+// and slow lookups are deferred, the movie is committed immediately together
+// with an enrichment job for the worker.
 func (s *MovieService) CreateMovie(ctx context.Context, params CreateMovieParams) (*model.Movie, error) {
-	title := strings.TrimSpace(params.Title)
-	genre := strings.TrimSpace(params.Genre)
-	if title == "" || genre == "" {
+	movie, err := validateMovieParams(params)
+	if err != nil {
+		return nil, err
+	}
+	movie.ID = uuid.NewString()
+
+	if s.enrichment == EnrichAsync {
+		movie.EnrichmentStatus = model.EnrichmentPending
+	} else {
+		lookupCtx, cancel := context.WithTimeout(ctx, s.lookupBudget)
+		record, err := s.boxOfficeClient.Fetch(boxoffice.WithReleaseYear(lookupCtx, movie.ReleaseDate.Year()), movie.Title)
+		timedOut := err != nil && ctx.Err() == nil && errors.Is(lookupCtx.Err(), context.DeadlineExceeded)
+		cancel()
+
+		if timedOut && s.deferSlow {
+			log.Printf("box office lookup for %q exceeded %s; deferring it to the enrichment worker", movie.Title, s.lookupBudget)
+			movie.EnrichmentStatus = model.EnrichmentPending
+		} else {
+			if err != nil && !errors.Is(err, boxoffice.ErrNotFound) {
+				log.Printf("box office request failed (ignored for creation): %v", err)
+			}
+			mergeEnrichment(movie, enrichmentFromLookup(record, err), false)
+		}
+	}
+
+	if err := s.repo.Create(ctx, movie); err != nil {
+		return nil, err
+	}
+
+	return movie, nil
+}
+
+func (s *MovieService) GetMovie(ctx context.Context, title string) (*model.Movie, error) {
+	if strings.TrimSpace(title) == "" {
 		return nil, ErrInvalidInput
 	}
 
-	releaseDate, err := time.Parse("2006-01-02", params.ReleaseDate)
+	return s.repo.GetByTitle(ctx, title)
+}
+
+// ReplaceMovie overwrites every editable attribute of a movie (PUT semantics).
+// Optional attributes omitted from params are cleared; box office data is kept.
+// It fails with ErrPreconditionFailed unless the movie's version satisfies
+// match.
+func (s *MovieService) ReplaceMovie(ctx context.Context, title string, match VersionMatch, params CreateMovieParams) (*model.Movie, error) {
+	current, err := s.matchingMovie(ctx, title, match)
 	if err != nil {
-		return nil, ErrInvalidInput
+		return nil, err
 	}
 
-	if params.Budget != nil && *params.Budget < 0 {
-		return nil, ErrInvalidInput
+	return s.saveMovie(ctx, current, match, params)
+}
+
+func (s *MovieService) PatchMovie(ctx context.Context, title string, match VersionMatch, patch MoviePatch) (*model.Movie, error) {
+	current, err := s.matchingMovie(ctx, title, match)
+	if err != nil {
+		return nil, err
 	}
 
-	movie := &model.Movie{
-		ID:          uuid.NewString(),
-		Title:       title,
-		Genre:       genre,
-		ReleaseDate: releaseDate,
-		Distributor: params.Distributor,
-		Budget:      params.Budget,
-		MpaRating:   params.MpaRating,
+	params := CreateMovieParams{
+		Title:       current.Title,
+		Genre:       current.Genre,
+		ReleaseDate: current.ReleaseDate.Format("2006-01-02"),
+		Distributor: current.Distributor,
+		Budget:      current.Budget,
+		MpaRating:   current.MpaRating,
+	}
+	if patch.Title != nil {
+		params.Title = *patch.Title
+	}
+	if patch.Genre != nil {
+		params.Genre = *patch.Genre
+	}
+	if patch.ReleaseDate != nil {
+		params.ReleaseDate = *patch.ReleaseDate
+	}
+	if patch.ClearDistributor {
+		params.Distributor = nil
+	} else if patch.Distributor != nil {
+		params.Distributor = patch.Distributor
+	}
+	if patch.ClearBudget {
+		params.Budget = nil
+	} else if patch.Budget != nil {
+		params.Budget = patch.Budget
+	}
+	if patch.ClearMpaRating {
+		params.MpaRating = nil
+	} else if patch.MpaRating != nil {
+		params.MpaRating = patch.MpaRating
 	}
 
-	if err := s.repo.Create(ctx, movie); err != nil {
+	return s.saveMovie(ctx, current, match, params)
+}
+
+// matchingMovie looks up the movie a write applies to and checks its version
+// against match.
+func (s *MovieService) matchingMovie(ctx context.Context, title string, match VersionMatch) (*model.Movie, error) {
+	current, err := s.GetMovie(ctx, title)
+	if err != nil {
 		return nil, err
 	}
+	if !match.matches(current.Version) {
+		return nil, ErrPreconditionFailed
+	}
+	return current, nil
+}
 
-	var (
-		boxOffice *model.BoxOffice
-		updated   bool
-	)
+// RefreshBoxOffice re-runs the box office lookup for an existing movie,
+// bypassing any cache, and merges the result as CreateMovie does. With
+// RefreshForce the provider's attributes overwrite the movie's. It returns the
+// updated movie and the fields that changed.
+func (s *MovieService) RefreshBoxOffice(ctx context.Context, title string, mode RefreshMode, match VersionMatch) (*model.Movie, []FieldChange, error) {
+	if mode != RefreshFillMissing && mode != RefreshForce {
+		return nil, nil, ErrInvalidInput
+	}
 
-	record, err := s.boxOfficeClient.Fetch(ctx, title)
-	switch {
-	case err == nil && record != nil:
-		if movie.Distributor == nil && record.Distributor != nil {
-			movie.Distributor = record.Distributor
-			updated = true
-		}
-		if movie.Budget == nil && record.Budget != nil {
-			movie.Budget = record.Budget
-			updated = true
-		}
-		if movie.MpaRating == nil && record.MpaRating != nil {
-			movie.MpaRating = record.MpaRating
-			updated = true
-		}
+	current, err := s.matchingMovie(ctx, title, match)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		boxOffice = &model.BoxOffice{
-			Revenue: model.BoxOfficeRevenue{
-				Worldwide:        record.Revenue.Worldwide,
-				OpeningWeekendUS: record.Revenue.OpeningWeekendUS,
-			},
-			Currency:    record.Currency,
-			Source:      record.Source,
-			LastUpdated: record.LastUpdated,
-		}
-		updated = true
-	case errors.Is(err, boxoffice.ErrNotFound):
-		// graceful degradation: no box office data
-	case err != nil:
-		log.Printf("box office request failed (ignored for creation): %v", err)
+	fetch := s.boxOfficeClient.Fetch
+	if fresh, ok := s.boxOfficeClient.(boxoffice.FreshFetcher); ok {
+		fetch = fresh.FetchFresh
+	}
+	record, err := fetch(boxoffice.WithReleaseYear(ctx, current.ReleaseDate.Year()), current.Title)
+	enrichment := enrichmentFromLookup(record, err)
+	if enrichment.Status == model.EnrichmentFailed {
+		return nil, nil, fmt.Errorf("%w: %w", ErrBoxOfficeUnavailable, err)
 	}
 
-	if updated {
-		if err := s.repo.UpdateSupplemental(ctx, movie.ID, movie.Distributor, movie.Budget, movie.MpaRating, boxOffice); err != nil {
-			log.Printf("failed to update movie with box office data: %v", err)
-		}
+	updated := *current
+	mergeEnrichment(&updated, enrichment, mode == RefreshForce)
+	if err := s.repo.UpdateBoxOffice(ctx, &updated, current.Version); err != nil {
+		return nil, nil, match.lost(err)
 	}
 
-	storedMovie, err := s.repo.GetByTitle(ctx, movie.Title)
+	return &updated, diffEnrichedFields(current, &updated), nil
+}
+
+func (s *MovieService) saveMovie(ctx context.Context, current *model.Movie, match VersionMatch, params CreateMovieParams) (*model.Movie, error) {
+	movie, err := validateMovieParams(params)
 	if err != nil {
 		return nil, err
 	}
+	movie.ID = current.ID
+	movie.BoxOffice = current.BoxOffice
+	movie.EnrichmentStatus = current.EnrichmentStatus
+	movie.Sources = keptSources(current, movie)
+	if reflect.DeepEqual(current.Budget, movie.Budget) {
+		// The budget may have come from a provider in another currency.
+		movie.BudgetUSD = current.BudgetUSD
+	}
+	movie.CreatedAt = current.CreatedAt
+
+	if err := s.repo.Update(ctx, movie, current.Version); err != nil {
+		return nil, match.lost(err)
+	}
+
+	return movie, nil
+}
+
+// keptSources drops the provenance of attributes the user has just changed,
+// since the provider no longer supplied them.
+func keptSources(current, updated *model.Movie) map[string]string {
+	changed := map[string]bool{
+		boxoffice.FieldReleaseDate: !current.ReleaseDate.Equal(updated.ReleaseDate),
+		boxoffice.FieldDistributor: !reflect.DeepEqual(current.Distributor, updated.Distributor),
+		boxoffice.FieldBudget:      !reflect.DeepEqual(current.Budget, updated.Budget),
+		boxoffice.FieldMpaRating:   !reflect.DeepEqual(current.MpaRating, updated.MpaRating),
+	}
+
+	sources := make(map[string]string, len(current.Sources))
+	for field, provider := range current.Sources {
+		if !changed[field] {
+			sources[field] = provider
+		}
+	}
+	return sources
+}
+
+// DeleteMovie soft-deletes a movie unless hard is set, in which case the row
+// and its ratings are removed permanently. A hard delete also purges a movie
+// that is already soft-deleted, the most recent one first, when no live movie
+// holds the title. The lookup and the delete form one unit of work, so a
+// movie renamed in between is not deleted by its old title.
+// It fails with ErrPreconditionFailed unless the movie's version satisfies
+// match.
+func (s *MovieService) DeleteMovie(ctx context.Context, title string, hard bool, match VersionMatch) error {
+	if strings.TrimSpace(title) == "" {
+		return ErrInvalidInput
+	}
+
+	return s.tx.WithTx(ctx, func(repos repository.Repositories) error {
+		lookup := repos.Movies.GetByTitle
+		if hard {
+			lookup = repos.Movies.GetByTitleIncludingDeleted
+		}
+		movie, err := lookup(ctx, title)
+		if err != nil {
+			return err
+		}
+		if !match.matches(movie.Version) {
+			return ErrPreconditionFailed
+		}
+
+		if hard {
+			err = repos.Movies.Delete(ctx, movie.ID, movie.Version)
+		} else {
+			err = repos.Movies.SoftDelete(ctx, movie.ID, movie.Version)
+		}
+		return match.lost(err)
+	})
+}
+
+func (s *MovieService) RestoreMovie(ctx context.Context, title string) (*model.Movie, error) {
+	if strings.TrimSpace(title) == "" {
+		return nil, ErrInvalidInput
+	}
 
-	return storedMovie, nil
+	return s.repo.Restore(ctx, title)
 }
 
 func (s *MovieService) ListMovies(ctx context.Context, params ListMoviesParams) ([]*model.Movie, *string, error) {
@@ -141,13 +375,16 @@ func (s *MovieService) ListMovies(ctx context.Context, params ListMoviesParams)
 	}
 
 	listParams := repository.MovieListParams{
-		Q:           strings.TrimSpace(params.Q),
-		Year:        params.Year,
-		Genre:       params.Genre,
-		Distributor: params.Distributor,
-		BudgetLTE:   params.BudgetLTE,
-		MpaRating:   params.MpaRating,
-		Limit:       limit + 1,
+		MovieFilter: repository.MovieFilter{
+			Q:           strings.TrimSpace(params.Q),
+			Year:        params.Year,
+			Genre:       params.Genre,
+			Distributor: params.Distributor,
+			BudgetLTE:   params.BudgetLTE,
+			RevenueGTE:  params.RevenueGTE,
+			MpaRating:   params.MpaRating,
+		},
+		Limit: limit + 1,
 	}
 
 	if params.Cursor != "" {
@@ -165,8 +402,8 @@ func (s *MovieService) ListMovies(ctx context.Context, params ListMoviesParams)
 
 	var nextCursor *string
 	if len(movies) > limit {
+		movies = movies[:limit]
 		last := movies[len(movies)-1]
-		movies = movies[:len(movies)-1]
 		encoded, err := encodeCursor(last)
 		if err != nil {
 			return nil, nil, err
@@ -177,13 +414,58 @@ func (s *MovieService) ListMovies(ctx context.Context, params ListMoviesParams)
 	return movies, nextCursor, nil
 }
 
+func validateMovieParams(params CreateMovieParams) (*model.Movie, error) {
+	title := strings.TrimSpace(params.Title)
+	genre := strings.TrimSpace(params.Genre)
+	if title == "" || genre == "" {
+		return nil, ErrInvalidInput
+	}
+
+	releaseDate, err := time.Parse("2006-01-02", params.ReleaseDate)
+	if err != nil {
+		return nil, ErrInvalidInput
+	}
+
+	if params.Budget != nil && *params.Budget < 0 {
+		return nil, ErrInvalidInput
+	}
+
+	return &model.Movie{
+		Title:       title,
+		Genre:       genre,
+		ReleaseDate: releaseDate,
+		Distributor: params.Distributor,
+		Budget:      params.Budget,
+		BudgetUSD:   params.Budget,
+		MpaRating:   params.MpaRating,
+	}, nil
+}
+
 func encodeCursor(movie *model.Movie) (string, error) {
+	return encodeKeysetCursor(movie.CreatedAt, movie.ID)
+}
+
+func decodeCursor(cursor string) (*repository.MovieCursor, error) {
+	createdAt, id, err := decodeKeysetCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.MovieCursor{
+		CreatedAt: createdAt,
+		ID:        id,
+	}, nil
+}
+
+// encodeKeysetCursor produces the opaque (createdAt, id) cursor shared by all
+// paginated listings.
+func encodeKeysetCursor(createdAt time.Time, id string) (string, error) {
 	payload := struct {
 		CreatedAt time.Time `json:"createdAt"`
 		ID        string    `json:"id"`
 	}{
-		CreatedAt: movie.CreatedAt,
-		ID:        movie.ID,
+		CreatedAt: createdAt,
+		ID:        id,
 	}
 
 	raw, err := json.Marshal(payload)
@@ -194,10 +476,10 @@ func encodeCursor(movie *model.Movie) (string, error) {
 	return base64.StdEncoding.EncodeToString(raw), nil
 }
 
-func decodeCursor(cursor string) (*repository.MovieCursor, error) {
+func decodeKeysetCursor(cursor string) (time.Time, string, error) {
 	raw, err := base64.StdEncoding.DecodeString(cursor)
 	if err != nil {
-		return nil, err
+		return time.Time{}, "", err
 	}
 
 	var payload struct {
@@ -206,15 +488,12 @@ func decodeCursor(cursor string) (*repository.MovieCursor, error) {
 	}
 
 	if err := json.Unmarshal(raw, &payload); err != nil {
-		return nil, err
+		return time.Time{}, "", err
 	}
 
 	if payload.ID == "" || payload.CreatedAt.IsZero() {
-		return nil, fmt.Errorf("cursor is missing required fields")
+		return time.Time{}, "", fmt.Errorf("cursor is missing required fields")
 	}
 
-	return &repository.MovieCursor{
-		CreatedAt: payload.CreatedAt,
-		ID:        payload.ID,
-	}, nil
+	return payload.CreatedAt, payload.ID, nil
 }
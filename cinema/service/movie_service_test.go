@@ -5,56 +5,30 @@ import (
 	"cinema/model"
 	"cinema/repository"
 	"context"
-	"strings"
+	"errors"
 	"testing"
+	"time"
 )
 
-type stubMovieRepository struct {
-	movies map[string]*model.Movie
+func newMemoryMovieRepository() *repository.MemoryMovieRepository {
+	return repository.NewMemoryMovieRepository(repository.NewMemoryStore())
 }
 
-func newStubMovieRepository() *stubMovieRepository {
-	return &stubMovieRepository{movies: make(map[string]*model.Movie)}
+// stubUnitOfWork runs units of work directly against its repositories,
+// without a transaction.
+type stubUnitOfWork struct {
+	repos repository.Repositories
 }
 
-func (r *stubMovieRepository) Create(ctx context.Context, movie *model.Movie) error {
-	key := strings.ToLower(movie.Title)
-	if _, exists := r.movies[key]; exists {
-		return repository.ErrMovieAlreadyExists
-	}
-	clone := *movie
-	r.movies[key] = &clone
-	return nil
-}
-
-func (r *stubMovieRepository) UpdateSupplemental(ctx context.Context, movieID string, distributor *string, budget *int64, mpaRating *string, boxOffice *model.BoxOffice) error {
-	for _, movie := range r.movies {
-		if movie.ID == movieID {
-			movie.Distributor = distributor
-			movie.Budget = budget
-			movie.MpaRating = mpaRating
-			movie.BoxOffice = boxOffice
-			return nil
-		}
-	}
-	return repository.ErrMovieNotFound
+func (u stubUnitOfWork) WithTx(ctx context.Context, fn func(repos repository.Repositories) error) error {
+	return fn(u.repos)
 }
 
-func (r *stubMovieRepository) GetByTitle(ctx context.Context, title string) (*model.Movie, error) {
-	if movie, ok := r.movies[strings.ToLower(title)]; ok {
-		clone := *movie
-		return &clone, nil
+func seedMovie(t *testing.T, repo repository.MovieRepository, movie *model.Movie) {
+	t.Helper()
+	if err := repo.Create(context.Background(), movie); err != nil {
+		t.Fatalf("seed %q: %v", movie.Title, err)
 	}
-	return nil, repository.ErrMovieNotFound
-}
-
-func (r *stubMovieRepository) List(ctx context.Context, params repository.MovieListParams) ([]*model.Movie, error) {
-	result := make([]*model.Movie, 0, len(r.movies))
-	for _, movie := range r.movies {
-		clone := *movie
-		result = append(result, &clone)
-	}
-	return result, nil
 }
 
 type stubBoxOfficeClient struct{}
@@ -64,8 +38,8 @@ func (stubBoxOfficeClient) Fetch(ctx context.Context, title string) (*boxoffice.
 }
 
 func TestCreateMovie_SucceedsWithValidInput(t *testing.T) {
-	repo := newStubMovieRepository()
-	svc := NewMovieService(repo, stubBoxOfficeClient{})
+	repo := newMemoryMovieRepository()
+	svc := NewMovieService(repo, stubUnitOfWork{repository.Repositories{Movies: repo}}, stubBoxOfficeClient{}, EnrichSync)
 
 	distributor := "Test Studios"
 	budget := int64(50000000)
@@ -89,3 +63,182 @@ func TestCreateMovie_SucceedsWithValidInput(t *testing.T) {
 		t.Fatalf("expected title %q, got %q", params.Title, movie.Title)
 	}
 }
+
+// slowBoxOfficeClient answers only once the caller gives up.
+type slowBoxOfficeClient struct{}
+
+func (slowBoxOfficeClient) Fetch(ctx context.Context, title string) (*boxoffice.Record, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCreateMovie_DefersLookupsThatExceedTheBudget(t *testing.T) {
+	ctx := context.Background()
+	for _, deferSlow := range []bool{true, false} {
+		repo := newMemoryMovieRepository()
+		svc := NewMovieService(repo, stubUnitOfWork{repository.Repositories{Movies: repo}}, slowBoxOfficeClient{}, EnrichSync)
+		svc.SetLookupBudget(10*time.Millisecond, deferSlow)
+
+		movie, err := svc.CreateMovie(ctx, CreateMovieParams{Title: "Heat", Genre: "Crime", ReleaseDate: "1995-12-15"})
+		if err != nil {
+			t.Fatalf("CreateMovie returned error: %v", err)
+		}
+		want := model.EnrichmentFailed
+		if deferSlow {
+			want = model.EnrichmentPending
+		}
+		if movie.EnrichmentStatus != want {
+			t.Fatalf("deferSlow=%v: expected status %q, got %q", deferSlow, want, movie.EnrichmentStatus)
+		}
+	}
+}
+
+func TestPatchMovie_AppliesMergePatchAndRejectsTitleClash(t *testing.T) {
+	repo := newMemoryMovieRepository()
+	svc := NewMovieService(repo, stubUnitOfWork{repository.Repositories{Movies: repo}}, stubBoxOfficeClient{}, EnrichSync)
+	ctx := context.Background()
+
+	distributor := "Test Studios"
+	original, err := svc.CreateMovie(ctx, CreateMovieParams{Title: "Original", Genre: "Drama", ReleaseDate: "2020-01-01", Distributor: &distributor})
+	if err != nil {
+		t.Fatalf("CreateMovie returned error: %v", err)
+	}
+	if _, err := svc.CreateMovie(ctx, CreateMovieParams{Title: "Taken", Genre: "Drama", ReleaseDate: "2020-01-01"}); err != nil {
+		t.Fatalf("CreateMovie returned error: %v", err)
+	}
+
+	renamed := "Renamed"
+	budget := int64(1000)
+	seen := VersionMatch{Versions: []int64{original.Version}}
+	movie, err := svc.PatchMovie(ctx, "original", seen, MoviePatch{Title: &renamed, Budget: &budget, ClearDistributor: true})
+	if err != nil {
+		t.Fatalf("PatchMovie returned error: %v", err)
+	}
+	if movie.Title != renamed || movie.Genre != "Drama" || movie.Distributor != nil || movie.Budget == nil || *movie.Budget != budget {
+		t.Fatalf("unexpected patched movie: %+v", movie)
+	}
+	if movie.Version != original.Version+1 {
+		t.Fatalf("expected the patch to advance the version to %d, got %d", original.Version+1, movie.Version)
+	}
+	if _, err := svc.PatchMovie(ctx, "Renamed", seen, MoviePatch{Genre: &renamed}); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed for a stale version, got %v", err)
+	}
+	if _, err := svc.GetMovie(ctx, "Original"); !errors.Is(err, repository.ErrMovieNotFound) {
+		t.Fatalf("expected old title to be gone, got %v", err)
+	}
+
+	clash := "TAKEN"
+	if _, err := svc.PatchMovie(ctx, "Renamed", VersionMatch{Any: true}, MoviePatch{Title: &clash}); !errors.Is(err, repository.ErrMovieAlreadyExists) {
+		t.Fatalf("expected ErrMovieAlreadyExists, got %v", err)
+	}
+
+	invalid := "not-a-date"
+	if _, err := svc.PatchMovie(ctx, "Renamed", VersionMatch{Any: true}, MoviePatch{ReleaseDate: &invalid}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestDeleteMovie_SoftDeleteFreesTitleAndRestoreDetectsReuse(t *testing.T) {
+	repo := newMemoryMovieRepository()
+	svc := NewMovieService(repo, stubUnitOfWork{repository.Repositories{Movies: repo}}, stubBoxOfficeClient{}, EnrichSync)
+	ctx := context.Background()
+
+	params := CreateMovieParams{Title: "Ghost", Genre: "Drama", ReleaseDate: "1990-07-13"}
+	if _, err := svc.CreateMovie(ctx, params); err != nil {
+		t.Fatalf("CreateMovie returned error: %v", err)
+	}
+
+	if err := svc.DeleteMovie(ctx, "ghost", false, VersionMatch{Versions: []int64{2}}); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed for a wrong version, got %v", err)
+	}
+	if err := svc.DeleteMovie(ctx, "ghost", false, VersionMatch{Versions: []int64{1}}); err != nil {
+		t.Fatalf("DeleteMovie returned error: %v", err)
+	}
+	if _, err := svc.GetMovie(ctx, "Ghost"); !errors.Is(err, repository.ErrMovieNotFound) {
+		t.Fatalf("expected soft-deleted movie to be hidden, got %v", err)
+	}
+
+	if _, err := svc.CreateMovie(ctx, params); err != nil {
+		t.Fatalf("expected title to be reusable after soft delete, got %v", err)
+	}
+	if _, err := svc.RestoreMovie(ctx, "Ghost"); !errors.Is(err, repository.ErrMovieAlreadyExists) {
+		t.Fatalf("expected ErrMovieAlreadyExists while the title is reused, got %v", err)
+	}
+
+	if err := svc.DeleteMovie(ctx, "Ghost", true, VersionMatch{Any: true}); err != nil {
+		t.Fatalf("hard DeleteMovie returned error: %v", err)
+	}
+	restored, err := svc.RestoreMovie(ctx, "Ghost")
+	if err != nil {
+		t.Fatalf("RestoreMovie returned error: %v", err)
+	}
+	if restored.Title != "Ghost" {
+		t.Fatalf("unexpected restored movie: %+v", restored)
+	}
+}
+
+func TestDeleteMovie_HardDeletePurgesSoftDeletedMovie(t *testing.T) {
+	repo := newMemoryMovieRepository()
+	svc := NewMovieService(repo, stubUnitOfWork{repository.Repositories{Movies: repo}}, stubBoxOfficeClient{}, EnrichSync)
+	ctx := context.Background()
+
+	if _, err := svc.CreateMovie(ctx, CreateMovieParams{Title: "Ghost", Genre: "Drama", ReleaseDate: "1990-07-13"}); err != nil {
+		t.Fatalf("CreateMovie returned error: %v", err)
+	}
+	if err := svc.DeleteMovie(ctx, "Ghost", false, VersionMatch{Any: true}); err != nil {
+		t.Fatalf("DeleteMovie returned error: %v", err)
+	}
+
+	if err := svc.DeleteMovie(ctx, "Ghost", false, VersionMatch{Any: true}); !errors.Is(err, repository.ErrMovieNotFound) {
+		t.Fatalf("expected a second soft delete to find nothing, got %v", err)
+	}
+	if err := svc.DeleteMovie(ctx, "ghost", true, VersionMatch{Any: true}); err != nil {
+		t.Fatalf("hard DeleteMovie returned error: %v", err)
+	}
+	if _, err := svc.RestoreMovie(ctx, "Ghost"); !errors.Is(err, repository.ErrMovieNotFound) {
+		t.Fatalf("expected the purged movie to be gone for good, got %v", err)
+	}
+	if err := svc.DeleteMovie(ctx, "Ghost", true, VersionMatch{Any: true}); !errors.Is(err, repository.ErrMovieNotFound) {
+		t.Fatalf("expected nothing left to purge, got %v", err)
+	}
+}
+
+func TestListMovies_PagesThroughEveryMovieOnce(t *testing.T) {
+	repo := newMemoryMovieRepository()
+	svc := NewMovieService(repo, stubUnitOfWork{repository.Repositories{Movies: repo}}, stubBoxOfficeClient{}, EnrichSync)
+	ctx := context.Background()
+
+	titles := []string{"Alien", "Brazil", "Casino", "Dune", "Heat"}
+	for _, title := range titles {
+		if _, err := svc.CreateMovie(ctx, CreateMovieParams{Title: title, Genre: "Drama", ReleaseDate: "1990-01-01"}); err != nil {
+			t.Fatalf("CreateMovie(%q) returned error: %v", title, err)
+		}
+	}
+
+	// The next page starts after the last movie returned, not after the
+	// look-ahead row, which would otherwise be skipped.
+	seen := map[string]int{}
+	params := ListMoviesParams{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages == len(titles) {
+			t.Fatal("expected pagination to end")
+		}
+		movies, next, err := svc.ListMovies(ctx, params)
+		if err != nil {
+			t.Fatalf("ListMovies returned error: %v", err)
+		}
+		for _, movie := range movies {
+			seen[movie.Title]++
+		}
+		if next == nil {
+			break
+		}
+		params.Cursor = *next
+	}
+
+	for _, title := range titles {
+		if seen[title] != 1 {
+			t.Fatalf("expected every movie exactly once, got %v", seen)
+		}
+	}
+}
@@ -6,45 +6,185 @@ import (
 	"context"
 	"errors"
 	"math"
+	"strings"
 )
 
-var ErrValidation = errors.New("validation error")
+var (
+	ErrValidation = errors.New("validation error")
+	ErrForbidden  = errors.New("operation not permitted")
+)
 
 type RatingService struct {
 	movieRepo  repository.MovieRepository
 	ratingRepo repository.RatingRepository
+	tx         repository.UnitOfWork
+	ranking    RankingConfig
+}
+
+// RankingConfig tunes the Bayesian leaderboard. MinVotes is the weight of the
+// prior in votes; a nil PriorMean falls back to the mean of all ratings.
+type RankingConfig struct {
+	PriorMean *float64
+	MinVotes  int
+}
+
+type TopRatedParams struct {
+	Year        *int
+	Genre       *string
+	Distributor *string
+	BudgetLTE   *int64
+	RevenueGTE  *int64
+	MpaRating   *string
+	Q           string
+	Limit       int
 }
 
-func NewRatingService(movieRepo repository.MovieRepository, ratingRepo repository.RatingRepository) *RatingService {
+// RatingDistribution extends the {average, count} aggregate with the full
+// histogram and derived statistics.
+type RatingDistribution struct {
+	Average   float64
+	Count     int
+	Histogram model.RatingHistogram
+	Median    float64
+	StdDev    float64
+}
+
+type ListRatingsParams struct {
+	MovieTitle string
+	Limit      int
+	Cursor     string
+}
+
+func NewRatingService(movieRepo repository.MovieRepository, ratingRepo repository.RatingRepository, tx repository.UnitOfWork, ranking RankingConfig) *RatingService {
+	if ranking.MinVotes < 0 {
+		ranking.MinVotes = 0
+	}
 	return &RatingService{
 		movieRepo:  movieRepo,
 		ratingRepo: ratingRepo,
+		tx:         tx,
+		ranking:    ranking,
 	}
 }
 
+// UpsertRating looks the movie up and writes the rating in one unit of work,
+// so the rating cannot land on a movie deleted in between.
 func (s *RatingService) UpsertRating(ctx context.Context, movieTitle, raterID string, value float64) (*model.Rating, bool, error) {
 	if !isValidRating(value) {
 		return nil, false, ErrValidation
 	}
 
-	movie, err := s.movieRepo.GetByTitle(ctx, movieTitle)
+	var (
+		rating  *model.Rating
+		created bool
+	)
+	err := s.tx.WithTx(ctx, func(repos repository.Repositories) error {
+		movie, err := repos.Movies.GetByTitle(ctx, movieTitle)
+		if err != nil {
+			return err
+		}
+
+		rating = &model.Rating{
+			MovieID:    movie.ID,
+			MovieTitle: movie.Title,
+			RaterID:    raterID,
+			Value:      value,
+		}
+		created, err = repos.Ratings.Upsert(ctx, rating)
+		return err
+	})
 	if err != nil {
 		return nil, false, err
 	}
 
-	rating := &model.Rating{
-		MovieID:    movie.ID,
-		MovieTitle: movie.Title,
-		RaterID:    raterID,
-		Value:      value,
+	return rating, created, nil
+}
+
+func (s *RatingService) GetRating(ctx context.Context, movieTitle, raterID string) (*model.Rating, error) {
+	movie, err := s.movieRepo.GetByTitle(ctx, movieTitle)
+	if err != nil {
+		return nil, err
 	}
 
-	created, err := s.ratingRepo.Upsert(ctx, rating)
+	rating, err := s.ratingRepo.Get(ctx, movie.ID, raterID)
 	if err != nil {
-		return nil, false, err
+		return nil, err
 	}
+	rating.MovieTitle = movie.Title
 
-	return rating, created, nil
+	return rating, nil
+}
+
+// DeleteRating retracts a rating. Only the rater who submitted it may do so.
+func (s *RatingService) DeleteRating(ctx context.Context, movieTitle, raterID, requesterID string) error {
+	if raterID != requesterID {
+		return ErrForbidden
+	}
+
+	return s.RemoveRating(ctx, movieTitle, raterID)
+}
+
+// RemoveRating deletes any rater's rating; callers must have checked that the
+// requester is allowed to moderate ratings.
+func (s *RatingService) RemoveRating(ctx context.Context, movieTitle, raterID string) error {
+	return s.tx.WithTx(ctx, func(repos repository.Repositories) error {
+		movie, err := repos.Movies.GetByTitle(ctx, movieTitle)
+		if err != nil {
+			return err
+		}
+
+		return repos.Ratings.Delete(ctx, movie.ID, raterID)
+	})
+}
+
+func (s *RatingService) ListRatings(ctx context.Context, params ListRatingsParams) ([]*model.Rating, *string, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	movie, err := s.movieRepo.GetByTitle(ctx, params.MovieTitle)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	listParams := repository.RatingListParams{
+		MovieID: movie.ID,
+		Limit:   limit + 1,
+	}
+
+	if params.Cursor != "" {
+		createdAt, raterID, err := decodeKeysetCursor(params.Cursor)
+		if err != nil {
+			return nil, nil, ErrInvalidInput
+		}
+		listParams.After = &repository.RatingCursor{CreatedAt: createdAt, RaterID: raterID}
+	}
+
+	ratings, err := s.ratingRepo.List(ctx, listParams)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var nextCursor *string
+	if len(ratings) > limit {
+		ratings = ratings[:limit]
+		last := ratings[len(ratings)-1]
+		encoded, err := encodeKeysetCursor(last.CreatedAt, last.RaterID)
+		if err != nil {
+			return nil, nil, err
+		}
+		nextCursor = &encoded
+	}
+
+	for _, rating := range ratings {
+		rating.MovieTitle = movie.Title
+	}
+
+	return ratings, nextCursor, nil
 }
 
 func (s *RatingService) GetAggregatedRating(ctx context.Context, movieTitle string) (float64, int, error) {
@@ -53,7 +193,13 @@ func (s *RatingService) GetAggregatedRating(ctx context.Context, movieTitle stri
 		return 0, 0, err
 	}
 
-	average, count, err := s.ratingRepo.AggregateByMovieID(ctx, movie.ID)
+	return s.AggregateForMovie(ctx, movie.ID)
+}
+
+// AggregateForMovie aggregates ratings for a movie that has already been
+// resolved, avoiding a second title lookup.
+func (s *RatingService) AggregateForMovie(ctx context.Context, movieID string) (float64, int, error) {
+	average, count, err := s.ratingRepo.AggregateByMovieID(ctx, movieID)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -66,6 +212,95 @@ func (s *RatingService) GetAggregatedRating(ctx context.Context, movieTitle stri
 	return rounded, count, nil
 }
 
+// TopRated returns the leaderboard ordered by Bayesian average, so that a
+// handful of perfect scores cannot outrank a large body of good ones.
+func (s *RatingService) TopRated(ctx context.Context, params TopRatedParams) ([]*model.RankedMovie, error) {
+	limit := params.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	ranked, err := s.ratingRepo.TopRated(ctx, repository.TopRatedParams{
+		MovieFilter: repository.MovieFilter{
+			Q:           strings.TrimSpace(params.Q),
+			Year:        params.Year,
+			Genre:       params.Genre,
+			Distributor: params.Distributor,
+			BudgetLTE:   params.BudgetLTE,
+			RevenueGTE:  params.RevenueGTE,
+			MpaRating:   params.MpaRating,
+		},
+		PriorMean: s.ranking.PriorMean,
+		MinVotes:  s.ranking.MinVotes,
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range ranked {
+		entry.Average = math.Round(entry.Average*10) / 10
+		entry.Score = math.Round(entry.Score*100) / 100
+	}
+
+	return ranked, nil
+}
+
+func (s *RatingService) GetRatingDistribution(ctx context.Context, movieTitle string) (*RatingDistribution, error) {
+	movie, err := s.movieRepo.GetByTitle(ctx, movieTitle)
+	if err != nil {
+		return nil, err
+	}
+
+	histogram, err := s.ratingRepo.HistogramByMovieID(ctx, movie.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return distributionFromHistogram(histogram), nil
+}
+
+// distributionFromHistogram derives every statistic from the bucket counts;
+// ratings are discrete, so this is exact.
+func distributionFromHistogram(histogram model.RatingHistogram) *RatingDistribution {
+	dist := &RatingDistribution{Histogram: histogram}
+
+	var sum float64
+	for bucket, count := range histogram {
+		dist.Count += count
+		sum += model.RatingBucketValue(bucket) * float64(count)
+	}
+	if dist.Count == 0 {
+		return dist
+	}
+
+	mean := sum / float64(dist.Count)
+	var squares float64
+	for bucket, count := range histogram {
+		delta := model.RatingBucketValue(bucket) - mean
+		squares += delta * delta * float64(count)
+	}
+
+	dist.Average = math.Round(mean*10) / 10
+	dist.StdDev = math.Round(math.Sqrt(squares/float64(dist.Count))*100) / 100
+	dist.Median = (nthRating(histogram, (dist.Count-1)/2) + nthRating(histogram, dist.Count/2)) / 2
+	return dist
+}
+
+// nthRating returns the zero-based nth smallest rating in the histogram.
+func nthRating(histogram model.RatingHistogram, n int) float64 {
+	for bucket, count := range histogram {
+		if n < count {
+			return model.RatingBucketValue(bucket)
+		}
+		n -= count
+	}
+	return 0
+}
+
 func isValidRating(value float64) bool {
 	if value < 0.5 || value > 5.0 {
 		return false
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"cinema/model"
+	"cinema/repository"
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+type stubRatingRepository struct {
+	ratings  []*model.Rating
+	topRated repository.TopRatedParams
+}
+
+func (r *stubRatingRepository) Upsert(ctx context.Context, rating *model.Rating) (bool, error) {
+	for _, existing := range r.ratings {
+		if existing.MovieID == rating.MovieID && existing.RaterID == rating.RaterID {
+			existing.Value = rating.Value
+			return false, nil
+		}
+	}
+	clone := *rating
+	if clone.CreatedAt.IsZero() {
+		clone.CreatedAt = time.Now()
+	}
+	r.ratings = append(r.ratings, &clone)
+	return true, nil
+}
+
+func (r *stubRatingRepository) Get(ctx context.Context, movieID, raterID string) (*model.Rating, error) {
+	for _, rating := range r.ratings {
+		if rating.MovieID == movieID && rating.RaterID == raterID {
+			clone := *rating
+			return &clone, nil
+		}
+	}
+	return nil, repository.ErrRatingNotFound
+}
+
+func (r *stubRatingRepository) Delete(ctx context.Context, movieID, raterID string) error {
+	for i, rating := range r.ratings {
+		if rating.MovieID == movieID && rating.RaterID == raterID {
+			r.ratings = append(r.ratings[:i], r.ratings[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrRatingNotFound
+}
+
+func (r *stubRatingRepository) List(ctx context.Context, params repository.RatingListParams) ([]*model.Rating, error) {
+	var result []*model.Rating
+	for _, rating := range r.ratings {
+		if rating.MovieID != params.MovieID {
+			continue
+		}
+		if after := params.After; after != nil {
+			if rating.CreatedAt.Before(after.CreatedAt) || (rating.CreatedAt.Equal(after.CreatedAt) && rating.RaterID <= after.RaterID) {
+				continue
+			}
+		}
+		clone := *rating
+		result = append(result, &clone)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+		return result[i].RaterID < result[j].RaterID
+	})
+	if len(result) > params.Limit {
+		result = result[:params.Limit]
+	}
+	return result, nil
+}
+
+func (r *stubRatingRepository) AggregateByMovieID(ctx context.Context, movieID string) (float64, int, error) {
+	var (
+		sum   float64
+		count int
+	)
+	for _, rating := range r.ratings {
+		if rating.MovieID == movieID {
+			sum += rating.Value
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, 0, nil
+	}
+	return sum / float64(count), count, nil
+}
+
+func (r *stubRatingRepository) HistogramByMovieID(ctx context.Context, movieID string) (model.RatingHistogram, error) {
+	var histogram model.RatingHistogram
+	for _, rating := range r.ratings {
+		if rating.MovieID == movieID {
+			histogram[model.RatingBucket(rating.Value)]++
+		}
+	}
+	return histogram, nil
+}
+
+func (r *stubRatingRepository) TopRated(ctx context.Context, params repository.TopRatedParams) ([]*model.RankedMovie, error) {
+	r.topRated = params
+	return []*model.RankedMovie{{Movie: &model.Movie{ID: "m_1"}, Average: 4.8123, Count: 1000, Score: 4.78456}}, nil
+}
+
+func TestListRatings_PagesWithoutSkippingOrRepeating(t *testing.T) {
+	movies := newMemoryMovieRepository()
+	seedMovie(t, movies, &model.Movie{ID: "m_1", Title: "Heat"})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ratings := &stubRatingRepository{}
+	for _, raterID := range []string{"a", "b", "c", "d", "e"} {
+		ratings.Upsert(context.Background(), &model.Rating{MovieID: "m_1", RaterID: raterID, Value: 3, CreatedAt: createdAt})
+	}
+
+	svc := NewRatingService(movies, ratings, stubUnitOfWork{repository.Repositories{Movies: movies, Ratings: ratings}}, RankingConfig{})
+
+	var (
+		seen   []string
+		cursor string
+	)
+	for page := 0; page < 5; page++ {
+		items, next, err := svc.ListRatings(context.Background(), ListRatingsParams{MovieTitle: "heat", Limit: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("ListRatings returned error: %v", err)
+		}
+		for _, item := range items {
+			seen = append(seen, item.RaterID)
+		}
+		if next == nil {
+			break
+		}
+		cursor = *next
+	}
+
+	if got := len(seen); got != 5 {
+		t.Fatalf("expected 5 ratings across pages, got %d (%v)", got, seen)
+	}
+	for i, raterID := range []string{"a", "b", "c", "d", "e"} {
+		if seen[i] != raterID {
+			t.Fatalf("unexpected page order %v", seen)
+		}
+	}
+}
+
+func TestDeleteRating_OnlyOwnerMayRetract(t *testing.T) {
+	movies := newMemoryMovieRepository()
+	seedMovie(t, movies, &model.Movie{ID: "m_1", Title: "Heat"})
+	ratings := &stubRatingRepository{}
+	svc := NewRatingService(movies, ratings, stubUnitOfWork{repository.Repositories{Movies: movies, Ratings: ratings}}, RankingConfig{})
+
+	if _, _, err := svc.UpsertRating(context.Background(), "Heat", "alice", 4.5); err != nil {
+		t.Fatalf("UpsertRating returned error: %v", err)
+	}
+
+	if err := svc.DeleteRating(context.Background(), "Heat", "alice", "mallory"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if err := svc.DeleteRating(context.Background(), "Heat", "alice", "alice"); err != nil {
+		t.Fatalf("DeleteRating returned error: %v", err)
+	}
+	if _, err := svc.GetRating(context.Background(), "Heat", "alice"); !errors.Is(err, repository.ErrRatingNotFound) {
+		t.Fatalf("expected ErrRatingNotFound, got %v", err)
+	}
+}
+
+func TestDistributionFromHistogram_ComputesMedianAndStdDev(t *testing.T) {
+	var histogram model.RatingHistogram
+	histogram[model.RatingBucket(1.0)] = 1
+	histogram[model.RatingBucket(3.0)] = 1
+	histogram[model.RatingBucket(4.0)] = 1
+	histogram[model.RatingBucket(5.0)] = 1
+
+	dist := distributionFromHistogram(histogram)
+	if dist.Count != 4 || dist.Average != 3.3 {
+		t.Fatalf("unexpected aggregate: %+v", dist)
+	}
+	if dist.Median != 3.5 {
+		t.Fatalf("expected median 3.5, got %v", dist.Median)
+	}
+	if dist.StdDev != 1.48 {
+		t.Fatalf("expected stddev 1.48, got %v", dist.StdDev)
+	}
+
+	if empty := distributionFromHistogram(model.RatingHistogram{}); empty.Count != 0 || empty.Median != 0 {
+		t.Fatalf("unexpected empty distribution: %+v", empty)
+	}
+}
+
+func TestTopRated_PassesRankingConfigAndRounds(t *testing.T) {
+	ratings := &stubRatingRepository{}
+	prior := 3.5
+	svc := NewRatingService(newMemoryMovieRepository(), ratings, stubUnitOfWork{}, RankingConfig{PriorMean: &prior, MinVotes: 25})
+
+	genre := "Drama"
+	ranked, err := svc.TopRated(context.Background(), TopRatedParams{Genre: &genre, Limit: 500})
+	if err != nil {
+		t.Fatalf("TopRated returned error: %v", err)
+	}
+
+	got := ratings.topRated
+	if got.MinVotes != 25 || got.PriorMean == nil || *got.PriorMean != prior || got.Limit != 100 || got.Genre == nil || *got.Genre != genre {
+		t.Fatalf("unexpected repository params: %+v", got)
+	}
+	if len(ranked) != 1 || ranked[0].Average != 4.8 || ranked[0].Score != 4.78 {
+		t.Fatalf("unexpected ranking: %+v", ranked[0])
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"cinema/model"
+	"cinema/repository"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const apiTokenPrefix = "cin_"
+
+// TokenService mints, verifies and revokes scoped API tokens. The optional
+// bootstrap token (AUTH_TOKEN) is accepted with admin scope so that the first
+// real tokens can be minted and existing clients keep working.
+type TokenService struct {
+	repo      repository.APITokenRepository
+	bootstrap []byte
+	now       func() time.Time
+}
+
+type MintTokenParams struct {
+	Name   string
+	Scopes []string
+	TTL    time.Duration
+}
+
+func NewTokenService(repo repository.APITokenRepository, bootstrapToken string) *TokenService {
+	s := &TokenService{repo: repo, now: time.Now}
+	if token := strings.TrimSpace(bootstrapToken); token != "" {
+		s.bootstrap = hashToken(token)
+	}
+	return s
+}
+
+// Mint creates a token and returns its plaintext, which is never stored and
+// cannot be recovered later.
+func (s *TokenService) Mint(ctx context.Context, params MintTokenParams) (string, *model.APIToken, error) {
+	name := strings.TrimSpace(params.Name)
+	if name == "" || len(params.Scopes) == 0 || params.TTL < 0 {
+		return "", nil, ErrInvalidInput
+	}
+
+	var scopes []string
+	for _, scope := range params.Scopes {
+		if !slices.Contains(model.KnownScopes, scope) {
+			return "", nil, ErrInvalidInput
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	plaintext := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	token := &model.APIToken{
+		ID:     uuid.NewString(),
+		Name:   name,
+		Scopes: scopes,
+	}
+	if params.TTL > 0 {
+		expiresAt := s.now().Add(params.TTL).UTC()
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.repo.Create(ctx, token, hashToken(plaintext)); err != nil {
+		return "", nil, err
+	}
+	return plaintext, token, nil
+}
+
+func (s *TokenService) Revoke(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return repository.ErrTokenNotFound
+	}
+	return s.repo.Revoke(ctx, id)
+}
+
+func (s *TokenService) List(ctx context.Context) ([]*model.APIToken, error) {
+	return s.repo.List(ctx)
+}
+
+// Authenticate resolves a presented bearer token. Tokens are looked up by
+// their SHA-256 digest, so the plaintext is never compared directly; the
+// bootstrap token is compared in constant time.
+func (s *TokenService) Authenticate(ctx context.Context, plaintext string) (*model.APIToken, error) {
+	if plaintext == "" {
+		return nil, model.ErrUnauthenticated
+	}
+
+	hash := hashToken(plaintext)
+	if s.bootstrap != nil && subtle.ConstantTimeCompare(hash, s.bootstrap) == 1 {
+		return &model.APIToken{ID: "bootstrap", Name: "AUTH_TOKEN", Scopes: []string{model.ScopeAdmin}}, nil
+	}
+	if !strings.HasPrefix(plaintext, apiTokenPrefix) {
+		return nil, model.ErrUnauthenticated
+	}
+
+	token, err := s.repo.GetByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, repository.ErrTokenNotFound) {
+			return nil, model.ErrUnauthenticated
+		}
+		return nil, err
+	}
+	if !token.Active(s.now()) {
+		return nil, model.ErrUnauthenticated
+	}
+	return token, nil
+}
+
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"cinema/model"
+	"cinema/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubTokenRepository struct {
+	tokens map[string]*model.APIToken
+}
+
+func newStubTokenRepository() *stubTokenRepository {
+	return &stubTokenRepository{tokens: make(map[string]*model.APIToken)}
+}
+
+func (r *stubTokenRepository) Create(ctx context.Context, token *model.APIToken, hash []byte) error {
+	token.CreatedAt = time.Now()
+	r.tokens[string(hash)] = token
+	return nil
+}
+
+func (r *stubTokenRepository) GetByHash(ctx context.Context, hash []byte) (*model.APIToken, error) {
+	token, ok := r.tokens[string(hash)]
+	if !ok {
+		return nil, repository.ErrTokenNotFound
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (r *stubTokenRepository) List(ctx context.Context) ([]*model.APIToken, error) {
+	var tokens []*model.APIToken
+	for _, token := range r.tokens {
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+func (r *stubTokenRepository) Revoke(ctx context.Context, id string) error {
+	for _, token := range r.tokens {
+		if token.ID == id {
+			now := time.Now()
+			token.RevokedAt = &now
+			return nil
+		}
+	}
+	return repository.ErrTokenNotFound
+}
+
+func TestTokenServiceMintAuthenticateAndRevoke(t *testing.T) {
+	ctx := context.Background()
+	svc := NewTokenService(newStubTokenRepository(), "")
+
+	plaintext, token, err := svc.Mint(ctx, MintTokenParams{Name: "importer", Scopes: []string{model.ScopeMoviesWrite}})
+	if err != nil {
+		t.Fatalf("Mint returned error: %v", err)
+	}
+
+	resolved, err := svc.Authenticate(ctx, plaintext)
+	if err != nil || resolved.ID != token.ID {
+		t.Fatalf("expected token %s, got %+v (%v)", token.ID, resolved, err)
+	}
+	if !resolved.HasScope(model.ScopeMoviesWrite) || resolved.HasScope(model.ScopeAdmin) {
+		t.Fatalf("unexpected scopes %v", resolved.Scopes)
+	}
+
+	if err := svc.Revoke(ctx, token.ID); err != nil {
+		t.Fatalf("Revoke returned error: %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, plaintext); !errors.Is(err, model.ErrUnauthenticated) {
+		t.Fatalf("expected revoked token to be rejected, got %v", err)
+	}
+}
+
+func TestTokenServiceRejectsExpiredAndUnknownScopes(t *testing.T) {
+	ctx := context.Background()
+	svc := NewTokenService(newStubTokenRepository(), "")
+
+	if _, _, err := svc.Mint(ctx, MintTokenParams{Name: "bad", Scopes: []string{"movies:delete"}}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput for unknown scope, got %v", err)
+	}
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	plaintext, _, err := svc.Mint(ctx, MintTokenParams{Name: "short", Scopes: []string{model.ScopeMoviesWrite}, TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("Mint returned error: %v", err)
+	}
+
+	svc.now = func() time.Time { return now.Add(2 * time.Hour) }
+	if _, err := svc.Authenticate(ctx, plaintext); !errors.Is(err, model.ErrUnauthenticated) {
+		t.Fatalf("expected expired token to be rejected, got %v", err)
+	}
+}
+
+func TestTokenServiceAcceptsBootstrapTokenAsAdmin(t *testing.T) {
+	svc := NewTokenService(newStubTokenRepository(), "static-secret")
+
+	token, err := svc.Authenticate(context.Background(), "static-secret")
+	if err != nil || !token.HasScope(model.ScopeMoviesWrite) {
+		t.Fatalf("expected bootstrap token to be accepted with every scope, got %+v (%v)", token, err)
+	}
+
+	if _, err := svc.Authenticate(context.Background(), "static-secreT"); !errors.Is(err, model.ErrUnauthenticated) {
+		t.Fatalf("expected wrong token to be rejected, got %v", err)
+	}
+}